@@ -1,28 +1,160 @@
 package main
 
 import (
-	"log"
-	"net/http"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
+	"sort"
+	"strings"
 
+	"github.com/aleury/goreddit"
+	"github.com/aleury/goreddit/config"
+	"github.com/aleury/goreddit/memory"
 	"github.com/aleury/goreddit/postgres"
 	"github.com/aleury/goreddit/web"
+	"github.com/alexedwards/scs/v2"
 )
 
+const usage = `usage: goreddit [command]
+
+commands:
+	serve [flags]                            serve the site (the default); see serve -h
+	migrate up [N]                           apply all pending migrations, or the next N
+	migrate down N | -all                    undo the last N migrations, or all of them
+	migrate status                           list the migrations and whether they are applied
+	user create [-password P] [-admin] NAME  register a user
+	user promote NAME                        make a user an admin
+	user reset-password [-password P] NAME   set a new password for a user
+	thread create -title T -description D [-author NAME]
+	                                         create a thread
+	thread delete ID                         delete a thread with all its posts
+	seed                                     fill an empty database with sample content
+
+Commands other than serve need Postgres, which they reach through
+DATA_SOURCE_NAME, set in the environment or in the file named by
+CONFIG_FILE. Commands that generate a password print it.`
+
+// errUsage reports that a command was called with the wrong arguments.
+var errUsage = errors.New("invalid arguments")
+
+var commands = map[string]func(ctx context.Context, args []string) error{
+	"serve":   runServe,
+	"migrate": runMigrate,
+	"user":    runUser,
+	"thread":  runThread,
+	"seed":    runSeed,
+}
+
 func main() {
-	dsn := os.Getenv("DATA_SOURCE_NAME")
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	run, ok := commands[name]
+	if !ok {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	err := run(context.Background(), args)
+	if errors.Is(err, errUsage) {
+		if err != errUsage {
+			fmt.Fprintf(os.Stderr, "goreddit %s: %v\n", name, err)
+		}
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "goreddit %s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+// newStore opens the store selected by driver along with a session manager
+// that keeps sessions in the same place.
+func newStore(driver, dsn string) (goreddit.Store, *scs.SessionManager, error) {
+	switch driver {
+	case "", "postgres":
+		store, err := postgres.NewStore(dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		sessions, err := web.NewSessionManager(dsn)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	store, err := postgres.NewStore(dsn)
+		return store, sessions, nil
+	case "memory":
+		return memory.NewStore(), web.NewMemorySessionManager(), nil
+	default:
+		return nil, nil, fmt.Errorf("unknown store %q", driver)
+	}
+}
+
+// dataSourceName returns the Postgres connection string of the
+// configuration, for commands that need Postgres, since the memory store
+// would forget their changes as soon as they exit.
+func dataSourceName() (string, error) {
+	cfg, err := config.Load("", nil, os.Getenv)
 	if err != nil {
-		log.Fatal(err)
+		return "", err
+	}
+	if cfg.Store != "postgres" {
+		return "", fmt.Errorf("this command needs the postgres store, not %q", cfg.Store)
 	}
+	return cfg.DataSourceName, nil
+}
 
-	sessions, err := web.NewSessionManager(dsn)
+// openStore opens the Postgres store for commands that change data.
+func openStore() (goreddit.Store, error) {
+	dsn, err := dataSourceName()
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	return postgres.NewStore(dsn)
+}
+
+// parseArgs parses the flags of fs in args, wherever they appear, and
+// returns the other arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	for {
+		if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+			return nil, errUsage
+		} else if err != nil {
+			return nil, fmt.Errorf("%v: %w", err, errUsage)
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		rest = append(rest, fs.Arg(0))
+		args = fs.Args()[1:]
 	}
+	return rest, nil
+}
+
+// newFlagSet returns a flag set for a command that leaves printing usage to
+// main.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(discard{})
+	return fs
+}
 
-	csrfKey := []byte("01234567890123456789012345678901")
-	h := web.NewHandler(store, sessions, csrfKey)
-	http.ListenAndServe(":3000", h)
+type discard struct{}
+
+func (discard) Write(p []byte) (int, error) { return len(p), nil }
+
+// formErrors joins the errors of a web form into one error.
+func formErrors(errs web.FormErrors) error {
+	msgs := make([]string, 0, len(errs))
+	for _, msg := range errs {
+		msgs = append(msgs, msg)
+	}
+	sort.Strings(msgs)
+	return errors.New(strings.Join(msgs, " "))
 }
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aleury/goreddit/postgres"
+)
+
+func runMigrate(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	fs := newFlagSet("migrate " + args[0])
+	all := fs.Bool("all", false, "undo every migration")
+	rest, err := parseArgs(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	if len(rest) > 1 {
+		return errUsage
+	}
+
+	n := 0
+	if len(rest) == 1 {
+		n, err = strconv.Atoi(rest[0])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("%q is not a number of migrations: %w", rest[0], errUsage)
+		}
+	}
+
+	switch args[0] {
+	case "up", "status":
+		if *all || (args[0] == "status" && n != 0) {
+			return errUsage
+		}
+	case "down":
+		// Undoing every migration drops all the data, so it has to be
+		// asked for.
+		if *all == (n != 0) {
+			return errUsage
+		}
+	default:
+		return errUsage
+	}
+
+	dsn, err := dataSourceName()
+	if err != nil {
+		return err
+	}
+	m, err := postgres.NewMigrator(dsn)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx, n)
+		for _, mig := range applied {
+			fmt.Printf("applied %d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("no migrations to apply")
+		}
+		return err
+	case "down":
+		undone, err := m.Down(ctx, n)
+		for _, mig := range undone {
+			fmt.Printf("undid %d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(undone) == 0 {
+			fmt.Println("no migrations to undo")
+		}
+		return err
+	default:
+		mm, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, mig := range mm {
+			status := "pending"
+			if mig.Applied {
+				status = "applied " + mig.AppliedAt.Format("2006-01-02 15:04")
+			}
+			fmt.Printf("%-24s %d_%s\n", status, mig.Version, mig.Name)
+		}
+		return nil
+	}
+}
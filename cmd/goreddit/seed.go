@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// seedUsers are the users the seed command registers. The first one is made
+// an admin.
+var seedUsers = []string{"admin", "alice", "bob", "carol"}
+
+// seedThreads is the sample content of the seed command. Each thread is
+// created by its first post's author, and each post's comments are written by
+// the other seed users in turn.
+var seedThreads = []struct {
+	title, description string
+	posts              []seedPost
+}{
+	{
+		title:       "Go",
+		description: "News and questions about the Go programming language.",
+		posts: []seedPost{
+			{"alice", "What is your favourite standard library package?", "Mine is net/http, it gets you surprisingly far.", []string{"encoding/json, despite its quirks.", "context, once it clicked."}},
+			{"bob", "Go 1.17 is out", "Module graph pruning and faster function calls.", []string{"The register ABI is a nice free speedup."}},
+		},
+	},
+	{
+		title:       "Cooking",
+		description: "Recipes, techniques and kitchen disasters.",
+		posts: []seedPost{
+			{"carol", "The easiest bread you will ever make", "Flour, water, salt and yeast. Leave it overnight and bake it in a hot pot.", []string{"Tried it, it works!", "How hot is hot?", "250°C with the lid on."}},
+		},
+	},
+	{
+		title:       "Meta",
+		description: "Discussion about this site.",
+		posts: []seedPost{
+			{"admin", "Welcome", "This instance has been filled with sample content. Feel free to delete it.", nil},
+		},
+	},
+}
+
+type seedPost struct {
+	author, title, content string
+	comments               []string
+}
+
+func runSeed(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+	return seed(ctx, store)
+}
+
+// seed fills an empty store with sample users, threads, posts, comments and
+// votes. All the users share one generated password, which it prints.
+func seed(ctx context.Context, store goreddit.Store) error {
+	stats, err := store.Stats(ctx)
+	if err != nil {
+		return err
+	}
+	if stats.Users > 0 || stats.Threads > 0 {
+		return errors.New("the database is not empty; seed only fills new instances")
+	}
+
+	password, _, err := choosePassword("")
+	if err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	users := map[string]uuid.UUID{}
+	for _, username := range seedUsers {
+		u := goreddit.User{ID: uuid.New(), Username: username, Password: string(hash)}
+		if err := store.CreateUser(ctx, &u); err != nil {
+			return err
+		}
+		users[username] = u.ID
+	}
+	if err := promote(ctx, store, seedUsers[0]); err != nil {
+		return err
+	}
+
+	for _, st := range seedThreads {
+		t := goreddit.Thread{
+			ID:          uuid.New(),
+			Title:       st.title,
+			Description: st.description,
+			AuthorID:    author(users, st.posts[0].author),
+		}
+		if err := store.CreateThread(ctx, &t); err != nil {
+			return err
+		}
+
+		for _, sp := range st.posts {
+			p := goreddit.Post{
+				ID:       uuid.New(),
+				ThreadID: t.ID,
+				Title:    sp.title,
+				Content:  sp.content,
+				AuthorID: author(users, sp.author),
+			}
+			if err := store.CreatePost(ctx, &p); err != nil {
+				return err
+			}
+
+			commenters := others(sp.author)
+			for i, content := range sp.comments {
+				commenter := commenters[i%len(commenters)]
+				c := goreddit.Comment{
+					ID:       uuid.New(),
+					PostID:   p.ID,
+					Content:  content,
+					AuthorID: author(users, commenter),
+				}
+				if err := store.CreateComment(ctx, &c); err != nil {
+					return err
+				}
+				if err := store.CastCommentVote(ctx, users[sp.author], c.ID, 1); err != nil {
+					return err
+				}
+			}
+
+			for _, voter := range commenters {
+				if err := store.CastPostVote(ctx, users[voter], p.ID, 1); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	fmt.Printf("Created %d users, %s being an admin, and %d threads.\n", len(seedUsers), seedUsers[0], len(seedThreads))
+	fmt.Printf("password: %s\n", password)
+	return nil
+}
+
+func author(users map[string]uuid.UUID, username string) uuid.NullUUID {
+	return uuid.NullUUID{UUID: users[username], Valid: true}
+}
+
+// others returns the seed users other than username.
+func others(username string) []string {
+	var uu []string
+	for _, u := range seedUsers {
+		if u != username {
+			uu = append(uu, u)
+		}
+	}
+	return uu
+}
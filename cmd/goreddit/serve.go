@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/aleury/goreddit/config"
+	"github.com/aleury/goreddit/postgres"
+	"github.com/aleury/goreddit/web"
+)
+
+func runServe(ctx context.Context, args []string) error {
+	cfg, err := config.Load("serve", args, os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, "usage: goreddit serve [flags]\n\nEach flag can also be set by an environment variable or in the config file.")
+		config.Usage(os.Stderr)
+		os.Exit(2)
+	} else if err != nil {
+		return fmt.Errorf("%v: %w", err, errUsage)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if cfg.AutoMigrate && cfg.Store == "postgres" {
+		if err := migrateUp(ctx, cfg.DataSourceName); err != nil {
+			return err
+		}
+	}
+
+	store, sessions, err := newStore(cfg.Store, cfg.DataSourceName)
+	if err != nil {
+		return err
+	}
+	// The servers are shut down before the stores are closed, so requests
+	// in flight still have them.
+	defer closeStore(sessions.Store, "session store")
+	defer closeStore(store, "store")
+	sessions.Lifetime = cfg.SessionLifetime
+	sessions.Cookie.Secure = cfg.SecureCookies
+
+	h := web.NewHandler(store, sessions, web.Options{
+		CSRFKey:       cfg.CSRFKey,
+		SecureCookies: cfg.SecureCookies,
+		TemplateDir:   cfg.TemplateDir,
+		LogRequests:   cfg.LogLevel == config.LogInfo,
+		Features: web.Features{
+			Registration: cfg.Registration,
+			API:          cfg.API,
+		},
+	})
+
+	servers := []*http.Server{newServer(cfg, cfg.Addr, h)}
+	if cfg.RedirectAddr != "" {
+		servers = append(servers, newServer(cfg, cfg.RedirectAddr, redirectToHTTPS(cfg.Addr)))
+	}
+	return serve(ctx, cfg, servers)
+}
+
+func newServer(cfg config.Config, addr string, h http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: cfg.ReadTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
+
+// serve runs the servers until one of them fails or the process is asked to
+// stop by SIGINT or SIGTERM. It then shuts them all down, giving the requests
+// in flight up to cfg.ShutdownTimeout to finish. The first server serves HTTPS
+// if the configuration has a certificate; the others serve plain HTTP.
+func serve(ctx context.Context, cfg config.Config, servers []*http.Server) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, len(servers))
+	for i, srv := range servers {
+		go func(srv *http.Server, tls bool) {
+			var err error
+			if tls {
+				log.Printf("serving HTTPS on %s", srv.Addr)
+				err = srv.ListenAndServeTLS(cfg.TLSCert, cfg.TLSKey)
+			} else {
+				log.Printf("serving HTTP on %s", srv.Addr)
+				err = srv.ListenAndServe()
+			}
+			errc <- err
+		}(srv, i == 0 && cfg.TLSCert != "")
+	}
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		log.Print("shutting down")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	for _, srv := range servers {
+		if serr := srv.Shutdown(shutdownCtx); serr != nil && err == nil {
+			err = fmt.Errorf("error shutting down %s: %w", srv.Addr, serr)
+		}
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// redirectToHTTPS returns a handler that sends requests to the same URL over
+// HTTPS, served on addr.
+func redirectToHTTPS(addr string) http.Handler {
+	_, port, _ := net.SplitHostPort(addr)
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(rw, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+// closeStore closes s if it holds resources such as database connections.
+func closeStore(s interface{}, name string) {
+	if c, ok := s.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			log.Printf("error closing %s: %v", name, err)
+		}
+	}
+}
+
+// migrateUp applies the pending migrations. Replicas started together wait
+// for each other, so only the first one applies them.
+func migrateUp(ctx context.Context, dsn string) error {
+	m, err := postgres.NewMigrator(dsn)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	applied, err := m.Up(ctx, 0)
+	for _, mig := range applied {
+		log.Printf("applied migration %d_%s", mig.Version, mig.Name)
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aleury/goreddit"
+	"github.com/aleury/goreddit/web"
+	"github.com/google/uuid"
+)
+
+func runThread(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "create":
+		fs := newFlagSet("thread create")
+		title := fs.String("title", "", "the title of the thread")
+		description := fs.String("description", "", "the description of the thread")
+		author := fs.String("author", "", "the username of the author, who becomes the thread's first moderator")
+		rest, err := parseArgs(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(rest) != 0 {
+			return errUsage
+		}
+
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		return createThread(ctx, store, *title, *description, *author)
+	case "delete":
+		rest, err := parseArgs(newFlagSet("thread delete"), args[1:])
+		if err != nil {
+			return err
+		}
+		if len(rest) != 1 {
+			return errUsage
+		}
+		id, err := uuid.Parse(rest[0])
+		if err != nil {
+			return fmt.Errorf("%q is not a thread ID: %w", rest[0], errUsage)
+		}
+
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		return deleteThread(ctx, store, id)
+	default:
+		return errUsage
+	}
+}
+
+// createThread creates a thread, written by the user with the author username
+// unless it is empty.
+func createThread(ctx context.Context, store goreddit.Store, title, description, author string) error {
+	form := web.CreateThreadForm{Title: title, Description: description}
+	if !form.Validate() {
+		return formErrors(form.Errors)
+	}
+
+	t := goreddit.Thread{
+		ID:          uuid.New(),
+		Title:       title,
+		Description: description,
+	}
+	if author != "" {
+		u, err := store.UserByUsername(ctx, author)
+		if err != nil {
+			return fmt.Errorf("error finding %s: %w", author, err)
+		}
+		t.AuthorID = uuid.NullUUID{UUID: u.ID, Valid: true}
+	}
+
+	if err := store.CreateThread(ctx, &t); err != nil {
+		return err
+	}
+	fmt.Printf("%s has been created with the ID %s.\n", t.Title, t.ID)
+	return nil
+}
+
+// deleteThread deletes a thread with its posts and records it in the mod log
+// like a deletion from the site.
+func deleteThread(ctx context.Context, store goreddit.Store, id uuid.UUID) error {
+	t, err := store.Thread(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := store.DeleteThread(ctx, t.ID); err != nil {
+		return err
+	}
+
+	err = store.CreateModLogEntry(ctx, &goreddit.ModLogEntry{
+		ID:          uuid.New(),
+		ThreadID:    uuid.NullUUID{UUID: t.ID, Valid: true},
+		ThreadTitle: t.Title,
+		Action:      goreddit.ModDeleteThread,
+		TargetKind:  goreddit.ModTargetThread,
+		TargetID:    t.ID,
+		TargetLabel: t.Title,
+		Reason:      "Deleted from the command line",
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s has been deleted.\n", t.Title)
+	return nil
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/aleury/goreddit"
+	"github.com/aleury/goreddit/web"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func runUser(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	fs := newFlagSet("user " + args[0])
+	password := fs.String("password", "", "the password; a random one is generated if it is empty")
+	var admin *bool
+	if args[0] == "create" {
+		admin = fs.Bool("admin", false, "make the user an admin")
+	}
+	rest, err := parseArgs(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return errUsage
+	}
+	username := rest[0]
+
+	var run func(context.Context, goreddit.Store) error
+	switch args[0] {
+	case "create":
+		run = func(ctx context.Context, store goreddit.Store) error {
+			return createUser(ctx, store, username, *password, *admin)
+		}
+	case "promote":
+		if *password != "" {
+			return errUsage
+		}
+		run = func(ctx context.Context, store goreddit.Store) error {
+			if err := promote(ctx, store, username); err != nil {
+				return err
+			}
+			fmt.Printf("%s is now an admin.\n", username)
+			return nil
+		}
+	case "reset-password":
+		run = func(ctx context.Context, store goreddit.Store) error {
+			return resetPassword(ctx, store, username, *password)
+		}
+	default:
+		return errUsage
+	}
+
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+	return run(ctx, store)
+}
+
+// createUser registers a user, printing the password if it generates one.
+func createUser(ctx context.Context, store goreddit.Store, username, password string, admin bool) error {
+	password, generated, err := choosePassword(password)
+	if err != nil {
+		return err
+	}
+
+	_, err = store.UserByUsername(ctx, username)
+	form := web.RegisterForm{
+		Username:      username,
+		Password:      password,
+		UsernameTaken: err == nil,
+	}
+	if err != nil && !errors.Is(err, goreddit.ErrNotFound) {
+		return fmt.Errorf("error creating %s: %w", username, err)
+	}
+	if !form.Validate() {
+		return formErrors(form.Errors)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	err = store.CreateUser(ctx, &goreddit.User{
+		ID:       uuid.New(),
+		Username: username,
+		Password: string(hash),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating %s: %w", username, err)
+	}
+	fmt.Printf("%s has been created.\n", username)
+	if generated {
+		fmt.Printf("password: %s\n", password)
+	}
+
+	if admin {
+		if err := promote(ctx, store, username); err != nil {
+			return err
+		}
+		fmt.Printf("%s is now an admin.\n", username)
+	}
+	return nil
+}
+
+// promote makes the user with username an admin. It is how the first admin of
+// a site is made, since only admins can promote users from the admin area.
+func promote(ctx context.Context, store goreddit.Store, username string) error {
+	u, err := store.UserByUsername(ctx, username)
+	if err != nil {
+		return fmt.Errorf("error promoting %s: %w", username, err)
+	}
+	if u.IsAdmin() {
+		return nil
+	}
+
+	if err := store.SetUserRole(ctx, u.ID, goreddit.RoleAdmin); err != nil {
+		return fmt.Errorf("error promoting %s: %w", username, err)
+	}
+
+	return store.CreateModLogEntry(ctx, &goreddit.ModLogEntry{
+		ID:          uuid.New(),
+		Action:      goreddit.ModPromoteAdmin,
+		TargetKind:  goreddit.ModTargetUser,
+		TargetID:    u.ID,
+		TargetLabel: u.Username,
+		Reason:      "Promoted from the command line",
+	})
+}
+
+// resetPassword gives the user with username a new password, printing it if
+// it generates one.
+func resetPassword(ctx context.Context, store goreddit.Store, username, password string) error {
+	password, generated, err := choosePassword(password)
+	if err != nil {
+		return err
+	}
+
+	u, err := store.UserByUsername(ctx, username)
+	if err != nil {
+		return fmt.Errorf("error resetting the password of %s: %w", username, err)
+	}
+
+	// Validate the password the way registering does; the username is
+	// known to be fine.
+	form := web.RegisterForm{Username: username, Password: password}
+	if !form.Validate() {
+		return formErrors(form.Errors)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	u.Password = string(hash)
+	if err := store.UpdateUser(ctx, &u); err != nil {
+		return fmt.Errorf("error resetting the password of %s: %w", username, err)
+	}
+	fmt.Printf("The password of %s has been reset.\n", username)
+	if generated {
+		fmt.Printf("password: %s\n", password)
+	}
+	return nil
+}
+
+// choosePassword returns password, or a random one if it is empty, and
+// whether it generated it.
+func choosePassword(password string) (string, bool, error) {
+	if password != "" {
+		return password, false, nil
+	}
+
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", false, fmt.Errorf("error generating a password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), true, nil
+}
@@ -0,0 +1,309 @@
+// Package config loads the settings of a goreddit server from flags,
+// environment variables and an optional config file.
+package config
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Modes a server can run in. Production refuses the insecure defaults that
+// make development convenient.
+const (
+	Development = "development"
+	Production  = "production"
+)
+
+// Log levels. Info logs every request as well as errors.
+const (
+	LogInfo  = "info"
+	LogError = "error"
+)
+
+// devCSRFKey is the CSRF key used in development unless another is given.
+// It is public, so production refuses it.
+var devCSRFKey = []byte("01234567890123456789012345678901")
+
+type Config struct {
+	Mode string
+
+	Addr string
+	// TLSCert and TLSKey name the certificate and key files to serve HTTPS
+	// with. Plain HTTP is served if they are empty.
+	TLSCert string
+	TLSKey  string
+	// RedirectAddr is the address to listen on for plain HTTP requests to
+	// redirect to HTTPS. No redirects are served if it is empty.
+	RedirectAddr string
+
+	// ReadTimeout, WriteTimeout and IdleTimeout limit how long the server
+	// reads a request, writes a response and keeps an idle connection.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// ShutdownTimeout is how long the server waits for the requests in
+	// flight to finish when it is asked to stop.
+	ShutdownTimeout time.Duration
+
+	Store string
+	// DataSourceName is the Postgres connection string.
+	DataSourceName string
+	// AutoMigrate applies pending migrations before serving.
+	AutoMigrate bool
+
+	// CSRFKey authenticates the CSRF cookie and must be 32 bytes long.
+	// Sessions need no secret, since their tokens are random and kept on
+	// the server.
+	CSRFKey []byte
+	// SecureCookies restricts the session and CSRF cookies to HTTPS.
+	SecureCookies   bool
+	SessionLifetime time.Duration
+
+	TemplateDir string
+	LogLevel    string
+
+	// Registration lets visitors create accounts, on the site and through
+	// the API.
+	Registration bool
+	// API serves the JSON API and lets users create API tokens.
+	API bool
+}
+
+// envNames maps each flag onto the environment variable, and the config file
+// key, that sets it too.
+var envNames = map[string]string{
+	"mode":             "MODE",
+	"addr":             "LISTEN_ADDR",
+	"tls-cert":         "TLS_CERT",
+	"tls-key":          "TLS_KEY",
+	"redirect-addr":    "REDIRECT_ADDR",
+	"read-timeout":     "READ_TIMEOUT",
+	"write-timeout":    "WRITE_TIMEOUT",
+	"idle-timeout":     "IDLE_TIMEOUT",
+	"shutdown-timeout": "SHUTDOWN_TIMEOUT",
+	"store":            "STORE",
+	"data-source-name": "DATA_SOURCE_NAME",
+	"migrate":          "AUTO_MIGRATE",
+	"csrf-key":         "CSRF_KEY",
+	"secure-cookies":   "SECURE_COOKIES",
+	"session-lifetime": "SESSION_LIFETIME",
+	"template-dir":     "TEMPLATE_DIR",
+	"log-level":        "LOG_LEVEL",
+	"registration":     "ENABLE_REGISTRATION",
+	"api":              "ENABLE_API",
+}
+
+// fileEnv is the environment variable naming the config file, which the
+// config flag overrides.
+const fileEnv = "CONFIG_FILE"
+
+// flagSet returns a flag set for the settings of c, with their current values
+// as defaults, plus the config flag naming a config file.
+func (c *Config) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&c.Mode, "mode", c.Mode, `"development" or "production"`)
+	fs.StringVar(&c.Addr, "addr", c.Addr, "the address to listen on")
+	fs.StringVar(&c.TLSCert, "tls-cert", c.TLSCert, "the certificate file to serve HTTPS with")
+	fs.StringVar(&c.TLSKey, "tls-key", c.TLSKey, "the key file of the certificate")
+	fs.StringVar(&c.RedirectAddr, "redirect-addr", c.RedirectAddr, "the address to redirect plain HTTP to HTTPS on")
+	fs.DurationVar(&c.ReadTimeout, "read-timeout", c.ReadTimeout, "how long reading a request may take")
+	fs.DurationVar(&c.WriteTimeout, "write-timeout", c.WriteTimeout, "how long writing a response may take")
+	fs.DurationVar(&c.IdleTimeout, "idle-timeout", c.IdleTimeout, "how long an idle connection is kept open")
+	fs.DurationVar(&c.ShutdownTimeout, "shutdown-timeout", c.ShutdownTimeout, "how long to wait for requests in flight when stopping")
+	fs.StringVar(&c.Store, "store", c.Store, `the store, "postgres" or "memory"`)
+	fs.StringVar(&c.DataSourceName, "data-source-name", c.DataSourceName, "the Postgres connection string")
+	fs.BoolVar(&c.AutoMigrate, "migrate", c.AutoMigrate, "apply pending migrations before serving")
+	fs.Var((*hexKey)(&c.CSRFKey), "csrf-key", "the CSRF key, as 64 hex digits")
+	fs.BoolVar(&c.SecureCookies, "secure-cookies", c.SecureCookies, "only send cookies over HTTPS")
+	fs.DurationVar(&c.SessionLifetime, "session-lifetime", c.SessionLifetime, "how long a login lasts")
+	fs.StringVar(&c.TemplateDir, "template-dir", c.TemplateDir, "the directory holding the templates")
+	fs.StringVar(&c.LogLevel, "log-level", c.LogLevel, `"info" to log requests, or "error"`)
+	fs.BoolVar(&c.Registration, "registration", c.Registration, "let visitors create accounts")
+	fs.BoolVar(&c.API, "api", c.API, "serve the JSON API")
+	fs.String("config", "", "a file of NAME=value lines, named like the environment variables")
+	return fs
+}
+
+// Default returns the settings used in development when nothing else is set.
+func Default() Config {
+	return Config{
+		Mode:            Development,
+		Addr:            ":3000",
+		ReadTimeout:     10 * time.Second,
+		WriteTimeout:    30 * time.Second,
+		IdleTimeout:     2 * time.Minute,
+		ShutdownTimeout: 30 * time.Second,
+		Store:           "postgres",
+		CSRFKey:         devCSRFKey,
+		SessionLifetime: 24 * time.Hour,
+		TemplateDir:     "templates",
+		LogLevel:        LogInfo,
+		Registration:    true,
+		API:             true,
+	}
+}
+
+// Usage writes a description of the flags and the environment variables
+// matching them to w.
+func Usage(w io.Writer) {
+	c := Default()
+	fs := c.flagSet("")
+	fs.VisitAll(func(f *flag.Flag) {
+		env := envNames[f.Name]
+		if env == "" {
+			env = fileEnv
+		}
+		fmt.Fprintf(w, "  -%s, %s\n    \t%s", f.Name, env, f.Usage)
+		if f.DefValue != "" && f.DefValue != "false" {
+			fmt.Fprintf(w, " (default %s)", f.DefValue)
+		}
+		fmt.Fprintln(w)
+	})
+}
+
+// Load returns the default settings overridden by the config file, then by
+// the environment, as read by getenv, and then by the flags in args. It only
+// checks that each value is well formed; Validate checks the whole.
+func Load(name string, args []string, getenv func(string) string) (Config, error) {
+	c := Default()
+	fs := c.flagSet(name)
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if fs.NArg() > 0 {
+		return Config{}, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	given := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	values := map[string]string{}
+	file := fs.Lookup("config").Value.String()
+	if file == "" {
+		file = getenv(fileEnv)
+	}
+	if file != "" {
+		var err error
+		values, err = readFile(file)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+	for _, env := range envNames {
+		if v := getenv(env); v != "" {
+			values[env] = v
+		}
+	}
+
+	for flagName, env := range envNames {
+		v, ok := values[env]
+		if !ok || given[flagName] {
+			continue
+		}
+		if err := fs.Set(flagName, v); err != nil {
+			return Config{}, fmt.Errorf("invalid %s %q: %w", env, v, err)
+		}
+	}
+
+	return c, nil
+}
+
+// readFile reads a config file of NAME=value lines. Blank lines and lines
+// starting with # are ignored.
+func readFile(name string) (map[string]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+	defer f.Close()
+
+	known := map[string]bool{}
+	for _, env := range envNames {
+		known[env] = true
+	}
+
+	values := map[string]string{}
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("%s:%d: want NAME=value", name, n)
+		}
+		key, value := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		if !known[key] {
+			return nil, fmt.Errorf("%s:%d: unknown setting %s", name, n, key)
+		}
+		values[key] = value
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+	return values, nil
+}
+
+// Validate checks that the settings make sense together. In production it
+// also refuses settings that are only safe in development.
+func (c Config) Validate() error {
+	var errs []string
+	check := func(ok bool, msg string) {
+		if !ok {
+			errs = append(errs, msg)
+		}
+	}
+
+	check(c.Mode == Development || c.Mode == Production, `MODE must be "development" or "production"`)
+	check(c.Addr != "", "LISTEN_ADDR must be set")
+	check((c.TLSCert == "") == (c.TLSKey == ""), "TLS_CERT and TLS_KEY must be set together")
+	check(c.RedirectAddr == "" || c.TLSCert != "", "REDIRECT_ADDR needs TLS_CERT and TLS_KEY")
+	check(c.ReadTimeout > 0 && c.WriteTimeout > 0 && c.IdleTimeout > 0, "READ_TIMEOUT, WRITE_TIMEOUT and IDLE_TIMEOUT must be positive")
+	check(c.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT must be positive")
+	check(c.Store == "postgres" || c.Store == "memory", `STORE must be "postgres" or "memory"`)
+	check(len(c.CSRFKey) == 32, "CSRF_KEY must be 32 bytes long")
+	check(c.SessionLifetime > 0, "SESSION_LIFETIME must be positive")
+	check(c.LogLevel == LogInfo || c.LogLevel == LogError, `LOG_LEVEL must be "info" or "error"`)
+	if fi, err := os.Stat(c.TemplateDir); err != nil || !fi.IsDir() {
+		errs = append(errs, fmt.Sprintf("TEMPLATE_DIR %q is not a directory", c.TemplateDir))
+	}
+
+	if c.Mode == Production {
+		check(string(c.CSRFKey) != string(devCSRFKey), "CSRF_KEY must be set to a secret key in production")
+		check(c.SecureCookies, "SECURE_COOKIES must be true in production")
+		check(c.Store == "postgres", "the memory store cannot be used in production")
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// hexKey is a key given as hex digits on the command line.
+type hexKey []byte
+
+func (k *hexKey) String() string {
+	if k == nil {
+		return ""
+	}
+	return hex.EncodeToString(*k)
+}
+
+func (k *hexKey) Set(s string) error {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return errors.New("not hex digits")
+	}
+	*k = b
+	return nil
+}
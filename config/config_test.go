@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func TestLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "goreddit.conf")
+	err := os.WriteFile(file, []byte(`
+# Settings for the tests.
+LISTEN_ADDR = :4000
+LOG_LEVEL=error
+SESSION_LIFETIME=1h
+`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load("serve", []string{"-log-level", "info", "-api=false"}, env(map[string]string{
+		"CONFIG_FILE":      file,
+		"SESSION_LIFETIME": "2h",
+		"LOG_LEVEL":        "error",
+	}))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if c.Addr != ":4000" {
+		t.Errorf("Addr = %q, want the file's :4000", c.Addr)
+	}
+	if c.SessionLifetime != 2*time.Hour {
+		t.Errorf("SessionLifetime = %v, want the environment's 2h", c.SessionLifetime)
+	}
+	if c.LogLevel != LogInfo || c.API {
+		t.Errorf("LogLevel, API = %q, %v, want the flags' info, false", c.LogLevel, c.API)
+	}
+	if c.Mode != Development || c.Store != "postgres" || !c.Registration {
+		t.Errorf("Load = %+v, want defaults for the settings not given", c)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "goreddit.conf")
+	if err := os.WriteFile(file, []byte("PORT=3000\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		args []string
+		env  map[string]string
+	}{
+		"unknown flag":       {args: []string{"-port", "3000"}},
+		"argument":           {args: []string{"extra"}},
+		"bad duration":       {env: map[string]string{"SESSION_LIFETIME": "a day"}},
+		"bad key":            {env: map[string]string{"CSRF_KEY": "not hex"}},
+		"unknown in file":    {env: map[string]string{"CONFIG_FILE": file}},
+		"missing file":       {args: []string{"-config", file + ".missing"}},
+		"bad bool from file": {env: map[string]string{"ENABLE_API": "maybe"}},
+	}
+	for name, tt := range tests {
+		if _, err := Load("serve", tt.args, env(tt.env)); err == nil {
+			t.Errorf("Load with %s succeeded, want an error", name)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	dev := Default()
+	dev.TemplateDir = dir
+
+	if err := dev.Validate(); err != nil {
+		t.Errorf("Validate(defaults) = %v, want nil", err)
+	}
+
+	prod := dev
+	prod.Mode = Production
+	err := prod.Validate()
+	if err == nil {
+		t.Fatal("Validate(production with defaults) = nil, want an error")
+	}
+	for _, want := range []string{"CSRF_KEY", "SECURE_COOKIES"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate(production with defaults) = %q, want it to mention %s", err, want)
+		}
+	}
+
+	prod.CSRFKey = []byte("a secret key of thirty-two bytes")
+	prod.SecureCookies = true
+	if err := prod.Validate(); err != nil {
+		t.Errorf("Validate(production) = %v, want nil", err)
+	}
+
+	for name, change := range map[string]func(c *Config){
+		"memory store":      func(c *Config) { c.Store = "memory" },
+		"short key":         func(c *Config) { c.CSRFKey = []byte("short") },
+		"unknown mode":      func(c *Config) { c.Mode = "staging" },
+		"unknown log level": func(c *Config) { c.LogLevel = "debug" },
+		"no lifetime":       func(c *Config) { c.SessionLifetime = 0 },
+		"no templates":      func(c *Config) { c.TemplateDir = filepath.Join(dir, "missing") },
+		"cert without key":  func(c *Config) { c.TLSCert = "cert.pem" },
+		"redirect no TLS":   func(c *Config) { c.RedirectAddr = ":80" },
+		"no read timeout":   func(c *Config) { c.ReadTimeout = 0 },
+		"no shutdown time":  func(c *Config) { c.ShutdownTimeout = 0 },
+	} {
+		c := prod
+		change(&c)
+		if err := c.Validate(); err == nil {
+			t.Errorf("Validate with %s = nil, want an error", name)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package goreddit
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by every Store implementation. Implementations wrap them
+// with additional context, so callers should test for them with errors.Is.
+var (
+	// ErrNotFound means the requested record does not exist.
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict means a write would violate a uniqueness constraint,
+	// such as registering a username that is already taken.
+	ErrConflict = errors.New("conflict")
+
+	// ErrInvalidReference means a write refers to a record that does not
+	// exist, such as creating a post in an unknown thread.
+	ErrInvalidReference = errors.New("invalid reference")
+
+	// ErrInvalidCursor means a listing was asked to continue from a cursor
+	// that is malformed or was issued for a different sort order.
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// ErrPinLimit means pinning a post would leave its thread with more
+	// than MaxPinnedPosts pinned posts.
+	ErrPinLimit = fmt.Errorf("a thread can have at most %d pinned posts", MaxPinnedPosts)
+)
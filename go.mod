@@ -13,10 +13,15 @@ require (
 	github.com/alexedwards/scs/v2 v2.5.0
 	github.com/go-chi/chi/v5 v5.0.7
 	github.com/gorilla/csrf v1.7.1
+	github.com/microcosm-cc/bluemonday v1.0.18
+	github.com/yuin/goldmark v1.4.13
 	golang.org/x/crypto v0.0.0-20220214200702-86341886e292
 )
 
 require (
+	github.com/aymerick/douceur v0.2.0 // indirect
+	github.com/gorilla/css v1.0.0 // indirect
 	github.com/gorilla/securecookie v1.1.1 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
+	golang.org/x/net v0.0.0-20211112202133-69e39bad7dc2 // indirect
 )
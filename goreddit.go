@@ -1,11 +1,27 @@
 package goreddit
 
-import "github.com/google/uuid"
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Thread struct {
 	ID          uuid.UUID `db:"id"`
 	Title       string    `db:"title"`
 	Description string    `db:"description"`
+
+	// SubscribersCount is only set on threads returned by ThreadStore and
+	// SubscriptionStore methods.
+	SubscribersCount int `db:"subscribers_count"`
+
+	AuthorID       uuid.NullUUID `db:"author_id"`
+	AuthorUsername string        `db:"author_username"`
+
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
 }
 
 type Post struct {
@@ -16,52 +32,367 @@ type Post struct {
 	Votes         int       `db:"votes"`
 	ThreadTitle   string    `db:"thread_title"`
 	CommentsCount int       `db:"comments_count"`
+
+	// Upvotes and Downvotes count the votes cast by users. Votes is their
+	// difference plus the score the post was created with.
+	Upvotes   int `db:"upvotes"`
+	Downvotes int `db:"downvotes"`
+
+	// Removed, Locked and Pinned are set by the thread's moderators.
+	// Removed posts are left out of listings, locked posts take no new
+	// comments and pinned posts are shown above the thread's listing.
+	Removed bool `db:"removed"`
+	Locked  bool `db:"locked"`
+	Pinned  bool `db:"pinned"`
+
+	AuthorID       uuid.NullUUID `db:"author_id"`
+	AuthorUsername string        `db:"author_username"`
+
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
 }
 
 type Comment struct {
-	ID      uuid.UUID `db:"id"`
-	PostID  uuid.UUID `db:"post_id"`
-	Content string    `db:"content"`
-	Votes   int       `db:"votes"`
+	ID       uuid.UUID     `db:"id"`
+	PostID   uuid.UUID     `db:"post_id"`
+	ParentID uuid.NullUUID `db:"parent_id"`
+	Content  string        `db:"content"`
+	Votes    int           `db:"votes"`
+
+	Upvotes   int `db:"upvotes"`
+	Downvotes int `db:"downvotes"`
+
+	// Removed comments stay in their tree, so that replies keep their
+	// place, but their content is only shown to moderators.
+	Removed bool `db:"removed"`
+
+	AuthorID       uuid.NullUUID `db:"author_id"`
+	AuthorUsername string        `db:"author_username"`
+
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+
+	// Depth, RepliesCount and Replies are only set on comments that are
+	// part of a tree returned by CommentTree or CommentSubtree. Depth is
+	// relative to the root of that tree, and Replies is empty for comments
+	// at the maximum depth even if RepliesCount is not.
+	Depth        int       `db:"depth"`
+	RepliesCount int       `db:"replies_count"`
+	Replies      []Comment `db:"-"`
+
+	// ThreadID and PostTitle are only set on comments returned by
+	// Comments, which lists comments from every post.
+	ThreadID  uuid.UUID `db:"thread_id"`
+	PostTitle string    `db:"post_title"`
+}
+
+// Role is the part a user plays on the whole site.
+type Role string
+
+const (
+	RoleUser Role = "user"
+	// RoleAdmin users moderate every thread and manage the site.
+	RoleAdmin Role = "admin"
+)
+
+func (r Role) Valid() bool {
+	return r == RoleUser || r == RoleAdmin
 }
 
 type User struct {
 	ID       uuid.UUID `db:"id"`
 	Username string    `db:"username"`
 	Password string    `db:"password"`
+	// Role is RoleUser unless it is changed with SetUserRole.
+	Role Role `db:"role"`
+
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// IsAdmin reports whether the user is a site administrator.
+func (u User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// Stats counts the users and content of the whole site.
+type Stats struct {
+	Users           int `db:"users"`
+	Threads         int `db:"threads"`
+	Posts           int `db:"posts"`
+	RemovedPosts    int `db:"removed_posts"`
+	Comments        int `db:"comments"`
+	RemovedComments int `db:"removed_comments"`
+	// Reports counts the open reports and Bans the bans in effect, from
+	// threads and from the site.
+	Reports int `db:"reports"`
+	Bans    int `db:"bans"`
+}
+
+// Token is a personal access token that lets programs act as a user. Only
+// a hash of the token is stored; the token itself is shown once, when it is
+// created.
+type Token struct {
+	ID     uuid.UUID `db:"id"`
+	UserID uuid.UUID `db:"user_id"`
+	Name   string    `db:"name"`
+	Hash   []byte    `db:"hash"`
+	Scopes Scopes    `db:"scopes"`
+
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Moderator is a user who moderates a thread.
+type Moderator struct {
+	ThreadID uuid.UUID `db:"thread_id"`
+	UserID   uuid.UUID `db:"user_id"`
+	Username string    `db:"username"`
+
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Invite asks a user to become a moderator of a thread.
+type Invite struct {
+	ThreadID    uuid.UUID `db:"thread_id"`
+	ThreadTitle string    `db:"thread_title"`
+	UserID      uuid.UUID `db:"user_id"`
+	Username    string    `db:"username"`
+
+	InvitedByID       uuid.NullUUID `db:"invited_by"`
+	InvitedByUsername string        `db:"invited_by_username"`
+
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Ban keeps a user from posting, commenting and voting in a thread, or on
+// the whole site if ThreadID is unset. Bans end at ExpiresAt, or never if it
+// is unset; ended bans are kept but no longer apply.
+type Ban struct {
+	ID          uuid.UUID     `db:"id"`
+	ThreadID    uuid.NullUUID `db:"thread_id"`
+	ThreadTitle string        `db:"thread_title"`
+	UserID      uuid.UUID     `db:"user_id"`
+	Username    string        `db:"username"`
+	Reason      string        `db:"reason"`
+	ExpiresAt   sql.NullTime  `db:"expires_at"`
+
+	BannedByID       uuid.NullUUID `db:"banned_by"`
+	BannedByUsername string        `db:"banned_by_username"`
+
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Report flags a post or comment for the moderators of its thread. Reports
+// of a comment set CommentID as well as the PostID of the comment's post.
+type Report struct {
+	ID         uuid.UUID     `db:"id"`
+	PostID     uuid.UUID     `db:"post_id"`
+	CommentID  uuid.NullUUID `db:"comment_id"`
+	ReporterID uuid.NullUUID `db:"reporter_id"`
+	Reason     ReportReason  `db:"reason"`
+	Details    string        `db:"details"`
+
+	// The thread and the reported content, joined in for the mod queue.
+	ThreadID       uuid.UUID     `db:"thread_id"`
+	PostTitle      string        `db:"post_title"`
+	Content        string        `db:"content"`
+	Removed        bool          `db:"removed"`
+	AuthorID       uuid.NullUUID `db:"author_id"`
+	AuthorUsername string        `db:"author_username"`
+
+	ReporterUsername string `db:"reporter_username"`
+
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// TargetID returns the ID of the reported comment or post.
+func (r Report) TargetID() uuid.UUID {
+	if r.CommentID.Valid {
+		return r.CommentID.UUID
+	}
+	return r.PostID
+}
+
+// ModLogEntry records a privileged action. Entries are never changed or
+// deleted, and keep the names of the users and content involved as they were
+// when the action was taken, so that they outlive them.
+type ModLogEntry struct {
+	ID uuid.UUID `db:"id"`
+	// ThreadID and ThreadTitle are those of the thread the action was
+	// taken in.
+	ThreadID    uuid.NullUUID `db:"thread_id"`
+	ThreadTitle string        `db:"thread_title"`
+	// ActorID is unset for actions taken automatically.
+	ActorID       uuid.NullUUID `db:"actor_id"`
+	ActorUsername string        `db:"actor_username"`
+	Action        ModAction     `db:"action"`
+	// TargetLabel is the title of a target thread or post, the start of a
+	// target comment or the username of a target user. PostID is set for
+	// post and comment targets.
+	TargetKind  ModTarget     `db:"target_kind"`
+	TargetID    uuid.UUID     `db:"target_id"`
+	TargetLabel string        `db:"target_label"`
+	PostID      uuid.NullUUID `db:"post_id"`
+	Reason      string        `db:"reason"`
+
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// SearchResult is a thread, post or comment that matched a search.
+type SearchResult struct {
+	Kind SearchKind `db:"kind"`
+	ID   uuid.UUID  `db:"id"`
+
+	// ThreadID and ThreadTitle are those of the thread the result is or
+	// belongs to. PostID and PostTitle are those of the post the result is
+	// or belongs to, and are unset for threads.
+	ThreadID    uuid.UUID     `db:"thread_id"`
+	ThreadTitle string        `db:"thread_title"`
+	PostID      uuid.NullUUID `db:"post_id"`
+	PostTitle   string        `db:"post_title"`
+
+	// Snippet is an excerpt of the description or content with the matched
+	// words between HighlightStart and HighlightStop.
+	Snippet string  `db:"snippet"`
+	Rank    float64 `db:"rank"`
+
+	AuthorID       uuid.NullUUID `db:"author_id"`
+	AuthorUsername string        `db:"author_username"`
+
+	CreatedAt time.Time `db:"created_at"`
 }
 
 type ThreadStore interface {
-	Thread(id uuid.UUID) (Thread, error)
-	Threads() ([]Thread, error)
-	CreateThread(t *Thread) error
-	UpdateThread(t *Thread) error
-	DeleteThread(id uuid.UUID) error
+	Thread(ctx context.Context, id uuid.UUID) (Thread, error)
+	Threads(ctx context.Context, opts PageOptions) ([]Thread, Page, error)
+	CreateThread(ctx context.Context, t *Thread) error
+	UpdateThread(ctx context.Context, t *Thread) error
+	DeleteThread(ctx context.Context, id uuid.UUID) error
 }
 
 type PostStore interface {
-	Post(id uuid.UUID) (Post, error)
-	Posts() ([]Post, error)
-	PostsByThread(threadID uuid.UUID) ([]Post, error)
-	CreatePost(p *Post) error
-	UpdatePost(p *Post) error
-	DeletePost(id uuid.UUID) error
+	Post(ctx context.Context, id uuid.UUID) (Post, error)
+	Posts(ctx context.Context, opts ListOptions) ([]Post, Page, error)
+	PostsByThread(ctx context.Context, threadID uuid.UUID, opts ListOptions) ([]Post, Page, error)
+	PostsByThreads(ctx context.Context, threadIDs []uuid.UUID, opts ListOptions) ([]Post, Page, error)
+	PinnedPosts(ctx context.Context, threadID uuid.UUID) ([]Post, error)
+	CreatePost(ctx context.Context, p *Post) error
+	UpdatePost(ctx context.Context, p *Post) error
+	DeletePost(ctx context.Context, id uuid.UUID) error
+	SetPostRemoved(ctx context.Context, id uuid.UUID, removed bool) error
+	SetPostLocked(ctx context.Context, id uuid.UUID, locked bool) error
+	// SetPostPinned fails with ErrPinLimit instead of pinning a post of a
+	// thread with MaxPinnedPosts pinned posts already. Removed posts keep
+	// their pin, so they count too, in case they are approved. The limit
+	// holds even when posts are pinned concurrently.
+	SetPostPinned(ctx context.Context, id uuid.UUID, pinned bool) error
 }
 
 type CommentStore interface {
-	Comment(id uuid.UUID) (Comment, error)
-	CommentsbyPost(postID uuid.UUID) ([]Comment, error)
-	CreateComment(c *Comment) error
-	UpdateComment(c *Comment) error
-	DeleteComment(id uuid.UUID) error
+	Comment(ctx context.Context, id uuid.UUID) (Comment, error)
+	// Comments lists the comments of every post, with the thread and title
+	// of their post.
+	Comments(ctx context.Context, opts ListOptions) ([]Comment, Page, error)
+	CommentsbyPost(ctx context.Context, postID uuid.UUID, opts ListOptions) ([]Comment, Page, error)
+	CommentTree(ctx context.Context, postID uuid.UUID, maxDepth int, sort Sort) ([]Comment, error)
+	CommentSubtree(ctx context.Context, id uuid.UUID, maxDepth int, sort Sort) (Comment, error)
+	CreateComment(ctx context.Context, c *Comment) error
+	UpdateComment(ctx context.Context, c *Comment) error
+	DeleteComment(ctx context.Context, id uuid.UUID) error
+	SetCommentRemoved(ctx context.Context, id uuid.UUID, removed bool) error
 }
 
 type UserStore interface {
-	User(id uuid.UUID) (User, error)
-	UserByUsername(username string) (User, error)
-	CreateUser(u *User) error
-	UpdateUser(u *User) error
-	DeleteUser(id uuid.UUID) error
+	User(ctx context.Context, id uuid.UUID) (User, error)
+	UserByUsername(ctx context.Context, username string) (User, error)
+	// Users lists every user, newest first.
+	Users(ctx context.Context, opts PageOptions) ([]User, Page, error)
+	CreateUser(ctx context.Context, u *User) error
+	// UpdateUser changes the username and password of a user, but not
+	// their role.
+	UpdateUser(ctx context.Context, u *User) error
+	SetUserRole(ctx context.Context, id uuid.UUID, role Role) error
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+}
+
+type VoteStore interface {
+	UserPostVote(ctx context.Context, userID, postID uuid.UUID) (int, error)
+	UserCommentVote(ctx context.Context, userID, commentID uuid.UUID) (int, error)
+	CastPostVote(ctx context.Context, userID, postID uuid.UUID, value int) error
+	CastCommentVote(ctx context.Context, userID, commentID uuid.UUID, value int) error
+}
+
+type TokenStore interface {
+	Token(ctx context.Context, id uuid.UUID) (Token, error)
+	TokenByHash(ctx context.Context, hash []byte) (Token, error)
+	TokensByUser(ctx context.Context, userID uuid.UUID) ([]Token, error)
+	CreateToken(ctx context.Context, t *Token) error
+	DeleteToken(ctx context.Context, id uuid.UUID) error
+}
+
+type SubscriptionStore interface {
+	Subscribed(ctx context.Context, userID, threadID uuid.UUID) (bool, error)
+	Subscriptions(ctx context.Context, userID uuid.UUID) ([]Thread, error)
+	PopularThreads(ctx context.Context, limit int) ([]Thread, error)
+	Subscribe(ctx context.Context, userID, threadID uuid.UUID) error
+	Unsubscribe(ctx context.Context, userID, threadID uuid.UUID) error
+}
+
+type ModeratorStore interface {
+	Moderators(ctx context.Context, threadID uuid.UUID) ([]Moderator, error)
+	IsModerator(ctx context.Context, threadID, userID uuid.UUID) (bool, error)
+	AddModerator(ctx context.Context, threadID, userID uuid.UUID) error
+	RemoveModerator(ctx context.Context, threadID, userID uuid.UUID) error
+	Invite(ctx context.Context, threadID, userID uuid.UUID) (Invite, error)
+	Invites(ctx context.Context, userID uuid.UUID) ([]Invite, error)
+	CreateInvite(ctx context.Context, inv *Invite) error
+	DeleteInvite(ctx context.Context, threadID, userID uuid.UUID) error
+	AcceptInvite(ctx context.Context, threadID, userID uuid.UUID) error
+}
+
+type BanStore interface {
+	Ban(ctx context.Context, id uuid.UUID) (Ban, error)
+	// ActiveBan returns the ban in effect that keeps a user from a thread,
+	// which is a site-wide ban or a ban from the thread, whichever lasts
+	// longer. If threadID is unset, only site-wide bans are considered. It
+	// returns ErrNotFound if the user is not banned.
+	ActiveBan(ctx context.Context, userID uuid.UUID, threadID uuid.NullUUID) (Ban, error)
+	// Bans lists the bans in effect in a thread, or the site-wide bans if
+	// threadID is unset, newest first.
+	Bans(ctx context.Context, threadID uuid.NullUUID) ([]Ban, error)
+	CreateBan(ctx context.Context, b *Ban) error
+	// LiftBan ends a ban in effect now.
+	LiftBan(ctx context.Context, id uuid.UUID) error
+}
+
+type ReportStore interface {
+	// Reports lists the open reports on the content of a thread, oldest
+	// first.
+	Reports(ctx context.Context, threadID uuid.UUID) ([]Report, error)
+	// ReportCounts returns the number of open reports on each reported
+	// post and comment of a thread, keyed by Report.TargetID.
+	ReportCounts(ctx context.Context, threadID uuid.UUID) (map[uuid.UUID]int, error)
+	CreateReport(ctx context.Context, r *Report) error
+	// DeletePostReports deletes the reports of a post, but not those of
+	// its comments.
+	DeletePostReports(ctx context.Context, postID uuid.UUID) error
+	DeleteCommentReports(ctx context.Context, commentID uuid.UUID) error
+}
+
+// ModLogStore records privileged actions. It has no way to change or delete
+// entries.
+type ModLogStore interface {
+	// ModLog lists the entries selected by opts, newest first.
+	ModLog(ctx context.Context, opts ModLogOptions) ([]ModLogEntry, Page, error)
+	CreateModLogEntry(ctx context.Context, e *ModLogEntry) error
+}
+
+type SearchStore interface {
+	Search(ctx context.Context, opts SearchOptions) ([]SearchResult, Page, error)
+}
+
+type StatsStore interface {
+	Stats(ctx context.Context) (Stats, error)
 }
 
 type Store interface {
@@ -69,4 +400,13 @@ type Store interface {
 	PostStore
 	CommentStore
 	UserStore
+	VoteStore
+	TokenStore
+	SearchStore
+	SubscriptionStore
+	ModeratorStore
+	ReportStore
+	ModLogStore
+	BanStore
+	StatsStore
 }
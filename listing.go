@@ -0,0 +1,212 @@
+package goreddit
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sort selects the order of a listing.
+type Sort string
+
+const (
+	// SortHot ranks by score, decayed by age so that new content with a
+	// few votes can outrank old content with many.
+	SortHot Sort = "hot"
+	// SortNew ranks the most recently created content first.
+	SortNew Sort = "new"
+	// SortTop ranks by score.
+	SortTop Sort = "top"
+	// SortControversial ranks content with many, evenly split votes first.
+	SortControversial Sort = "controversial"
+	// SortRising ranks content from the last day by how fast it gains score.
+	SortRising Sort = "rising"
+)
+
+// Sorts lists every valid Sort in the order they are offered to users.
+var Sorts = []Sort{SortHot, SortNew, SortTop, SortControversial, SortRising}
+
+func (s Sort) Valid() bool {
+	for _, v := range Sorts {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Windowed reports whether the sort honors ListOptions.Window.
+func (s Sort) Windowed() bool {
+	return s == SortTop || s == SortControversial
+}
+
+// TimeWindow limits windowed sorts to content created within it.
+type TimeWindow string
+
+const (
+	WindowHour  TimeWindow = "hour"
+	WindowDay   TimeWindow = "day"
+	WindowWeek  TimeWindow = "week"
+	WindowMonth TimeWindow = "month"
+	WindowYear  TimeWindow = "year"
+	WindowAll   TimeWindow = "all"
+)
+
+// TimeWindows lists every valid TimeWindow from shortest to longest.
+var TimeWindows = []TimeWindow{WindowHour, WindowDay, WindowWeek, WindowMonth, WindowYear, WindowAll}
+
+func (w TimeWindow) Valid() bool {
+	for _, v := range TimeWindows {
+		if w == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Duration returns the length of the window, or 0 if it is unbounded.
+func (w TimeWindow) Duration() time.Duration {
+	switch w {
+	case WindowHour:
+		return time.Hour
+	case WindowDay:
+		return 24 * time.Hour
+	case WindowWeek:
+		return 7 * 24 * time.Hour
+	case WindowMonth:
+		return 30 * 24 * time.Hour
+	case WindowYear:
+		return 365 * 24 * time.Hour
+	}
+	return 0
+}
+
+// MaxPinnedPosts is how many posts of a thread may be pinned at once.
+const MaxPinnedPosts = 2
+
+// RisingWindow is how recent content must be to appear in rising listings.
+const RisingWindow = 24 * time.Hour
+
+// ListOptions controls the order and extent of a listing. The zero value
+// lists the first page of everything that was not removed, hottest first.
+type ListOptions struct {
+	Sort   Sort
+	Window TimeWindow
+	// IncludeRemoved lists posts removed by moderators as well, for the
+	// moderators of their thread. Comments listed by CommentStore.Comments
+	// honor it too.
+	IncludeRemoved bool
+	PageOptions
+}
+
+const (
+	// DefaultPageSize is the number of items on a page when none is given.
+	DefaultPageSize = 25
+	// MaxPageSize is the largest number of items a page may hold.
+	MaxPageSize = 100
+)
+
+// PageOptions selects a page of a listing.
+type PageOptions struct {
+	// Cursor continues the listing from Page.Next or Page.Prev of an
+	// earlier page with the same sort order. Empty means the first page.
+	Cursor string
+	// Limit is the number of items per page. Values outside 1 to
+	// MaxPageSize are replaced by DefaultPageSize and MaxPageSize.
+	Limit int
+}
+
+// PageSize returns Limit clamped to the allowed range.
+func (o PageOptions) PageSize() int {
+	if o.Limit <= 0 {
+		return DefaultPageSize
+	}
+	if o.Limit > MaxPageSize {
+		return MaxPageSize
+	}
+	return o.Limit
+}
+
+// Page holds the cursors of the pages around a page of a listing. A cursor
+// is empty if there is no page in that direction.
+type Page struct {
+	Next string
+	Prev string
+}
+
+// Cursor is a position in a listing, between the item with Rank and ID and
+// the item after it. Stores list items by descending rank and ID, so that a
+// position stays put as items are added around it. Cursors are handed out
+// encoded as opaque strings.
+type Cursor struct {
+	Sort Sort      `json:"s"`
+	Rank float64   `json:"r"`
+	ID   uuid.UUID `json:"id"`
+	// Before selects the page ending before the item instead of the one
+	// starting after it.
+	Before bool `json:"b,omitempty"`
+	// At is when the first page of the listing was requested. Rankings
+	// and windows that depend on the time, like rising, are computed as of
+	// At on every page, so that items keep their rank while paging.
+	At time.Time `json:"t"`
+}
+
+// IsZero reports whether c is the position before the first item.
+func (c Cursor) IsZero() bool {
+	return c.ID == uuid.Nil
+}
+
+func (c Cursor) String() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor decodes a cursor string from a Page of a listing in sort
+// order. An empty string decodes to the position before the first item, at
+// the current time.
+func DecodeCursor(s string, sort Sort) (Cursor, error) {
+	if sort == "" {
+		sort = SortHot
+	}
+	// Postgres keeps times to the microsecond.
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	if s == "" {
+		return Cursor{Sort: sort, At: now}, nil
+	}
+
+	var c Cursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(b, &c) != nil || c.IsZero() || c.Sort != sort {
+		return Cursor{}, ErrInvalidCursor
+	}
+	if c.At.IsZero() {
+		c.At = now
+	}
+	return c, nil
+}
+
+// NewPage returns the cursors around a page listed from cur, given the
+// positions of the first and last items on the page. more reports whether
+// there are items beyond the page in the direction cur pages in.
+func NewPage(cur, first, last Cursor, more bool) Page {
+	first.Before, last.Before = true, false
+	first.At, last.At = cur.At, cur.At
+
+	var p Page
+	if cur.Before {
+		p.Next = last.String()
+		if more {
+			p.Prev = first.String()
+		}
+		return p
+	}
+	if more {
+		p.Next = last.String()
+	}
+	if !cur.IsZero() {
+		p.Prev = first.String()
+	}
+	return p
+}
@@ -0,0 +1,41 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"emphasis", "*hi* **there**", "<p><em>hi</em> <strong>there</strong></p>\n"},
+		{"strikethrough", "~~old~~", "<p><del>old</del></p>\n"},
+		{"quote", "> quoted", "<blockquote>\n<p>quoted</p>\n</blockquote>\n"},
+		{"fenced code", "```go\nfmt.Println(\"<hi>\")\n```", "<pre><code class=\"language-go\">fmt.Println(&#34;&lt;hi&gt;&#34;)\n</code></pre>\n"},
+		{"autolink", "see https://go.dev", `<p>see <a href="https://go.dev" rel="nofollow noopener" target="_blank">https://go.dev</a></p>` + "\n"},
+		{"relative link", "[home](/)", `<p><a href="/" rel="nofollow">home</a></p>` + "\n"},
+		{"table", "| a | b |\n|:--|--:|\n| 1 | 2 |", "<table>\n<thead>\n<tr>\n<th align=\"left\">a</th>\n<th align=\"right\">b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td align=\"left\">1</td>\n<td align=\"right\">2</td>\n</tr>\n</tbody>\n</table>\n"},
+		{"inline spoiler", "it was >!him!< all along", `<p>it was <span class="spoiler" tabindex="0">him</span> all along</p>` + "\n"},
+		{"leading spoiler", ">!Snape!< kills Dumbledore", `<p><span class="spoiler" tabindex="0">Snape</span> kills Dumbledore</p>` + "\n"},
+		{"unclosed spoiler", "a >!b", "<p>a &gt;!b</p>\n"},
+		{"raw html", "<script>alert(1)</script>", "\n"},
+		{"inline html", "a <b onclick=\"x()\">b</b>", "<p>a b</p>\n"},
+		{"javascript link", "[x](javascript:alert(1))", "<p>x</p>\n"},
+		{"image", "![alt](https://example.com/x.png)", "<p></p>\n"},
+	}
+	for _, tt := range tests {
+		if got := string(Render(tt.in)); got != tt.want {
+			t.Errorf("%s: Render(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRenderEscapesSpoilers(t *testing.T) {
+	got := string(Render(">!<img src=x onerror=alert(1)>!<"))
+	if strings.Contains(got, "<img") {
+		t.Errorf("Render kept an element inside a spoiler: %q", got)
+	}
+}
@@ -0,0 +1,111 @@
+package markdown
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// KindSpoiler is the ast.NodeKind of spoilers.
+var KindSpoiler = ast.NewNodeKind("Spoiler")
+
+// SpoilerNode is text written as >!text!< that stays hidden until the
+// reader reveals it. Spoilers hold plain text and end on the line they
+// start on.
+type SpoilerNode struct {
+	ast.BaseInline
+}
+
+func (n *SpoilerNode) Kind() ast.NodeKind {
+	return KindSpoiler
+}
+
+func (n *SpoilerNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+var (
+	spoilerOpen  = []byte(">!")
+	spoilerClose = []byte("!<")
+)
+
+type spoilerParser struct{}
+
+func (p spoilerParser) Trigger() []byte {
+	return []byte{'>'}
+}
+
+func (p spoilerParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	if !bytes.HasPrefix(line, spoilerOpen) {
+		return nil
+	}
+	end := bytes.Index(line[len(spoilerOpen):], spoilerClose)
+	if end <= 0 {
+		return nil
+	}
+
+	n := &SpoilerNode{}
+	start := segment.Start + len(spoilerOpen)
+	n.AppendChild(n, ast.NewTextSegment(text.NewSegment(start, start+end)))
+	block.Advance(len(spoilerOpen) + end + len(spoilerClose))
+	return n
+}
+
+// spoilerParagraphParser starts a paragraph at a line that begins with a
+// spoiler, which would otherwise be read as a block quote.
+type spoilerParagraphParser struct {
+	parser.BlockParser
+}
+
+func (p spoilerParagraphParser) Trigger() []byte {
+	return []byte{'>'}
+}
+
+func (p spoilerParagraphParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, _ := reader.PeekLine()
+	if !bytes.HasPrefix(bytes.TrimLeft(line, " "), spoilerOpen) {
+		return nil, parser.NoChildren
+	}
+	return p.BlockParser.Open(parent, reader, pc)
+}
+
+type spoilerRenderer struct{}
+
+func (r spoilerRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindSpoiler, r.render)
+}
+
+func (r spoilerRenderer) render(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		w.WriteString(`<span class="spoiler" tabindex="0">`)
+	} else {
+		w.WriteString(`</span>`)
+	}
+	return ast.WalkContinue, nil
+}
+
+type spoiler struct{}
+
+// Spoiler is a goldmark extension for >!spoilers!<.
+var Spoiler goldmark.Extender = spoiler{}
+
+func (e spoiler) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(
+			// Before the block quote parser, which has priority 800.
+			util.Prioritized(spoilerParagraphParser{parser.NewParagraphParser()}, 790),
+		),
+		parser.WithInlineParsers(
+			util.Prioritized(spoilerParser{}, 500),
+		),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(spoilerRenderer{}, 500),
+	))
+}
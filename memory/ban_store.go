@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+)
+
+type BanStore struct {
+	*db
+}
+
+func (s *BanStore) Ban(ctx context.Context, id uuid.UUID) (goreddit.Ban, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	b, ok := s.bans[id]
+	if !ok {
+		return goreddit.Ban{}, fmt.Errorf("error getting ban: %w", goreddit.ErrNotFound)
+	}
+
+	return s.ban(b), nil
+}
+
+func (s *BanStore) ActiveBan(ctx context.Context, userID uuid.UUID, threadID uuid.NullUUID) (goreddit.Ban, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found *goreddit.Ban
+	t := now()
+	for _, b := range s.bans {
+		b := b
+		if b.UserID != userID || !banActive(b, t) {
+			continue
+		}
+		if b.ThreadID.Valid && (!threadID.Valid || b.ThreadID.UUID != threadID.UUID) {
+			continue
+		}
+		if found == nil || outlasts(b, *found) {
+			found = &b
+		}
+	}
+	if found == nil {
+		return goreddit.Ban{}, fmt.Errorf("error getting ban: %w", goreddit.ErrNotFound)
+	}
+
+	return s.ban(*found), nil
+}
+
+func (s *BanStore) Bans(ctx context.Context, threadID uuid.NullUUID) ([]goreddit.Ban, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bb := []goreddit.Ban{}
+	t := now()
+	for _, b := range s.bans {
+		if b.ThreadID == threadID && banActive(b, t) {
+			bb = append(bb, s.ban(b))
+		}
+	}
+	sort.Slice(bb, func(i, j int) bool {
+		if !bb[i].CreatedAt.Equal(bb[j].CreatedAt) {
+			return bb[i].CreatedAt.After(bb[j].CreatedAt)
+		}
+		return bytes.Compare(bb[i].ID[:], bb[j].ID[:]) < 0
+	})
+
+	return bb, nil
+}
+
+func (s *BanStore) CreateBan(ctx context.Context, b *goreddit.Ban) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.bans[b.ID]; ok {
+		return fmt.Errorf("error creating ban: %w", goreddit.ErrConflict)
+	}
+	if _, ok := s.threads[b.ThreadID.UUID]; b.ThreadID.Valid && !ok {
+		return fmt.Errorf("error creating ban: %w", goreddit.ErrInvalidReference)
+	}
+	if _, ok := s.users[b.UserID]; !ok {
+		return fmt.Errorf("error creating ban: %w", goreddit.ErrInvalidReference)
+	}
+	if !s.authorExists(b.BannedByID) {
+		return fmt.Errorf("error creating ban: %w", goreddit.ErrInvalidReference)
+	}
+	row := goreddit.Ban{
+		ID:         b.ID,
+		ThreadID:   b.ThreadID,
+		UserID:     b.UserID,
+		Reason:     b.Reason,
+		ExpiresAt:  b.ExpiresAt,
+		BannedByID: b.BannedByID,
+		CreatedAt:  b.CreatedAt,
+	}
+	if row.CreatedAt.IsZero() {
+		row.CreatedAt = now()
+	}
+	s.bans[row.ID] = row
+	*b = s.ban(row)
+
+	return nil
+}
+
+func (s *BanStore) LiftBan(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := now()
+	b, ok := s.bans[id]
+	if !ok || !banActive(b, t) {
+		return fmt.Errorf("error lifting ban: %w", goreddit.ErrNotFound)
+	}
+	b.ExpiresAt.Time, b.ExpiresAt.Valid = t, true
+	s.bans[id] = b
+
+	return nil
+}
+
+// ban returns a ban with the names it refers to.
+// The caller must hold the lock.
+func (s *BanStore) ban(b goreddit.Ban) goreddit.Ban {
+	b.ThreadTitle = s.threads[b.ThreadID.UUID].Title
+	b.Username = s.users[b.UserID].Username
+	b.BannedByUsername = s.authorUsername(b.BannedByID)
+	return b
+}
+
+// banActive reports whether b is in effect at t.
+func banActive(b goreddit.Ban, t time.Time) bool {
+	return !b.ExpiresAt.Valid || b.ExpiresAt.Time.After(t)
+}
+
+// outlasts reports whether ban a is listed before ban b by ActiveBan: it
+// lasts longer, or as long and applies site-wide, ties broken by ID.
+func outlasts(a, b goreddit.Ban) bool {
+	if a.ExpiresAt.Valid != b.ExpiresAt.Valid {
+		return !a.ExpiresAt.Valid
+	}
+	if a.ExpiresAt.Valid && !a.ExpiresAt.Time.Equal(b.ExpiresAt.Time) {
+		return a.ExpiresAt.Time.After(b.ExpiresAt.Time)
+	}
+	if a.ThreadID.Valid != b.ThreadID.Valid {
+		return !a.ThreadID.Valid
+	}
+	return bytes.Compare(a.ID[:], b.ID[:]) < 0
+}
@@ -0,0 +1,220 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aleury/goreddit"
+	"github.com/aleury/goreddit/ranking"
+	"github.com/google/uuid"
+)
+
+type CommentStore struct {
+	*db
+}
+
+func (s *CommentStore) Comment(ctx context.Context, id uuid.UUID) (goreddit.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.comments[id]
+	if !ok {
+		return goreddit.Comment{}, fmt.Errorf("error getting comment: %w", goreddit.ErrNotFound)
+	}
+	c.AuthorUsername = s.authorUsername(c.AuthorID)
+
+	return c, nil
+}
+
+func (s *CommentStore) Comments(ctx context.Context, opts goreddit.ListOptions) ([]goreddit.Comment, goreddit.Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cur, err := goreddit.DecodeCursor(opts.Cursor, opts.Sort)
+	if err != nil {
+		return []goreddit.Comment{}, goreddit.Page{}, fmt.Errorf("error getting comments: %w", err)
+	}
+	now := cur.At
+	cc := []goreddit.Comment{}
+	for _, c := range s.comments {
+		if (c.Removed && !opts.IncludeRemoved) || !ranking.Includes(opts, c.CreatedAt, now) {
+			continue
+		}
+		p := s.posts[c.PostID]
+		c.AuthorUsername = s.authorUsername(c.AuthorID)
+		c.ThreadID, c.PostTitle = p.ThreadID, p.Title
+		cc = append(cc, c)
+	}
+	ranks := sortComments(cc, opts.Sort, now)
+
+	lo, hi, page := paginate(len(cc), func(i int) (float64, uuid.UUID) {
+		return ranks[cc[i].ID], cc[i].ID
+	}, cur, opts.PageSize())
+
+	return cc[lo:hi], page, nil
+}
+
+func (s *CommentStore) CommentsbyPost(ctx context.Context, postID uuid.UUID, opts goreddit.ListOptions) ([]goreddit.Comment, goreddit.Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cur, err := goreddit.DecodeCursor(opts.Cursor, opts.Sort)
+	if err != nil {
+		return []goreddit.Comment{}, goreddit.Page{}, fmt.Errorf("error getting comments: %w", err)
+	}
+	now := cur.At
+	cc := []goreddit.Comment{}
+	for _, c := range s.comments {
+		if c.PostID == postID && ranking.Includes(opts, c.CreatedAt, now) {
+			c.AuthorUsername = s.authorUsername(c.AuthorID)
+			cc = append(cc, c)
+		}
+	}
+	ranks := sortComments(cc, opts.Sort, now)
+
+	lo, hi, page := paginate(len(cc), func(i int) (float64, uuid.UUID) {
+		return ranks[cc[i].ID], cc[i].ID
+	}, cur, opts.PageSize())
+
+	return cc[lo:hi], page, nil
+}
+
+func (s *CommentStore) CommentTree(ctx context.Context, postID uuid.UUID, maxDepth int, by goreddit.Sort) ([]goreddit.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	roots := []goreddit.Comment{}
+	for _, c := range s.comments {
+		if c.PostID == postID && !c.ParentID.Valid {
+			roots = append(roots, c)
+		}
+	}
+
+	return s.nest(roots, 0, maxDepth, by, now()), nil
+}
+
+func (s *CommentStore) CommentSubtree(ctx context.Context, id uuid.UUID, maxDepth int, by goreddit.Sort) (goreddit.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.comments[id]
+	if !ok {
+		return goreddit.Comment{}, fmt.Errorf("error getting comment tree: %w", goreddit.ErrNotFound)
+	}
+
+	return s.nest([]goreddit.Comment{c}, 0, maxDepth, by, now())[0], nil
+}
+
+// nest sorts comments at depth and attaches their replies down to maxDepth.
+// The caller must hold the lock.
+func (s *CommentStore) nest(cc []goreddit.Comment, depth, maxDepth int, by goreddit.Sort, now time.Time) []goreddit.Comment {
+	sortComments(cc, by, now)
+	for i := range cc {
+		replies := []goreddit.Comment{}
+		for _, r := range s.comments {
+			if r.ParentID.Valid && r.ParentID.UUID == cc[i].ID {
+				replies = append(replies, r)
+			}
+		}
+
+		cc[i].AuthorUsername = s.authorUsername(cc[i].AuthorID)
+		cc[i].Depth = depth
+		cc[i].RepliesCount = len(replies)
+		if depth < maxDepth && len(replies) > 0 {
+			cc[i].Replies = s.nest(replies, depth+1, maxDepth, by, now)
+		}
+	}
+	return cc
+}
+
+func (s *CommentStore) CreateComment(ctx context.Context, c *goreddit.Comment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.comments[c.ID]; ok {
+		return fmt.Errorf("error creating comment: %w", goreddit.ErrConflict)
+	}
+	if _, ok := s.posts[c.PostID]; !ok {
+		return fmt.Errorf("error creating comment: %w", goreddit.ErrInvalidReference)
+	}
+	if !s.authorExists(c.AuthorID) {
+		return fmt.Errorf("error creating comment: %w", goreddit.ErrInvalidReference)
+	}
+	if _, ok := s.comments[c.ParentID.UUID]; c.ParentID.Valid && !ok {
+		return fmt.Errorf("error creating comment: %w", goreddit.ErrInvalidReference)
+	}
+	row := *c
+	row.AuthorUsername, row.Depth, row.RepliesCount, row.Replies = "", 0, 0, nil
+	row.Upvotes, row.Downvotes = 0, 0
+	row.Removed = false
+	if row.CreatedAt.IsZero() {
+		row.CreatedAt = now()
+	}
+	row.UpdatedAt = row.CreatedAt
+	s.comments[c.ID] = row
+	c.CreatedAt, c.UpdatedAt = row.CreatedAt, row.UpdatedAt
+
+	return nil
+}
+
+func (s *CommentStore) UpdateComment(ctx context.Context, c *goreddit.Comment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row, ok := s.comments[c.ID]
+	if !ok {
+		return fmt.Errorf("error updating comment: %w", goreddit.ErrNotFound)
+	}
+	if _, ok := s.posts[c.PostID]; !ok {
+		return fmt.Errorf("error updating comment: %w", goreddit.ErrInvalidReference)
+	}
+	row.PostID = c.PostID
+	row.Content = c.Content
+	row.UpdatedAt = now()
+	s.comments[c.ID] = row
+	*c = row
+
+	return nil
+}
+
+func (s *CommentStore) DeleteComment(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.comments[id]; !ok {
+		return fmt.Errorf("error deleting comment: %w", goreddit.ErrNotFound)
+	}
+
+	s.deleteComment(id)
+
+	return nil
+}
+
+func (s *CommentStore) SetCommentRemoved(ctx context.Context, id uuid.UUID, removed bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row, ok := s.comments[id]
+	if !ok {
+		return fmt.Errorf("error updating comment: %w", goreddit.ErrNotFound)
+	}
+	row.Removed = removed
+	s.comments[id] = row
+
+	return nil
+}
+
+// sortComments orders comments by descending rank, breaking ties by
+// descending ID, and returns their ranks.
+func sortComments(cc []goreddit.Comment, by goreddit.Sort, now time.Time) map[uuid.UUID]float64 {
+	ranks := make(map[uuid.UUID]float64, len(cc))
+	for _, c := range cc {
+		ranks[c.ID] = ranking.Rank(by, c.Votes, c.Upvotes, c.Downvotes, c.CreatedAt, now)
+	}
+	sort.Slice(cc, func(i, j int) bool {
+		return rankedBefore(ranks[cc[i].ID], cc[i].ID, ranks[cc[j].ID], cc[j].ID)
+	})
+	return ranks
+}
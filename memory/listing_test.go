@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+)
+
+func TestRisingPagesAcrossClockChange(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore()
+	th := goreddit.Thread{ID: uuid.New(), Title: "Alpha"}
+	if err := s.CreateThread(ctx, &th); err != nil {
+		t.Fatal(err)
+	}
+
+	// Listed A, B, D, C at first, but six hours later B would come before A
+	// and every post would rank below B.
+	start := time.Now()
+	for _, p := range []struct {
+		title string
+		votes int
+		age   time.Duration
+	}{
+		{"A", 10, 0},
+		{"B", 40, 6 * time.Hour},
+		{"C", 30, 10 * time.Hour},
+		{"D", 5, time.Hour},
+	} {
+		post := goreddit.Post{ID: uuid.New(), ThreadID: th.ID, Title: p.title, Votes: p.votes, CreatedAt: start.Add(-p.age)}
+		if err := s.CreatePost(ctx, &post); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defer func(orig func() time.Time) { now = orig }(now)
+	opts := goreddit.ListOptions{Sort: goreddit.SortRising, PageOptions: goreddit.PageOptions{Limit: 2}}
+	var got []string
+	for page := 0; page < 3; page++ {
+		pp, p, err := s.Posts(ctx, opts)
+		if err != nil {
+			t.Fatalf("Posts: %v", err)
+		}
+		for _, post := range pp {
+			got = append(got, post.Title)
+		}
+		if p.Next == "" {
+			break
+		}
+		opts.Cursor = p.Next
+		now = func() time.Time { return start.Add(6 * time.Hour).UTC() }
+	}
+
+	if want := []string{"A", "B", "D", "C"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("paging through rising listed %v, want %v", got, want)
+	}
+}
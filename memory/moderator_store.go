@@ -0,0 +1,181 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+)
+
+type ModeratorStore struct {
+	*db
+}
+
+func (s *ModeratorStore) Moderators(ctx context.Context, threadID uuid.UUID) ([]goreddit.Moderator, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	mm := []goreddit.Moderator{}
+	for k, createdAt := range s.moderators {
+		if k.threadID == threadID {
+			mm = append(mm, goreddit.Moderator{
+				ThreadID:  k.threadID,
+				UserID:    k.userID,
+				Username:  s.users[k.userID].Username,
+				CreatedAt: createdAt,
+			})
+		}
+	}
+	sort.Slice(mm, func(i, j int) bool {
+		if !mm[i].CreatedAt.Equal(mm[j].CreatedAt) {
+			return mm[i].CreatedAt.Before(mm[j].CreatedAt)
+		}
+		return bytes.Compare(mm[i].UserID[:], mm[j].UserID[:]) < 0
+	})
+
+	return mm, nil
+}
+
+func (s *ModeratorStore) IsModerator(ctx context.Context, threadID, userID uuid.UUID) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.moderators[moderatorKey{threadID, userID}]
+	return ok, nil
+}
+
+func (s *ModeratorStore) AddModerator(ctx context.Context, threadID, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.threads[threadID]; !ok {
+		return fmt.Errorf("error adding moderator: %w", goreddit.ErrInvalidReference)
+	}
+	if _, ok := s.users[userID]; !ok {
+		return fmt.Errorf("error adding moderator: %w", goreddit.ErrInvalidReference)
+	}
+	k := moderatorKey{threadID, userID}
+	if _, ok := s.moderators[k]; !ok {
+		s.moderators[k] = now()
+	}
+
+	return nil
+}
+
+func (s *ModeratorStore) RemoveModerator(ctx context.Context, threadID, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := moderatorKey{threadID, userID}
+	if _, ok := s.moderators[k]; !ok {
+		return fmt.Errorf("error removing moderator: %w", goreddit.ErrNotFound)
+	}
+	delete(s.moderators, k)
+
+	return nil
+}
+
+func (s *ModeratorStore) Invite(ctx context.Context, threadID, userID uuid.UUID) (goreddit.Invite, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	inv, ok := s.invites[moderatorKey{threadID, userID}]
+	if !ok {
+		return goreddit.Invite{}, fmt.Errorf("error getting invite: %w", goreddit.ErrNotFound)
+	}
+
+	return s.invite(inv), nil
+}
+
+func (s *ModeratorStore) Invites(ctx context.Context, userID uuid.UUID) ([]goreddit.Invite, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ii := []goreddit.Invite{}
+	for k, inv := range s.invites {
+		if k.userID == userID {
+			ii = append(ii, s.invite(inv))
+		}
+	}
+	sort.Slice(ii, func(i, j int) bool {
+		if !ii[i].CreatedAt.Equal(ii[j].CreatedAt) {
+			return ii[i].CreatedAt.After(ii[j].CreatedAt)
+		}
+		return bytes.Compare(ii[i].ThreadID[:], ii[j].ThreadID[:]) < 0
+	})
+
+	return ii, nil
+}
+
+func (s *ModeratorStore) CreateInvite(ctx context.Context, inv *goreddit.Invite) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := moderatorKey{inv.ThreadID, inv.UserID}
+	if _, ok := s.invites[k]; ok {
+		return fmt.Errorf("error creating invite: %w", goreddit.ErrConflict)
+	}
+	if _, ok := s.threads[inv.ThreadID]; !ok {
+		return fmt.Errorf("error creating invite: %w", goreddit.ErrInvalidReference)
+	}
+	if _, ok := s.users[inv.UserID]; !ok {
+		return fmt.Errorf("error creating invite: %w", goreddit.ErrInvalidReference)
+	}
+	if !s.authorExists(inv.InvitedByID) {
+		return fmt.Errorf("error creating invite: %w", goreddit.ErrInvalidReference)
+	}
+	row := goreddit.Invite{
+		ThreadID:    inv.ThreadID,
+		UserID:      inv.UserID,
+		InvitedByID: inv.InvitedByID,
+		CreatedAt:   inv.CreatedAt,
+	}
+	if row.CreatedAt.IsZero() {
+		row.CreatedAt = now()
+	}
+	s.invites[k] = row
+	*inv = s.invite(row)
+
+	return nil
+}
+
+func (s *ModeratorStore) DeleteInvite(ctx context.Context, threadID, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := moderatorKey{threadID, userID}
+	if _, ok := s.invites[k]; !ok {
+		return fmt.Errorf("error deleting invite: %w", goreddit.ErrNotFound)
+	}
+	delete(s.invites, k)
+
+	return nil
+}
+
+func (s *ModeratorStore) AcceptInvite(ctx context.Context, threadID, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := moderatorKey{threadID, userID}
+	if _, ok := s.invites[k]; !ok {
+		return fmt.Errorf("error accepting invite: %w", goreddit.ErrNotFound)
+	}
+	delete(s.invites, k)
+	if _, ok := s.moderators[k]; !ok {
+		s.moderators[k] = now()
+	}
+
+	return nil
+}
+
+// invite returns an invite with the names it refers to.
+// The caller must hold the lock.
+func (s *ModeratorStore) invite(inv goreddit.Invite) goreddit.Invite {
+	inv.ThreadTitle = s.threads[inv.ThreadID].Title
+	inv.Username = s.users[inv.UserID].Username
+	inv.InvitedByUsername = s.authorUsername(inv.InvitedByID)
+	return inv
+}
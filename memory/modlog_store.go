@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aleury/goreddit"
+	"github.com/aleury/goreddit/ranking"
+	"github.com/google/uuid"
+)
+
+type ModLogStore struct {
+	*db
+}
+
+func (s *ModLogStore) ModLog(ctx context.Context, opts goreddit.ModLogOptions) ([]goreddit.ModLogEntry, goreddit.Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ee := []goreddit.ModLogEntry{}
+	for _, e := range s.modLog {
+		if opts.ThreadID.Valid && e.ThreadID != opts.ThreadID {
+			continue
+		}
+		if opts.Action != "" && e.Action != opts.Action {
+			continue
+		}
+		if opts.Target != "" && e.TargetKind != opts.Target {
+			continue
+		}
+		if opts.Actor != "" && e.ActorUsername != opts.Actor {
+			continue
+		}
+		ee = append(ee, e)
+	}
+	sort.Slice(ee, func(i, j int) bool {
+		return rankedBefore(ranking.New(ee[i].CreatedAt), ee[i].ID, ranking.New(ee[j].CreatedAt), ee[j].ID)
+	})
+
+	cur, err := goreddit.DecodeCursor(opts.Cursor, goreddit.SortNew)
+	if err != nil {
+		return []goreddit.ModLogEntry{}, goreddit.Page{}, fmt.Errorf("error getting mod log: %w", err)
+	}
+	lo, hi, page := paginate(len(ee), func(i int) (float64, uuid.UUID) {
+		return ranking.New(ee[i].CreatedAt), ee[i].ID
+	}, cur, opts.PageSize())
+
+	return ee[lo:hi], page, nil
+}
+
+func (s *ModLogStore) CreateModLogEntry(ctx context.Context, e *goreddit.ModLogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, other := range s.modLog {
+		if other.ID == e.ID {
+			return fmt.Errorf("error creating mod log entry: %w", goreddit.ErrConflict)
+		}
+	}
+	row := *e
+	if row.CreatedAt.IsZero() {
+		row.CreatedAt = now()
+	}
+	s.modLog = append(s.modLog, row)
+	*e = row
+
+	return nil
+}
@@ -0,0 +1,276 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aleury/goreddit"
+	"github.com/aleury/goreddit/ranking"
+	"github.com/google/uuid"
+)
+
+type PostStore struct {
+	*db
+}
+
+func (s *PostStore) Post(ctx context.Context, id uuid.UUID) (goreddit.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.posts[id]
+	if !ok {
+		return goreddit.Post{}, fmt.Errorf("error getting post: %w", goreddit.ErrNotFound)
+	}
+	p.ThreadTitle = s.threads[p.ThreadID].Title
+	p.AuthorUsername = s.authorUsername(p.AuthorID)
+
+	return p, nil
+}
+
+func (s *PostStore) Posts(ctx context.Context, opts goreddit.ListOptions) ([]goreddit.Post, goreddit.Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cur, err := goreddit.DecodeCursor(opts.Cursor, opts.Sort)
+	if err != nil {
+		return []goreddit.Post{}, goreddit.Page{}, fmt.Errorf("error getting posts: %w", err)
+	}
+	now := cur.At
+	pp := []goreddit.Post{}
+	for _, p := range s.posts {
+		if (p.Removed && !opts.IncludeRemoved) || !ranking.Includes(opts, p.CreatedAt, now) {
+			continue
+		}
+		p.ThreadTitle = s.threads[p.ThreadID].Title
+		p.CommentsCount = s.commentsCount(p.ID)
+		p.AuthorUsername = s.authorUsername(p.AuthorID)
+		pp = append(pp, p)
+	}
+	ranks := sortPosts(pp, opts.Sort, now)
+
+	lo, hi, page := paginate(len(pp), func(i int) (float64, uuid.UUID) {
+		return ranks[pp[i].ID], pp[i].ID
+	}, cur, opts.PageSize())
+
+	return pp[lo:hi], page, nil
+}
+
+func (s *PostStore) PostsByThread(ctx context.Context, threadID uuid.UUID, opts goreddit.ListOptions) ([]goreddit.Post, goreddit.Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cur, err := goreddit.DecodeCursor(opts.Cursor, opts.Sort)
+	if err != nil {
+		return []goreddit.Post{}, goreddit.Page{}, fmt.Errorf("error getting posts: %w", err)
+	}
+	now := cur.At
+	pp := []goreddit.Post{}
+	for _, p := range s.posts {
+		if p.ThreadID != threadID || (p.Removed && !opts.IncludeRemoved) || !ranking.Includes(opts, p.CreatedAt, now) {
+			continue
+		}
+		p.CommentsCount = s.commentsCount(p.ID)
+		p.AuthorUsername = s.authorUsername(p.AuthorID)
+		pp = append(pp, p)
+	}
+	ranks := sortPosts(pp, opts.Sort, now)
+
+	lo, hi, page := paginate(len(pp), func(i int) (float64, uuid.UUID) {
+		return ranks[pp[i].ID], pp[i].ID
+	}, cur, opts.PageSize())
+
+	return pp[lo:hi], page, nil
+}
+
+func (s *PostStore) PostsByThreads(ctx context.Context, threadIDs []uuid.UUID, opts goreddit.ListOptions) ([]goreddit.Post, goreddit.Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	in := make(map[uuid.UUID]bool, len(threadIDs))
+	for _, id := range threadIDs {
+		in[id] = true
+	}
+
+	cur, err := goreddit.DecodeCursor(opts.Cursor, opts.Sort)
+	if err != nil {
+		return []goreddit.Post{}, goreddit.Page{}, fmt.Errorf("error getting posts: %w", err)
+	}
+	now := cur.At
+	pp := []goreddit.Post{}
+	for _, p := range s.posts {
+		if !in[p.ThreadID] || (p.Removed && !opts.IncludeRemoved) || !ranking.Includes(opts, p.CreatedAt, now) {
+			continue
+		}
+		p.ThreadTitle = s.threads[p.ThreadID].Title
+		p.CommentsCount = s.commentsCount(p.ID)
+		p.AuthorUsername = s.authorUsername(p.AuthorID)
+		pp = append(pp, p)
+	}
+	ranks := sortPosts(pp, opts.Sort, now)
+
+	lo, hi, page := paginate(len(pp), func(i int) (float64, uuid.UUID) {
+		return ranks[pp[i].ID], pp[i].ID
+	}, cur, opts.PageSize())
+
+	return pp[lo:hi], page, nil
+}
+
+func (s *PostStore) PinnedPosts(ctx context.Context, threadID uuid.UUID) ([]goreddit.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pp := []goreddit.Post{}
+	for _, p := range s.posts {
+		if p.ThreadID != threadID || !p.Pinned || p.Removed {
+			continue
+		}
+		p.CommentsCount = s.commentsCount(p.ID)
+		p.AuthorUsername = s.authorUsername(p.AuthorID)
+		pp = append(pp, p)
+	}
+	sort.Slice(pp, func(i, j int) bool {
+		if !pp[i].CreatedAt.Equal(pp[j].CreatedAt) {
+			return pp[i].CreatedAt.After(pp[j].CreatedAt)
+		}
+		return rankedBefore(0, pp[i].ID, 0, pp[j].ID)
+	})
+
+	return pp, nil
+}
+
+func (s *PostStore) CreatePost(ctx context.Context, p *goreddit.Post) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.posts[p.ID]; ok {
+		return fmt.Errorf("error creating post: %w", goreddit.ErrConflict)
+	}
+	if _, ok := s.threads[p.ThreadID]; !ok {
+		return fmt.Errorf("error creating post: %w", goreddit.ErrInvalidReference)
+	}
+	if !s.authorExists(p.AuthorID) {
+		return fmt.Errorf("error creating post: %w", goreddit.ErrInvalidReference)
+	}
+	row := *p
+	row.ThreadTitle, row.CommentsCount, row.AuthorUsername = "", 0, ""
+	row.Upvotes, row.Downvotes = 0, 0
+	row.Removed, row.Locked, row.Pinned = false, false, false
+	if row.CreatedAt.IsZero() {
+		row.CreatedAt = now()
+	}
+	row.UpdatedAt = row.CreatedAt
+	s.posts[p.ID] = row
+	p.CreatedAt, p.UpdatedAt = row.CreatedAt, row.UpdatedAt
+
+	return nil
+}
+
+func (s *PostStore) UpdatePost(ctx context.Context, p *goreddit.Post) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row, ok := s.posts[p.ID]
+	if !ok {
+		return fmt.Errorf("error updating post: %w", goreddit.ErrNotFound)
+	}
+	if _, ok := s.threads[p.ThreadID]; !ok {
+		return fmt.Errorf("error updating post: %w", goreddit.ErrInvalidReference)
+	}
+	row.ThreadID = p.ThreadID
+	row.Title = p.Title
+	row.Content = p.Content
+	row.UpdatedAt = now()
+	s.posts[p.ID] = row
+	*p = row
+
+	return nil
+}
+
+func (s *PostStore) DeletePost(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.posts[id]; !ok {
+		return fmt.Errorf("error deleting post: %w", goreddit.ErrNotFound)
+	}
+
+	s.deletePost(id)
+
+	return nil
+}
+
+func (s *PostStore) SetPostRemoved(ctx context.Context, id uuid.UUID, removed bool) error {
+	return s.setFlag(id, func(p *goreddit.Post) { p.Removed = removed })
+}
+
+func (s *PostStore) SetPostLocked(ctx context.Context, id uuid.UUID, locked bool) error {
+	return s.setFlag(id, func(p *goreddit.Post) { p.Locked = locked })
+}
+
+func (s *PostStore) SetPostPinned(ctx context.Context, id uuid.UUID, pinned bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row, ok := s.posts[id]
+	if !ok {
+		return fmt.Errorf("error pinning post: %w", goreddit.ErrNotFound)
+	}
+	if pinned && !row.Pinned {
+		n := 0
+		for _, p := range s.posts {
+			if p.ThreadID == row.ThreadID && p.Pinned {
+				n++
+			}
+		}
+		if n >= goreddit.MaxPinnedPosts {
+			return fmt.Errorf("error pinning post: %w", goreddit.ErrPinLimit)
+		}
+	}
+	row.Pinned = pinned
+	s.posts[id] = row
+
+	return nil
+}
+
+// setFlag sets one of the moderation flags of a post. Setting a flag does
+// not count as editing the post, so UpdatedAt is left alone.
+func (s *PostStore) setFlag(id uuid.UUID, set func(p *goreddit.Post)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row, ok := s.posts[id]
+	if !ok {
+		return fmt.Errorf("error updating post: %w", goreddit.ErrNotFound)
+	}
+	set(&row)
+	s.posts[id] = row
+
+	return nil
+}
+
+// commentsCount returns the number of comments on a post.
+// The caller must hold the lock.
+func (s *PostStore) commentsCount(postID uuid.UUID) int {
+	n := 0
+	for _, c := range s.comments {
+		if c.PostID == postID {
+			n++
+		}
+	}
+	return n
+}
+
+// sortPosts orders posts by descending rank, breaking ties by descending ID,
+// and returns their ranks.
+func sortPosts(pp []goreddit.Post, by goreddit.Sort, now time.Time) map[uuid.UUID]float64 {
+	ranks := make(map[uuid.UUID]float64, len(pp))
+	for _, p := range pp {
+		ranks[p.ID] = ranking.Rank(by, p.Votes, p.Upvotes, p.Downvotes, p.CreatedAt, now)
+	}
+	sort.Slice(pp, func(i, j int) bool {
+		return rankedBefore(ranks[pp[i].ID], pp[i].ID, ranks[pp[j].ID], pp[j].ID)
+	})
+	return ranks
+}
@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+)
+
+type ReportStore struct {
+	*db
+}
+
+func (s *ReportStore) Reports(ctx context.Context, threadID uuid.UUID) ([]goreddit.Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rr := []goreddit.Report{}
+	for _, r := range s.reports {
+		p := s.posts[r.PostID]
+		if p.ThreadID != threadID {
+			continue
+		}
+		r.ThreadID = p.ThreadID
+		r.PostTitle = p.Title
+		r.Content, r.Removed, r.AuthorID = p.Content, p.Removed, p.AuthorID
+		if r.CommentID.Valid {
+			c := s.comments[r.CommentID.UUID]
+			r.Content, r.Removed, r.AuthorID = c.Content, c.Removed, c.AuthorID
+		}
+		r.AuthorUsername = s.authorUsername(r.AuthorID)
+		r.ReporterUsername = s.authorUsername(r.ReporterID)
+		rr = append(rr, r)
+	}
+	sort.Slice(rr, func(i, j int) bool {
+		if !rr[i].CreatedAt.Equal(rr[j].CreatedAt) {
+			return rr[i].CreatedAt.Before(rr[j].CreatedAt)
+		}
+		return bytes.Compare(rr[i].ID[:], rr[j].ID[:]) < 0
+	})
+
+	return rr, nil
+}
+
+func (s *ReportStore) ReportCounts(ctx context.Context, threadID uuid.UUID) (map[uuid.UUID]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := map[uuid.UUID]int{}
+	for _, r := range s.reports {
+		if s.posts[r.PostID].ThreadID == threadID {
+			counts[r.TargetID()]++
+		}
+	}
+
+	return counts, nil
+}
+
+func (s *ReportStore) CreateReport(ctx context.Context, r *goreddit.Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.reports[r.ID]; ok {
+		return fmt.Errorf("error creating report: %w", goreddit.ErrConflict)
+	}
+	if _, ok := s.posts[r.PostID]; !ok {
+		return fmt.Errorf("error creating report: %w", goreddit.ErrInvalidReference)
+	}
+	if _, ok := s.comments[r.CommentID.UUID]; r.CommentID.Valid && !ok {
+		return fmt.Errorf("error creating report: %w", goreddit.ErrInvalidReference)
+	}
+	if !s.authorExists(r.ReporterID) {
+		return fmt.Errorf("error creating report: %w", goreddit.ErrInvalidReference)
+	}
+	for _, other := range s.reports {
+		if r.ReporterID.Valid && other.ReporterID == r.ReporterID && other.PostID == r.PostID && other.CommentID == r.CommentID {
+			return fmt.Errorf("error creating report: %w", goreddit.ErrConflict)
+		}
+	}
+	row := goreddit.Report{
+		ID:         r.ID,
+		PostID:     r.PostID,
+		CommentID:  r.CommentID,
+		ReporterID: r.ReporterID,
+		Reason:     r.Reason,
+		Details:    r.Details,
+		CreatedAt:  r.CreatedAt,
+	}
+	if row.CreatedAt.IsZero() {
+		row.CreatedAt = now()
+	}
+	s.reports[r.ID] = row
+	*r = row
+
+	return nil
+}
+
+func (s *ReportStore) DeletePostReports(ctx context.Context, postID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, r := range s.reports {
+		if r.PostID == postID && !r.CommentID.Valid {
+			delete(s.reports, id)
+		}
+	}
+
+	return nil
+}
+
+func (s *ReportStore) DeleteCommentReports(ctx context.Context, commentID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, r := range s.reports {
+		if r.CommentID.Valid && r.CommentID.UUID == commentID {
+			delete(s.reports, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,279 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+)
+
+// Weights of matches in titles and in descriptions or content, the same as
+// the default weights Postgres gives to the A and B labels.
+const (
+	titleWeight = 1.0
+	bodyWeight  = 0.4
+)
+
+// snippetWords is the length of snippets in words.
+const snippetWords = 30
+
+type SearchStore struct {
+	*db
+}
+
+// Search approximates the Postgres full-text search without stemming: words
+// only match words spelled the same, ignoring case.
+func (s *SearchStore) Search(ctx context.Context, opts goreddit.SearchOptions) ([]goreddit.SearchResult, goreddit.Page, error) {
+	if _, err := goreddit.DecodeCursor(opts.Cursor, goreddit.SortRelevance); err != nil {
+		return []goreddit.SearchResult{}, goreddit.Page{}, fmt.Errorf("error searching: %w", err)
+	}
+	q := parseQuery(opts.Query)
+	if q.empty() {
+		return []goreddit.SearchResult{}, goreddit.Page{}, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var rr []goreddit.SearchResult
+	add := func(r goreddit.SearchResult, title, body string, threadID uuid.UUID) {
+		if opts.ThreadID.Valid && opts.ThreadID.UUID != threadID {
+			return
+		}
+		if opts.Author != "" && r.AuthorUsername != opts.Author {
+			return
+		}
+		if !opts.After.IsZero() && r.CreatedAt.Before(opts.After) {
+			return
+		}
+		if !opts.Before.IsZero() && !r.CreatedAt.Before(opts.Before) {
+			return
+		}
+		rank, ok := q.rank(title, body)
+		if !ok {
+			return
+		}
+		r.Rank = rank
+		r.Snippet = q.snippet(body)
+		rr = append(rr, r)
+	}
+
+	if opts.Includes(goreddit.SearchThreads) {
+		for _, t := range s.threads {
+			add(goreddit.SearchResult{
+				Kind:           goreddit.SearchThreads,
+				ID:             t.ID,
+				ThreadID:       t.ID,
+				ThreadTitle:    t.Title,
+				AuthorID:       t.AuthorID,
+				AuthorUsername: s.authorUsername(t.AuthorID),
+				CreatedAt:      t.CreatedAt,
+			}, t.Title, t.Description, t.ID)
+		}
+	}
+	if opts.Includes(goreddit.SearchPosts) {
+		for _, p := range s.posts {
+			if p.Removed {
+				continue
+			}
+			add(goreddit.SearchResult{
+				Kind:           goreddit.SearchPosts,
+				ID:             p.ID,
+				ThreadID:       p.ThreadID,
+				ThreadTitle:    s.threads[p.ThreadID].Title,
+				PostID:         uuid.NullUUID{UUID: p.ID, Valid: true},
+				PostTitle:      p.Title,
+				AuthorID:       p.AuthorID,
+				AuthorUsername: s.authorUsername(p.AuthorID),
+				CreatedAt:      p.CreatedAt,
+			}, p.Title, p.Content, p.ThreadID)
+		}
+	}
+	if opts.Includes(goreddit.SearchComments) {
+		for _, c := range s.comments {
+			p := s.posts[c.PostID]
+			if c.Removed || p.Removed {
+				continue
+			}
+			add(goreddit.SearchResult{
+				Kind:           goreddit.SearchComments,
+				ID:             c.ID,
+				ThreadID:       p.ThreadID,
+				ThreadTitle:    s.threads[p.ThreadID].Title,
+				PostID:         uuid.NullUUID{UUID: p.ID, Valid: true},
+				PostTitle:      p.Title,
+				AuthorID:       c.AuthorID,
+				AuthorUsername: s.authorUsername(c.AuthorID),
+				CreatedAt:      c.CreatedAt,
+			}, "", c.Content, p.ThreadID)
+		}
+	}
+
+	sort.Slice(rr, func(i, j int) bool {
+		return rankedBefore(rr[i].Rank, rr[i].ID, rr[j].Rank, rr[j].ID)
+	})
+
+	cur, err := goreddit.DecodeCursor(opts.Cursor, goreddit.SortRelevance)
+	if err != nil {
+		return []goreddit.SearchResult{}, goreddit.Page{}, fmt.Errorf("error searching: %w", err)
+	}
+	lo, hi, page := paginate(len(rr), func(i int) (float64, uuid.UUID) {
+		return rr[i].Rank, rr[i].ID
+	}, cur, opts.PageSize())
+
+	return append([]goreddit.SearchResult{}, rr[lo:hi]...), page, nil
+}
+
+// word is a word of a text and where it is in the text.
+type word struct {
+	text       string
+	start, end int
+}
+
+// words splits s into lowercase words of letters and digits.
+func words(s string) []word {
+	var ww []word
+	start := -1
+	for i, r := range s {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if inWord && start < 0 {
+			start = i
+		} else if !inWord && start >= 0 {
+			ww = append(ww, word{strings.ToLower(s[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		ww = append(ww, word{strings.ToLower(s[start:]), start, len(s)})
+	}
+	return ww
+}
+
+// query is a parsed search query.
+type query struct {
+	phrases [][]string // words and quoted phrases that must appear
+	exclude []string   // words that must not appear
+}
+
+func parseQuery(s string) query {
+	var q query
+	for i, part := range strings.Split(s, `"`) {
+		if i%2 == 1 {
+			// Inside quotes.
+			if phrase := wordTexts(words(part)); len(phrase) > 0 {
+				q.phrases = append(q.phrases, phrase)
+			}
+			continue
+		}
+		for _, field := range strings.Fields(part) {
+			exclude := strings.HasPrefix(field, "-")
+			for _, w := range words(field) {
+				if exclude {
+					q.exclude = append(q.exclude, w.text)
+				} else {
+					q.phrases = append(q.phrases, []string{w.text})
+				}
+			}
+		}
+	}
+	return q
+}
+
+func wordTexts(ww []word) []string {
+	texts := make([]string, len(ww))
+	for i, w := range ww {
+		texts[i] = w.text
+	}
+	return texts
+}
+
+func (q query) empty() bool {
+	return len(q.phrases) == 0
+}
+
+// matches returns the indexes in ww at which phrase appears.
+func matches(ww []word, phrase []string) []int {
+	var at []int
+outer:
+	for i := 0; i+len(phrase) <= len(ww); i++ {
+		for j, p := range phrase {
+			if ww[i+j].text != p {
+				continue outer
+			}
+		}
+		at = append(at, i)
+	}
+	return at
+}
+
+// rank reports whether a document with title and body matches q, and how
+// well. Like ts_rank, it weighs matches by where they are and by the length
+// of the document.
+func (q query) rank(title, body string) (float64, bool) {
+	tw, bw := words(title), words(body)
+
+	var score float64
+	for _, phrase := range q.phrases {
+		t, b := len(matches(tw, phrase)), len(matches(bw, phrase))
+		if t+b == 0 {
+			return 0, false
+		}
+		score += titleWeight*float64(t) + bodyWeight*float64(b)
+	}
+	for _, w := range q.exclude {
+		if len(matches(tw, []string{w}))+len(matches(bw, []string{w})) > 0 {
+			return 0, false
+		}
+	}
+	return score / (1 + math.Log(float64(1+len(tw)+len(bw)))), true
+}
+
+// snippet returns an excerpt of body around the first match of q, with the
+// matched words highlighted.
+func (q query) snippet(body string) string {
+	ww := words(body)
+	if len(ww) == 0 {
+		return ""
+	}
+
+	matched := make([]bool, len(ww))
+	first := -1
+	for _, phrase := range q.phrases {
+		for _, i := range matches(ww, phrase) {
+			for j := range phrase {
+				matched[i+j] = true
+			}
+			if first < 0 || i < first {
+				first = i
+			}
+		}
+	}
+
+	lo := 0
+	if first > snippetWords/4 {
+		lo = first - snippetWords/4
+	}
+	hi := lo + snippetWords
+	if hi > len(ww) {
+		hi = len(ww)
+	}
+
+	var b strings.Builder
+	pos := ww[lo].start
+	for i := lo; i < hi; i++ {
+		if matched[i] {
+			b.WriteString(body[pos:ww[i].start])
+			b.WriteString(goreddit.HighlightStart)
+			b.WriteString(body[ww[i].start:ww[i].end])
+			b.WriteString(goreddit.HighlightStop)
+			pos = ww[i].end
+		}
+	}
+	b.WriteString(body[pos:ww[hi-1].end])
+	return b.String()
+}
@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/aleury/goreddit"
+)
+
+type StatsStore struct {
+	*db
+}
+
+func (s *StatsStore) Stats(ctx context.Context) (goreddit.Stats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	st := goreddit.Stats{
+		Users:    len(s.users),
+		Threads:  len(s.threads),
+		Posts:    len(s.posts),
+		Comments: len(s.comments),
+		Reports:  len(s.reports),
+	}
+	for _, p := range s.posts {
+		if p.Removed {
+			st.RemovedPosts++
+		}
+	}
+	for _, c := range s.comments {
+		if c.Removed {
+			st.RemovedComments++
+		}
+	}
+	t := now()
+	for _, b := range s.bans {
+		if banActive(b, t) {
+			st.Bans++
+		}
+	}
+
+	return st, nil
+}
@@ -0,0 +1,237 @@
+// Package memory provides an in-memory implementation of goreddit.Store for
+// tests and local demos. It mirrors the behavior of the postgres package,
+// including cascading deletes and vote tallies, and is safe for concurrent use.
+package memory
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+)
+
+type voteKey struct {
+	userID   uuid.UUID
+	targetID uuid.UUID
+}
+
+type subscriptionKey struct {
+	userID   uuid.UUID
+	threadID uuid.UUID
+}
+
+type moderatorKey struct {
+	threadID uuid.UUID
+	userID   uuid.UUID
+}
+
+// db holds the tables shared by the individual stores.
+type db struct {
+	mu sync.RWMutex
+
+	threads       map[uuid.UUID]goreddit.Thread
+	posts         map[uuid.UUID]goreddit.Post
+	comments      map[uuid.UUID]goreddit.Comment
+	users         map[uuid.UUID]goreddit.User
+	postVotes     map[voteKey]int
+	commentVotes  map[voteKey]int
+	tokens        map[uuid.UUID]goreddit.Token
+	subscriptions map[subscriptionKey]time.Time
+	moderators    map[moderatorKey]time.Time
+	invites       map[moderatorKey]goreddit.Invite
+	reports       map[uuid.UUID]goreddit.Report
+	bans          map[uuid.UUID]goreddit.Ban
+	// modLog is only ever appended to.
+	modLog []goreddit.ModLogEntry
+}
+
+type Store struct {
+	*ThreadStore
+	*PostStore
+	*CommentStore
+	*UserStore
+	*VoteStore
+	*TokenStore
+	*SearchStore
+	*SubscriptionStore
+	*ModeratorStore
+	*ReportStore
+	*ModLogStore
+	*BanStore
+	*StatsStore
+}
+
+func NewStore() *Store {
+	db := &db{
+		threads:       map[uuid.UUID]goreddit.Thread{},
+		posts:         map[uuid.UUID]goreddit.Post{},
+		comments:      map[uuid.UUID]goreddit.Comment{},
+		users:         map[uuid.UUID]goreddit.User{},
+		postVotes:     map[voteKey]int{},
+		commentVotes:  map[voteKey]int{},
+		tokens:        map[uuid.UUID]goreddit.Token{},
+		subscriptions: map[subscriptionKey]time.Time{},
+		moderators:    map[moderatorKey]time.Time{},
+		invites:       map[moderatorKey]goreddit.Invite{},
+		reports:       map[uuid.UUID]goreddit.Report{},
+		bans:          map[uuid.UUID]goreddit.Ban{},
+	}
+
+	store := Store{
+		ThreadStore:       &ThreadStore{db: db},
+		PostStore:         &PostStore{db: db},
+		CommentStore:      &CommentStore{db: db},
+		UserStore:         &UserStore{db: db},
+		VoteStore:         &VoteStore{db: db},
+		TokenStore:        &TokenStore{db: db},
+		SearchStore:       &SearchStore{db: db},
+		SubscriptionStore: &SubscriptionStore{db: db},
+		ModeratorStore:    &ModeratorStore{db: db},
+		ReportStore:       &ReportStore{db: db},
+		ModLogStore:       &ModLogStore{db: db},
+		BanStore:          &BanStore{db: db},
+		StatsStore:        &StatsStore{db: db},
+	}
+
+	return &store
+}
+
+// now returns the current time at the precision Postgres stores timestamps.
+// Tests replace it to move the clock.
+var now = func() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// rankedBefore reports whether an item with rank ra and ID a is listed before
+// one with rank rb and ID b. Ties are broken by descending ID, comparing bytes
+// the way Postgres compares UUIDs.
+func rankedBefore(ra float64, a uuid.UUID, rb float64, b uuid.UUID) bool {
+	if ra != rb {
+		return ra > rb
+	}
+	return bytes.Compare(a[:], b[:]) > 0
+}
+
+// paginate finds the page of at most limit items of a listing of n items
+// following cur, where key returns the rank and ID of the i'th item in
+// listing order. The page holds items lo through hi-1.
+func paginate(n int, key func(i int) (float64, uuid.UUID), cur goreddit.Cursor, limit int) (lo, hi int, page goreddit.Page) {
+	var more bool
+	if cur.Before {
+		hi = sort.Search(n, func(i int) bool {
+			rank, id := key(i)
+			return !rankedBefore(rank, id, cur.Rank, cur.ID)
+		})
+		lo = hi - limit
+		if lo < 0 {
+			lo = 0
+		}
+		more = lo > 0
+	} else {
+		if !cur.IsZero() {
+			lo = sort.Search(n, func(i int) bool {
+				rank, id := key(i)
+				return rankedBefore(cur.Rank, cur.ID, rank, id)
+			})
+		}
+		hi = lo + limit
+		if hi > n {
+			hi = n
+		}
+		more = hi < n
+	}
+	if lo == hi {
+		return lo, hi, goreddit.Page{}
+	}
+
+	position := func(i int) goreddit.Cursor {
+		rank, id := key(i)
+		return goreddit.Cursor{Sort: cur.Sort, Rank: rank, ID: id}
+	}
+	return lo, hi, goreddit.NewPage(cur, position(lo), position(hi-1), more)
+}
+
+// authorExists reports whether id is unset or refers to an existing user.
+// The caller must hold the lock.
+func (db *db) authorExists(id uuid.NullUUID) bool {
+	if !id.Valid {
+		return true
+	}
+	_, ok := db.users[id.UUID]
+	return ok
+}
+
+// authorUsername returns the username of the author, or the empty string if
+// the author is unset or has been deleted. The caller must hold the lock.
+func (db *db) authorUsername(id uuid.NullUUID) string {
+	if !id.Valid {
+		return ""
+	}
+	return db.users[id.UUID].Username
+}
+
+// thread returns a thread with its author's username and subscriber count.
+// The caller must hold the lock.
+func (db *db) thread(id uuid.UUID) goreddit.Thread {
+	t := db.threads[id]
+	t.AuthorUsername = db.authorUsername(t.AuthorID)
+	t.SubscribersCount = db.subscribersCount(id)
+	return t
+}
+
+// subscribersCount returns the number of users subscribed to a thread.
+// The caller must hold the lock.
+func (db *db) subscribersCount(threadID uuid.UUID) int {
+	n := 0
+	for k := range db.subscriptions {
+		if k.threadID == threadID {
+			n++
+		}
+	}
+	return n
+}
+
+// deletePost removes a post together with its comments and votes.
+// The caller must hold the write lock.
+func (db *db) deletePost(id uuid.UUID) {
+	for cid, c := range db.comments {
+		if c.PostID == id {
+			db.deleteComment(cid)
+		}
+	}
+	for k := range db.postVotes {
+		if k.targetID == id {
+			delete(db.postVotes, k)
+		}
+	}
+	for rid, r := range db.reports {
+		if r.PostID == id {
+			delete(db.reports, rid)
+		}
+	}
+	delete(db.posts, id)
+}
+
+// deleteComment removes a comment together with its replies and votes.
+// The caller must hold the write lock.
+func (db *db) deleteComment(id uuid.UUID) {
+	for cid, c := range db.comments {
+		if c.ParentID.Valid && c.ParentID.UUID == id {
+			db.deleteComment(cid)
+		}
+	}
+	for k := range db.commentVotes {
+		if k.targetID == id {
+			delete(db.commentVotes, k)
+		}
+	}
+	for rid, r := range db.reports {
+		if r.CommentID.Valid && r.CommentID.UUID == id {
+			delete(db.reports, rid)
+		}
+	}
+	delete(db.comments, id)
+}
@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/aleury/goreddit"
+	"github.com/aleury/goreddit/memory"
+	"github.com/aleury/goreddit/storetest"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) goreddit.Store {
+		return memory.NewStore()
+	})
+}
@@ -0,0 +1,94 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+)
+
+type SubscriptionStore struct {
+	*db
+}
+
+func (s *SubscriptionStore) Subscribed(ctx context.Context, userID, threadID uuid.UUID) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.subscriptions[subscriptionKey{userID, threadID}]
+	return ok, nil
+}
+
+func (s *SubscriptionStore) Subscriptions(ctx context.Context, userID uuid.UUID) ([]goreddit.Thread, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tt := []goreddit.Thread{}
+	for k := range s.subscriptions {
+		if k.userID == userID {
+			tt = append(tt, s.thread(k.threadID))
+		}
+	}
+	sort.Slice(tt, func(i, j int) bool {
+		if tt[i].Title != tt[j].Title {
+			return tt[i].Title < tt[j].Title
+		}
+		return bytes.Compare(tt[i].ID[:], tt[j].ID[:]) < 0
+	})
+
+	return tt, nil
+}
+
+func (s *SubscriptionStore) PopularThreads(ctx context.Context, limit int) ([]goreddit.Thread, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tt := make([]goreddit.Thread, 0, len(s.threads))
+	for id := range s.threads {
+		tt = append(tt, s.thread(id))
+	}
+	sort.Slice(tt, func(i, j int) bool {
+		if tt[i].SubscribersCount != tt[j].SubscribersCount {
+			return tt[i].SubscribersCount > tt[j].SubscribersCount
+		}
+		if !tt[i].CreatedAt.Equal(tt[j].CreatedAt) {
+			return tt[i].CreatedAt.Before(tt[j].CreatedAt)
+		}
+		return bytes.Compare(tt[i].ID[:], tt[j].ID[:]) < 0
+	})
+	if len(tt) > limit {
+		tt = tt[:limit]
+	}
+
+	return tt, nil
+}
+
+func (s *SubscriptionStore) Subscribe(ctx context.Context, userID, threadID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return fmt.Errorf("error subscribing: %w", goreddit.ErrInvalidReference)
+	}
+	if _, ok := s.threads[threadID]; !ok {
+		return fmt.Errorf("error subscribing: %w", goreddit.ErrInvalidReference)
+	}
+	k := subscriptionKey{userID, threadID}
+	if _, ok := s.subscriptions[k]; !ok {
+		s.subscriptions[k] = now()
+	}
+
+	return nil
+}
+
+func (s *SubscriptionStore) Unsubscribe(ctx context.Context, userID, threadID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.subscriptions, subscriptionKey{userID, threadID})
+
+	return nil
+}
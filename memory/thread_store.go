@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aleury/goreddit"
+	"github.com/aleury/goreddit/ranking"
+	"github.com/google/uuid"
+)
+
+type ThreadStore struct {
+	*db
+}
+
+func (s *ThreadStore) Thread(ctx context.Context, id uuid.UUID) (goreddit.Thread, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.threads[id]
+	if !ok {
+		return goreddit.Thread{}, fmt.Errorf("error getting thread: %w", goreddit.ErrNotFound)
+	}
+	t.AuthorUsername = s.authorUsername(t.AuthorID)
+	t.SubscribersCount = s.subscribersCount(t.ID)
+
+	return t, nil
+}
+
+func (s *ThreadStore) Threads(ctx context.Context, opts goreddit.PageOptions) ([]goreddit.Thread, goreddit.Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tt := make([]goreddit.Thread, 0, len(s.threads))
+	ranks := make(map[uuid.UUID]float64, len(s.threads))
+	for _, t := range s.threads {
+		t.AuthorUsername = s.authorUsername(t.AuthorID)
+		t.SubscribersCount = s.subscribersCount(t.ID)
+		tt = append(tt, t)
+		ranks[t.ID] = ranking.New(t.CreatedAt)
+	}
+	sort.Slice(tt, func(i, j int) bool {
+		return rankedBefore(ranks[tt[i].ID], tt[i].ID, ranks[tt[j].ID], tt[j].ID)
+	})
+
+	cur, err := goreddit.DecodeCursor(opts.Cursor, goreddit.SortNew)
+	if err != nil {
+		return []goreddit.Thread{}, goreddit.Page{}, fmt.Errorf("error getting threads: %w", err)
+	}
+	lo, hi, page := paginate(len(tt), func(i int) (float64, uuid.UUID) {
+		return ranks[tt[i].ID], tt[i].ID
+	}, cur, opts.PageSize())
+
+	return tt[lo:hi], page, nil
+}
+
+func (s *ThreadStore) CreateThread(ctx context.Context, t *goreddit.Thread) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.threads[t.ID]; ok {
+		return fmt.Errorf("error creating thread: %w", goreddit.ErrConflict)
+	}
+	if !s.authorExists(t.AuthorID) {
+		return fmt.Errorf("error creating thread: %w", goreddit.ErrInvalidReference)
+	}
+	row := *t
+	row.AuthorUsername = ""
+	if row.CreatedAt.IsZero() {
+		row.CreatedAt = now()
+	}
+	row.UpdatedAt = row.CreatedAt
+	s.threads[t.ID] = row
+	t.CreatedAt, t.UpdatedAt = row.CreatedAt, row.UpdatedAt
+
+	// The author becomes the thread's first moderator.
+	if t.AuthorID.Valid {
+		s.moderators[moderatorKey{t.ID, t.AuthorID.UUID}] = row.CreatedAt
+	}
+
+	return nil
+}
+
+func (s *ThreadStore) UpdateThread(ctx context.Context, t *goreddit.Thread) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row, ok := s.threads[t.ID]
+	if !ok {
+		return fmt.Errorf("error updating thread: %w", goreddit.ErrNotFound)
+	}
+	row.Title = t.Title
+	row.Description = t.Description
+	row.UpdatedAt = now()
+	s.threads[t.ID] = row
+	*t = row
+
+	return nil
+}
+
+func (s *ThreadStore) DeleteThread(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.threads[id]; !ok {
+		return fmt.Errorf("error deleting thread: %w", goreddit.ErrNotFound)
+	}
+
+	for pid, p := range s.posts {
+		if p.ThreadID == id {
+			s.deletePost(pid)
+		}
+	}
+	for k := range s.subscriptions {
+		if k.threadID == id {
+			delete(s.subscriptions, k)
+		}
+	}
+	for k := range s.moderators {
+		if k.threadID == id {
+			delete(s.moderators, k)
+		}
+	}
+	for k := range s.invites {
+		if k.threadID == id {
+			delete(s.invites, k)
+		}
+	}
+	for bid, b := range s.bans {
+		if b.ThreadID.Valid && b.ThreadID.UUID == id {
+			delete(s.bans, bid)
+		}
+	}
+	delete(s.threads, id)
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+)
+
+type TokenStore struct {
+	*db
+}
+
+func (s *TokenStore) Token(ctx context.Context, id uuid.UUID) (goreddit.Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.tokens[id]
+	if !ok {
+		return goreddit.Token{}, fmt.Errorf("error getting token: %w", goreddit.ErrNotFound)
+	}
+
+	return t, nil
+}
+
+func (s *TokenStore) TokenByHash(ctx context.Context, hash []byte) (goreddit.Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, t := range s.tokens {
+		if bytes.Equal(t.Hash, hash) {
+			return t, nil
+		}
+	}
+
+	return goreddit.Token{}, fmt.Errorf("error getting token: %w", goreddit.ErrNotFound)
+}
+
+func (s *TokenStore) TokensByUser(ctx context.Context, userID uuid.UUID) ([]goreddit.Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tt := []goreddit.Token{}
+	for _, t := range s.tokens {
+		if t.UserID == userID {
+			tt = append(tt, t)
+		}
+	}
+	sort.Slice(tt, func(i, j int) bool {
+		return rankedBefore(float64(tt[i].CreatedAt.UnixNano()), tt[i].ID, float64(tt[j].CreatedAt.UnixNano()), tt[j].ID)
+	})
+
+	return tt, nil
+}
+
+func (s *TokenStore) CreateToken(ctx context.Context, t *goreddit.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tokens[t.ID]; ok {
+		return fmt.Errorf("error creating token: %w", goreddit.ErrConflict)
+	}
+	for _, other := range s.tokens {
+		if bytes.Equal(other.Hash, t.Hash) {
+			return fmt.Errorf("error creating token: %w", goreddit.ErrConflict)
+		}
+	}
+	if _, ok := s.users[t.UserID]; !ok {
+		return fmt.Errorf("error creating token: %w", goreddit.ErrInvalidReference)
+	}
+	row := *t
+	if row.CreatedAt.IsZero() {
+		row.CreatedAt = now()
+	}
+	s.tokens[t.ID] = row
+	t.CreatedAt = row.CreatedAt
+
+	return nil
+}
+
+func (s *TokenStore) DeleteToken(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tokens[id]; !ok {
+		return fmt.Errorf("error deleting token: %w", goreddit.ErrNotFound)
+	}
+
+	delete(s.tokens, id)
+
+	return nil
+}
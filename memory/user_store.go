@@ -0,0 +1,219 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aleury/goreddit"
+	"github.com/aleury/goreddit/ranking"
+	"github.com/google/uuid"
+)
+
+type UserStore struct {
+	*db
+}
+
+func (s *UserStore) User(ctx context.Context, id uuid.UUID) (goreddit.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return goreddit.User{}, fmt.Errorf("error getting user: %w", goreddit.ErrNotFound)
+	}
+
+	return u, nil
+}
+
+func (s *UserStore) UserByUsername(ctx context.Context, username string) (goreddit.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+
+	return goreddit.User{}, fmt.Errorf("error getting user: %w", goreddit.ErrNotFound)
+}
+
+func (s *UserStore) Users(ctx context.Context, opts goreddit.PageOptions) ([]goreddit.User, goreddit.Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	uu := make([]goreddit.User, 0, len(s.users))
+	ranks := make(map[uuid.UUID]float64, len(s.users))
+	for _, u := range s.users {
+		uu = append(uu, u)
+		ranks[u.ID] = ranking.New(u.CreatedAt)
+	}
+	sort.Slice(uu, func(i, j int) bool {
+		return rankedBefore(ranks[uu[i].ID], uu[i].ID, ranks[uu[j].ID], uu[j].ID)
+	})
+
+	cur, err := goreddit.DecodeCursor(opts.Cursor, goreddit.SortNew)
+	if err != nil {
+		return []goreddit.User{}, goreddit.Page{}, fmt.Errorf("error getting users: %w", err)
+	}
+	lo, hi, page := paginate(len(uu), func(i int) (float64, uuid.UUID) {
+		return ranks[uu[i].ID], uu[i].ID
+	}, cur, opts.PageSize())
+
+	return uu[lo:hi], page, nil
+}
+
+func (s *UserStore) CreateUser(ctx context.Context, u *goreddit.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[u.ID]; ok || s.usernameTaken(u.Username, u.ID) {
+		return fmt.Errorf("error creating user: %w", goreddit.ErrConflict)
+	}
+	if u.Role == "" {
+		u.Role = goreddit.RoleUser
+	}
+	if !u.Role.Valid() {
+		return fmt.Errorf("error creating user: invalid role %q", u.Role)
+	}
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = now()
+	}
+	u.UpdatedAt = u.CreatedAt
+	s.users[u.ID] = *u
+
+	return nil
+}
+
+func (s *UserStore) UpdateUser(ctx context.Context, u *goreddit.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row, ok := s.users[u.ID]
+	if !ok {
+		return fmt.Errorf("error updating user: %w", goreddit.ErrNotFound)
+	}
+	if s.usernameTaken(u.Username, u.ID) {
+		return fmt.Errorf("error updating user: %w", goreddit.ErrConflict)
+	}
+	row.Username = u.Username
+	row.Password = u.Password
+	row.UpdatedAt = now()
+	s.users[u.ID] = row
+	*u = row
+
+	return nil
+}
+
+func (s *UserStore) SetUserRole(ctx context.Context, id uuid.UUID, role goreddit.Role) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !role.Valid() {
+		return fmt.Errorf("error setting user role: invalid role %q", role)
+	}
+	u, ok := s.users[id]
+	if !ok {
+		return fmt.Errorf("error setting user role: %w", goreddit.ErrNotFound)
+	}
+	u.Role = role
+	u.UpdatedAt = now()
+	s.users[id] = u
+
+	return nil
+}
+
+func (s *UserStore) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[id]; !ok {
+		return fmt.Errorf("error deleting user: %w", goreddit.ErrNotFound)
+	}
+
+	// Deleting a user retracts their votes, as the vote ledger rows
+	// cascade and the tallies follow.
+	for k := range s.postVotes {
+		if k.userID == id {
+			s.setPostVote(k, 0)
+			delete(s.postVotes, k)
+		}
+	}
+	for k := range s.commentVotes {
+		if k.userID == id {
+			s.setCommentVote(k, 0)
+			delete(s.commentVotes, k)
+		}
+	}
+	// Content outlives its author, as author_id is set to NULL.
+	for tid, t := range s.threads {
+		if t.AuthorID.Valid && t.AuthorID.UUID == id {
+			t.AuthorID = uuid.NullUUID{}
+			s.threads[tid] = t
+		}
+	}
+	for pid, p := range s.posts {
+		if p.AuthorID.Valid && p.AuthorID.UUID == id {
+			p.AuthorID = uuid.NullUUID{}
+			s.posts[pid] = p
+		}
+	}
+	for cid, c := range s.comments {
+		if c.AuthorID.Valid && c.AuthorID.UUID == id {
+			c.AuthorID = uuid.NullUUID{}
+			s.comments[cid] = c
+		}
+	}
+	for tid, t := range s.tokens {
+		if t.UserID == id {
+			delete(s.tokens, tid)
+		}
+	}
+	for k := range s.subscriptions {
+		if k.userID == id {
+			delete(s.subscriptions, k)
+		}
+	}
+	for k := range s.moderators {
+		if k.userID == id {
+			delete(s.moderators, k)
+		}
+	}
+	for k, inv := range s.invites {
+		if k.userID == id {
+			delete(s.invites, k)
+		} else if inv.InvitedByID.Valid && inv.InvitedByID.UUID == id {
+			inv.InvitedByID = uuid.NullUUID{}
+			s.invites[k] = inv
+		}
+	}
+	for rid, r := range s.reports {
+		if r.ReporterID.Valid && r.ReporterID.UUID == id {
+			r.ReporterID = uuid.NullUUID{}
+			s.reports[rid] = r
+		}
+	}
+	for bid, b := range s.bans {
+		if b.UserID == id {
+			delete(s.bans, bid)
+		} else if b.BannedByID.Valid && b.BannedByID.UUID == id {
+			b.BannedByID = uuid.NullUUID{}
+			s.bans[bid] = b
+		}
+	}
+	delete(s.users, id)
+
+	return nil
+}
+
+// usernameTaken reports whether a user other than id has the username.
+// The caller must hold the lock.
+func (s *UserStore) usernameTaken(username string, id uuid.UUID) bool {
+	for _, u := range s.users {
+		if u.Username == username && u.ID != id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+)
+
+type VoteStore struct {
+	*db
+}
+
+func (s *VoteStore) UserPostVote(ctx context.Context, userID, postID uuid.UUID) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.postVotes[voteKey{userID, postID}], nil
+}
+
+func (s *VoteStore) UserCommentVote(ctx context.Context, userID, commentID uuid.UUID) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.commentVotes[voteKey{userID, commentID}], nil
+}
+
+func (s *VoteStore) CastPostVote(ctx context.Context, userID, postID uuid.UUID, value int) error {
+	if value < -1 || value > 1 {
+		return fmt.Errorf("error casting post vote: invalid value %d", value)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return fmt.Errorf("error casting post vote: %w", goreddit.ErrInvalidReference)
+	}
+	if _, ok := s.posts[postID]; !ok {
+		return fmt.Errorf("error casting post vote: %w", goreddit.ErrInvalidReference)
+	}
+	s.setPostVote(voteKey{userID, postID}, value)
+
+	return nil
+}
+
+func (s *VoteStore) CastCommentVote(ctx context.Context, userID, commentID uuid.UUID, value int) error {
+	if value < -1 || value > 1 {
+		return fmt.Errorf("error casting comment vote: invalid value %d", value)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return fmt.Errorf("error casting comment vote: %w", goreddit.ErrInvalidReference)
+	}
+	if _, ok := s.comments[commentID]; !ok {
+		return fmt.Errorf("error casting comment vote: %w", goreddit.ErrInvalidReference)
+	}
+	s.setCommentVote(voteKey{userID, commentID}, value)
+
+	return nil
+}
+
+// setPostVote records a vote and applies the difference to the post's tally.
+// The caller must hold the write lock.
+func (db *db) setPostVote(k voteKey, value int) {
+	p := db.posts[k.targetID]
+	old := db.postVotes[k]
+	p.Votes += value - old
+	p.Upvotes += isVote(value, 1) - isVote(old, 1)
+	p.Downvotes += isVote(value, -1) - isVote(old, -1)
+	db.posts[k.targetID] = p
+	db.postVotes[k] = value
+}
+
+// setCommentVote records a vote and applies the difference to the comment's
+// tally. The caller must hold the write lock.
+func (db *db) setCommentVote(k voteKey, value int) {
+	c := db.comments[k.targetID]
+	old := db.commentVotes[k]
+	c.Votes += value - old
+	c.Upvotes += isVote(value, 1) - isVote(old, 1)
+	c.Downvotes += isVote(value, -1) - isVote(old, -1)
+	db.comments[k.targetID] = c
+	db.commentVotes[k] = value
+}
+
+// isVote returns 1 if value is dir and 0 otherwise.
+func isVote(value, dir int) int {
+	if value == dir {
+		return 1
+	}
+	return 0
+}
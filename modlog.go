@@ -0,0 +1,123 @@
+package goreddit
+
+import "github.com/google/uuid"
+
+// ModAction is a privileged action recorded in the moderation log.
+type ModAction string
+
+const (
+	ModRemove          ModAction = "remove"
+	ModApprove         ModAction = "approve"
+	ModIgnoreReports   ModAction = "ignore_reports"
+	ModLock            ModAction = "lock"
+	ModUnlock          ModAction = "unlock"
+	ModPin             ModAction = "pin"
+	ModUnpin           ModAction = "unpin"
+	ModEditThread      ModAction = "edit_thread"
+	ModDeleteThread    ModAction = "delete_thread"
+	ModInviteModerator ModAction = "invite_moderator"
+	ModAddModerator    ModAction = "add_moderator"
+	ModRemoveModerator ModAction = "remove_moderator"
+	ModBan             ModAction = "ban"
+	ModUnban           ModAction = "unban"
+	ModDeletePost      ModAction = "delete_post"
+	ModDeleteComment   ModAction = "delete_comment"
+	ModPromoteAdmin    ModAction = "promote_admin"
+	ModDemoteAdmin     ModAction = "demote_admin"
+)
+
+// ModActions lists every valid ModAction in the order they are offered as
+// filters.
+var ModActions = []ModAction{
+	ModRemove, ModApprove, ModIgnoreReports, ModLock, ModUnlock, ModPin, ModUnpin,
+	ModEditThread, ModDeleteThread, ModInviteModerator, ModAddModerator, ModRemoveModerator,
+	ModBan, ModUnban, ModDeletePost, ModDeleteComment, ModPromoteAdmin, ModDemoteAdmin,
+}
+
+func (a ModAction) Valid() bool {
+	for _, v := range ModActions {
+		if a == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Label returns the action as shown to users.
+func (a ModAction) Label() string {
+	switch a {
+	case ModRemove:
+		return "Remove"
+	case ModApprove:
+		return "Approve"
+	case ModIgnoreReports:
+		return "Ignore reports"
+	case ModLock:
+		return "Lock"
+	case ModUnlock:
+		return "Unlock"
+	case ModPin:
+		return "Pin"
+	case ModUnpin:
+		return "Unpin"
+	case ModEditThread:
+		return "Edit thread"
+	case ModDeleteThread:
+		return "Delete thread"
+	case ModInviteModerator:
+		return "Invite moderator"
+	case ModAddModerator:
+		return "Add moderator"
+	case ModRemoveModerator:
+		return "Remove moderator"
+	case ModBan:
+		return "Ban"
+	case ModUnban:
+		return "Unban"
+	case ModDeletePost:
+		return "Delete post"
+	case ModDeleteComment:
+		return "Delete comment"
+	case ModPromoteAdmin:
+		return "Promote admin"
+	case ModDemoteAdmin:
+		return "Demote admin"
+	}
+	return string(a)
+}
+
+// ModTarget is the kind of thing a moderation log entry acts on.
+type ModTarget string
+
+const (
+	ModTargetThread  ModTarget = "thread"
+	ModTargetPost    ModTarget = "post"
+	ModTargetComment ModTarget = "comment"
+	ModTargetUser    ModTarget = "user"
+)
+
+// ModTargets lists every valid ModTarget.
+var ModTargets = []ModTarget{ModTargetThread, ModTargetPost, ModTargetComment, ModTargetUser}
+
+func (t ModTarget) Valid() bool {
+	for _, v := range ModTargets {
+		if t == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ModLogOptions selects the entries of the moderation log to list. The zero
+// value lists the first page of every entry, newest first.
+type ModLogOptions struct {
+	// ThreadID restricts the listing to the entries of one thread. Entries
+	// of site-wide actions belong to no thread.
+	ThreadID uuid.NullUUID
+	// Action, Target and Actor restrict the listing to entries with that
+	// action, target kind and actor username when set.
+	Action ModAction
+	Target ModTarget
+	Actor  string
+	PageOptions
+}
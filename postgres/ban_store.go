@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type BanStore struct {
+	*sqlx.DB
+}
+
+// banColumns selects a ban together with the names it refers to.
+const banColumns = `
+	bans.*,
+	COALESCE(threads.title, '') as thread_title,
+	users.username,
+	COALESCE(banners.username, '') as banned_by_username
+`
+
+// banJoins joins the tables read by banColumns.
+const banJoins = `
+	LEFT JOIN threads ON threads.id = bans.thread_id
+	JOIN users ON users.id = bans.user_id
+	LEFT JOIN users banners ON banners.id = bans.banned_by
+`
+
+// banActive is the condition bans in effect meet. Bans end by themselves as
+// time passes, without anything having to change them.
+const banActive = `(bans.expires_at IS NULL OR bans.expires_at > now())`
+
+func (s *BanStore) Ban(ctx context.Context, id uuid.UUID) (goreddit.Ban, error) {
+	var b goreddit.Ban
+
+	query := `SELECT ` + banColumns + ` FROM bans ` + banJoins + ` WHERE bans.id = $1`
+
+	err := s.GetContext(ctx, &b, query, id)
+	if err != nil {
+		return goreddit.Ban{}, fmt.Errorf("error getting ban: %w", translateError(err))
+	}
+
+	return b, nil
+}
+
+func (s *BanStore) ActiveBan(ctx context.Context, userID uuid.UUID, threadID uuid.NullUUID) (goreddit.Ban, error) {
+	var b goreddit.Ban
+
+	query := `SELECT ` + banColumns + ` FROM bans ` + banJoins + `
+		WHERE bans.user_id = $1
+			AND (bans.thread_id IS NULL OR bans.thread_id = $2::uuid)
+			AND ` + banActive + `
+		ORDER BY bans.expires_at DESC NULLS FIRST, bans.thread_id NULLS FIRST, bans.id
+		LIMIT 1`
+
+	err := s.GetContext(ctx, &b, query, userID, threadID)
+	if err != nil {
+		return goreddit.Ban{}, fmt.Errorf("error getting ban: %w", translateError(err))
+	}
+
+	return b, nil
+}
+
+func (s *BanStore) Bans(ctx context.Context, threadID uuid.NullUUID) ([]goreddit.Ban, error) {
+	var bb []goreddit.Ban
+
+	query := `SELECT ` + banColumns + ` FROM bans ` + banJoins + `
+		WHERE bans.thread_id IS NOT DISTINCT FROM $1::uuid AND ` + banActive + `
+		ORDER BY bans.created_at DESC, bans.id`
+
+	err := s.SelectContext(ctx, &bb, query, threadID)
+	if err != nil {
+		return []goreddit.Ban{}, fmt.Errorf("error getting bans: %w", translateError(err))
+	}
+
+	return bb, nil
+}
+
+func (s *BanStore) CreateBan(ctx context.Context, b *goreddit.Ban) error {
+	query := `
+		WITH bans AS (
+			INSERT INTO bans (id, thread_id, user_id, reason, expires_at, banned_by, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7, now()))
+			RETURNING *
+		)
+		SELECT ` + banColumns + ` FROM bans ` + banJoins
+
+	err := s.GetContext(ctx, b, query, b.ID, b.ThreadID, b.UserID, b.Reason, b.ExpiresAt, b.BannedByID, nullTime(b.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("error creating ban: %w", translateError(err))
+	}
+
+	return nil
+}
+
+func (s *BanStore) LiftBan(ctx context.Context, id uuid.UUID) error {
+	res, err := s.ExecContext(ctx, `UPDATE bans SET expires_at = now() WHERE id = $1 AND `+banActive, id)
+	if err != nil {
+		return fmt.Errorf("error lifting ban: %w", translateError(err))
+	}
+	if err := requireRows(res); err != nil {
+		return fmt.Errorf("error lifting ban: %w", err)
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/aleury/goreddit"
 	"github.com/google/uuid"
@@ -12,54 +14,241 @@ type CommentStore struct {
 	*sqlx.DB
 }
 
-func (s *CommentStore) Comment(id uuid.UUID) (goreddit.Comment, error) {
+func (s *CommentStore) Comment(ctx context.Context, id uuid.UUID) (goreddit.Comment, error) {
 	var c goreddit.Comment
 
-	err := s.Get(&c, `SELECT * FROM comments WHERE id = $1`, id)
+	var query string = `
+		SELECT
+			comments.*,
+			COALESCE(users.username, '') as author_username
+		FROM comments
+		LEFT JOIN users ON users.id = comments.author_id
+		WHERE comments.id = $1
+	`
+
+	err := s.GetContext(ctx, &c, query, id)
 	if err != nil {
-		return goreddit.Comment{}, fmt.Errorf("error getting comment: %w", err)
+		return goreddit.Comment{}, fmt.Errorf("error getting comment: %w", translateError(err))
 	}
 
 	return c, nil
 }
 
-func (s *CommentStore) CommentsbyPost(postID uuid.UUID) ([]goreddit.Comment, error) {
+func (s *CommentStore) Comments(ctx context.Context, opts goreddit.ListOptions) ([]goreddit.Comment, goreddit.Page, error) {
+	cur, err := goreddit.DecodeCursor(opts.Cursor, opts.Sort)
+	if err != nil {
+		return []goreddit.Comment{}, goreddit.Page{}, fmt.Errorf("error getting comments: %w", err)
+	}
+
+	var args []interface{}
+	rank := rankExpr("comments", opts.Sort, cur.At)
+	var query string = `
+		SELECT
+			comments.*,
+			posts.thread_id as thread_id,
+			posts.title as post_title,
+			COALESCE(users.username, '') as author_username,
+			` + rank + ` as rank
+		FROM comments
+		JOIN posts ON posts.id = comments.post_id
+		LEFT JOIN users ON users.id = comments.author_id
+		WHERE ` + windowCond("comments", opts, cur.At) + ` AND ` + removedCond("comments", opts) + ` AND ` + keysetCond(rank, "comments.id", cur, &args) + `
+		ORDER BY ` + keysetOrder(rank, "comments.id", cur) + `
+	`
+
+	var rows []struct {
+		goreddit.Comment
+		Rank float64 `db:"rank"`
+	}
+	err = s.SelectContext(ctx, &rows, pageQuery(query, opts.PageSize()), args...)
+	if err != nil {
+		return []goreddit.Comment{}, goreddit.Page{}, fmt.Errorf("error getting comments: %w", translateError(err))
+	}
+
+	pos := make([]goreddit.Cursor, len(rows))
+	for i, row := range rows {
+		pos[i] = goreddit.Cursor{Sort: cur.Sort, Rank: row.Rank, ID: row.ID}
+	}
+	lo, hi, page := trimPage(cur, opts.PageSize(), pos)
+
+	cc := make([]goreddit.Comment, 0, hi-lo)
+	for _, row := range rows[lo:hi] {
+		cc = append(cc, row.Comment)
+	}
+	return cc, page, nil
+}
+
+func (s *CommentStore) CommentsbyPost(ctx context.Context, postID uuid.UUID, opts goreddit.ListOptions) ([]goreddit.Comment, goreddit.Page, error) {
+	cur, err := goreddit.DecodeCursor(opts.Cursor, opts.Sort)
+	if err != nil {
+		return []goreddit.Comment{}, goreddit.Page{}, fmt.Errorf("error getting comments: %w", err)
+	}
+
+	args := []interface{}{postID}
+	rank := rankExpr("comments", opts.Sort, cur.At)
+	var query string = `
+		SELECT
+			comments.*,
+			COALESCE(users.username, '') as author_username,
+			` + rank + ` as rank
+		FROM comments
+		LEFT JOIN users ON users.id = comments.author_id
+		WHERE comments.post_id = $1 AND ` + windowCond("comments", opts, cur.At) + ` AND ` + keysetCond(rank, "comments.id", cur, &args) + `
+		ORDER BY ` + keysetOrder(rank, "comments.id", cur) + `
+	`
+
+	var rows []struct {
+		goreddit.Comment
+		Rank float64 `db:"rank"`
+	}
+	err = s.SelectContext(ctx, &rows, pageQuery(query, opts.PageSize()), args...)
+	if err != nil {
+		return []goreddit.Comment{}, goreddit.Page{}, fmt.Errorf("error getting comments: %w", translateError(err))
+	}
+
+	pos := make([]goreddit.Cursor, len(rows))
+	for i, row := range rows {
+		pos[i] = goreddit.Cursor{Sort: cur.Sort, Rank: row.Rank, ID: row.ID}
+	}
+	lo, hi, page := trimPage(cur, opts.PageSize(), pos)
+
+	cc := make([]goreddit.Comment, 0, hi-lo)
+	for _, row := range rows[lo:hi] {
+		cc = append(cc, row.Comment)
+	}
+	return cc, page, nil
+}
+
+func (s *CommentStore) CommentTree(ctx context.Context, postID uuid.UUID, maxDepth int, sort goreddit.Sort) ([]goreddit.Comment, error) {
 	var cc []goreddit.Comment
+	var query string = `
+		WITH RECURSIVE tree AS (
+			SELECT comments.*, 0 as depth
+			FROM comments
+			WHERE comments.post_id = $1 AND comments.parent_id IS NULL
+			UNION ALL
+			SELECT comments.*, tree.depth + 1
+			FROM comments
+			JOIN tree ON comments.parent_id = tree.id
+			WHERE tree.depth < $2
+		)
+		SELECT
+			tree.*,
+			COALESCE(users.username, '') as author_username,
+			(SELECT COUNT(*) FROM comments replies WHERE replies.parent_id = tree.id) as replies_count
+		FROM tree
+		LEFT JOIN users ON users.id = tree.author_id
+		ORDER BY tree.depth, ` + rankExpr("tree", sort, time.Now()) + ` DESC, tree.id DESC
+	`
+
+	err := s.SelectContext(ctx, &cc, query, postID, maxDepth)
+	if err != nil {
+		return []goreddit.Comment{}, fmt.Errorf("error getting comment tree: %w", translateError(err))
+	}
+
+	return buildTree(cc, uuid.NullUUID{}), nil
+}
 
-	err := s.Select(&cc, `SELECT * FROM comments WHERE post_id = $1 ORDER BY votes DESC`, postID)
+func (s *CommentStore) CommentSubtree(ctx context.Context, id uuid.UUID, maxDepth int, sort goreddit.Sort) (goreddit.Comment, error) {
+	var cc []goreddit.Comment
+	var query string = `
+		WITH RECURSIVE tree AS (
+			SELECT comments.*, 0 as depth
+			FROM comments
+			WHERE comments.id = $1
+			UNION ALL
+			SELECT comments.*, tree.depth + 1
+			FROM comments
+			JOIN tree ON comments.parent_id = tree.id
+			WHERE tree.depth < $2
+		)
+		SELECT
+			tree.*,
+			COALESCE(users.username, '') as author_username,
+			(SELECT COUNT(*) FROM comments replies WHERE replies.parent_id = tree.id) as replies_count
+		FROM tree
+		LEFT JOIN users ON users.id = tree.author_id
+		ORDER BY tree.depth, ` + rankExpr("tree", sort, time.Now()) + ` DESC, tree.id DESC
+	`
+
+	err := s.SelectContext(ctx, &cc, query, id, maxDepth)
 	if err != nil {
-		return []goreddit.Comment{}, fmt.Errorf("error getting comments: %w", err)
+		return goreddit.Comment{}, fmt.Errorf("error getting comment tree: %w", translateError(err))
+	}
+	if len(cc) == 0 {
+		return goreddit.Comment{}, fmt.Errorf("error getting comment tree: %w", goreddit.ErrNotFound)
 	}
 
-	return cc, nil
+	root := cc[0]
+	root.Replies = buildTree(cc[1:], uuid.NullUUID{UUID: root.ID, Valid: true})
+	return root, nil
 }
 
-func (s *CommentStore) CreateComment(c *goreddit.Comment) error {
-	query := `INSERT INTO comments VALUES ($1, $2, $3, $4) RETURNING *`
+func (s *CommentStore) CreateComment(ctx context.Context, c *goreddit.Comment) error {
+	query := `
+		INSERT INTO comments (id, post_id, parent_id, content, votes, author_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7, now()), COALESCE($7, now()))
+		RETURNING *
+	`
 
-	err := s.Get(c, query, c.ID, c.PostID, c.Content, c.Votes)
+	err := s.GetContext(ctx, c, query, c.ID, c.PostID, c.ParentID, c.Content, c.Votes, c.AuthorID, nullTime(c.CreatedAt))
 	if err != nil {
-		return fmt.Errorf("error creating comment: %w", err)
+		return fmt.Errorf("error creating comment: %w", translateError(err))
 	}
 
 	return nil
 }
 
-func (s *CommentStore) UpdateComment(c *goreddit.Comment) error {
-	query := `UPDATE comments SET post_id = $1, content = $2, votes = $3 WHERE id = $4 RETURNING *`
+func (s *CommentStore) UpdateComment(ctx context.Context, c *goreddit.Comment) error {
+	query := `UPDATE comments SET post_id = $1, content = $2, updated_at = now() WHERE id = $3 RETURNING *`
 
-	err := s.Get(c, query, c.PostID, c.Content, c.Votes, c.ID)
+	err := s.GetContext(ctx, c, query, c.PostID, c.Content, c.ID)
 	if err != nil {
-		return fmt.Errorf("error updating comment: %w", err)
+		return fmt.Errorf("error updating comment: %w", translateError(err))
 	}
 
 	return nil
 }
 
-func (s *CommentStore) DeleteComment(id uuid.UUID) error {
-	_, err := s.Exec(`DELETE FROM comments WHERE id = $1`, id)
+func (s *CommentStore) DeleteComment(ctx context.Context, id uuid.UUID) error {
+	res, err := s.ExecContext(ctx, `DELETE FROM comments WHERE id = $1`, id)
 	if err != nil {
+		return fmt.Errorf("error deleting comment: %w", translateError(err))
+	}
+	if err := requireRows(res); err != nil {
 		return fmt.Errorf("error deleting comment: %w", err)
 	}
 	return nil
 }
+
+func (s *CommentStore) SetCommentRemoved(ctx context.Context, id uuid.UUID, removed bool) error {
+	res, err := s.ExecContext(ctx, `UPDATE comments SET removed = $1 WHERE id = $2`, removed, id)
+	if err != nil {
+		return fmt.Errorf("error updating comment: %w", translateError(err))
+	}
+	if err := requireRows(res); err != nil {
+		return fmt.Errorf("error updating comment: %w", err)
+	}
+	return nil
+}
+
+// buildTree nests comments under their parents, starting with the children of
+// parentID. Comments must be ordered by depth so that siblings keep their
+// relative order.
+func buildTree(cc []goreddit.Comment, parentID uuid.NullUUID) []goreddit.Comment {
+	children := map[uuid.NullUUID][]goreddit.Comment{}
+	for _, c := range cc {
+		children[c.ParentID] = append(children[c.ParentID], c)
+	}
+
+	var nest func(parentID uuid.NullUUID) []goreddit.Comment
+	nest = func(parentID uuid.NullUUID) []goreddit.Comment {
+		replies := children[parentID]
+		for i := range replies {
+			replies[i].Replies = nest(uuid.NullUUID{UUID: replies[i].ID, Valid: true})
+		}
+		return replies
+	}
+
+	return nest(parentID)
+}
@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/aleury/goreddit"
+	"github.com/lib/pq"
+)
+
+// translateError maps errors from the database driver onto the errors
+// defined by the goreddit package, keeping the original message.
+func translateError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %v", goreddit.ErrNotFound, err)
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Name() {
+		case "unique_violation":
+			return fmt.Errorf("%w: %v", goreddit.ErrConflict, err)
+		case "foreign_key_violation":
+			return fmt.Errorf("%w: %v", goreddit.ErrInvalidReference, err)
+		}
+	}
+
+	return err
+}
+
+// requireRows returns goreddit.ErrNotFound if a statement affected no rows.
+func requireRows(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return goreddit.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aleury/goreddit"
+)
+
+// rankExpr returns an SQL expression ranking the rows of table for sort as of
+// time at, where higher ranks come first. The formulas match the ranking
+// package.
+func rankExpr(table string, sort goreddit.Sort, at time.Time) string {
+	var expr string
+	switch sort {
+	case goreddit.SortNew:
+		expr = `EXTRACT(EPOCH FROM %[1]s.created_at)`
+	case goreddit.SortTop:
+		expr = `%[1]s.votes`
+	case goreddit.SortControversial:
+		expr = `CASE WHEN %[1]s.upvotes > 0 AND %[1]s.downvotes > 0
+			THEN POWER(%[1]s.upvotes + %[1]s.downvotes,
+				LEAST(%[1]s.upvotes, %[1]s.downvotes)::float8 / GREATEST(%[1]s.upvotes, %[1]s.downvotes))
+			ELSE 0 END`
+	case goreddit.SortRising:
+		expr = `%[1]s.votes / POWER(EXTRACT(EPOCH FROM %[2]s - %[1]s.created_at) / 3600 + 2, 1.5)`
+	default:
+		expr = `SIGN(%[1]s.votes) * LOG(GREATEST(ABS(%[1]s.votes), 1))
+			+ (EXTRACT(EPOCH FROM %[1]s.created_at) - 1134028003) / 45000`
+	}
+	return fmt.Sprintf("("+expr+")::float8", table, timeLiteral(at))
+}
+
+// windowCond returns an SQL condition restricting the rows of table to those
+// that belong in a listing with opts as of time at.
+func windowCond(table string, opts goreddit.ListOptions, at time.Time) string {
+	if opts.Sort == goreddit.SortRising {
+		return fmt.Sprintf("%s.created_at > %s - interval '%d seconds'", table, timeLiteral(at), int(goreddit.RisingWindow.Seconds()))
+	}
+	if d := opts.Window.Duration(); opts.Sort.Windowed() && d > 0 {
+		return fmt.Sprintf("%s.created_at > %s - interval '%d seconds'", table, timeLiteral(at), int(d.Seconds()))
+	}
+	return "TRUE"
+}
+
+// timeLiteral returns t as an SQL timestamptz literal. Since t is formatted
+// here, it needs no escaping even when it comes from a cursor.
+func timeLiteral(t time.Time) string {
+	return fmt.Sprintf("'%s'::timestamptz", t.UTC().Format(time.RFC3339Nano))
+}
+
+// removedCond returns an SQL condition leaving the removed rows of table out
+// of a listing unless opts includes them.
+func removedCond(table string, opts goreddit.ListOptions) string {
+	if opts.IncludeRemoved {
+		return "TRUE"
+	}
+	return fmt.Sprintf("NOT %s.removed", table)
+}
+
+// keysetCond returns an SQL condition restricting a listing ranked by rank
+// and idCol to the rows on the far side of cur, appending its arguments to
+// args.
+func keysetCond(rank, idCol string, cur goreddit.Cursor, args *[]interface{}) string {
+	if cur.IsZero() {
+		return "TRUE"
+	}
+	op := "<"
+	if cur.Before {
+		op = ">"
+	}
+	*args = append(*args, cur.Rank, cur.ID)
+	return fmt.Sprintf("(%s, %s) %s ($%d, $%d)", rank, idCol, op, len(*args)-1, len(*args))
+}
+
+// keysetOrder returns the ORDER BY clause that lists the rows nearest to cur
+// first.
+func keysetOrder(rank, idCol string, cur goreddit.Cursor) string {
+	if cur.Before {
+		return fmt.Sprintf("%s ASC, %s ASC", rank, idCol)
+	}
+	return fmt.Sprintf("%s DESC, %s DESC", rank, idCol)
+}
+
+// pageQuery limits query, which must select rank and id columns and be
+// ordered by keysetOrder, to one row more than a page and puts the rows back
+// in listing order.
+func pageQuery(query string, limit int) string {
+	return fmt.Sprintf(`SELECT * FROM (%s LIMIT %d) page ORDER BY page.rank DESC, page.id DESC`, query, limit+1)
+}
+
+// trimPage drops the extra row fetched by pageQuery, given the positions of
+// the rows in listing order. The page holds rows lo through hi-1.
+func trimPage(cur goreddit.Cursor, limit int, pos []goreddit.Cursor) (lo, hi int, page goreddit.Page) {
+	lo, hi = 0, len(pos)
+	more := len(pos) > limit
+	if more && cur.Before {
+		lo = 1
+	} else if more {
+		hi = limit
+	}
+	if lo == hi {
+		return lo, hi, goreddit.Page{}
+	}
+	return lo, hi, goreddit.NewPage(cur, pos[lo], pos[hi-1], more)
+}
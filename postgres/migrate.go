@@ -0,0 +1,319 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// migrationFiles holds the schema migrations, named VERSION_NAME.up.sql and
+// VERSION_NAME.down.sql.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is a change to the database schema and the change that undoes it.
+type Migration struct {
+	Version int
+	Name    string
+	// Applied and AppliedAt are set by Migrator.Status.
+	Applied   bool
+	AppliedAt time.Time
+
+	up, down string
+}
+
+// migrationLock is the key of the Postgres advisory lock a Migrator holds
+// while it reads or changes the schema, so that replicas starting together
+// do not apply the same migration twice.
+const migrationLock int64 = 0x676f726564646974 // "goreddit"
+
+// Migrator applies the embedded migrations to a database and records each
+// applied version in the applied_migrations table.
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+}
+
+func NewMigrator(dataSourceName string) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sqlx.Open("postgres", dataSourceName)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error connecting to database: %w", err)
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+func (m *Migrator) Close() error {
+	return m.db.Close()
+}
+
+// Status lists every migration, oldest first, noting which are applied.
+func (m *Migrator) Status(ctx context.Context) ([]Migration, error) {
+	var mm []Migration
+	err := m.locked(ctx, func(conn *sqlx.Conn, applied map[int]time.Time) error {
+		mm = make([]Migration, len(m.migrations))
+		for i, mig := range m.migrations {
+			mig.AppliedAt, mig.Applied = applied[mig.Version]
+			mm[i] = mig
+		}
+		return nil
+	})
+	return mm, err
+}
+
+// Up applies the pending migrations in order, at most n of them if n is
+// positive. It returns the migrations applied.
+func (m *Migrator) Up(ctx context.Context, n int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *sqlx.Conn, applied map[int]time.Time) error {
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if n > 0 && len(done) == n {
+				break
+			}
+			err := apply(ctx, conn, mig.up, `INSERT INTO applied_migrations (version, name) VALUES ($1, $2)`, mig.Version, mig.Name)
+			if err != nil {
+				return fmt.Errorf("error applying migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down undoes the applied migrations newest first, at most n of them if n is
+// positive. It returns the migrations undone.
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *sqlx.Conn, applied map[int]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if n > 0 && len(done) == n {
+				break
+			}
+			err := apply(ctx, conn, mig.down, `DELETE FROM applied_migrations WHERE version = $1`, mig.Version)
+			if err != nil {
+				return fmt.Errorf("error undoing migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// locked calls fn with a connection holding the migration lock and the
+// versions applied so far, mapped to when they were applied.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sqlx.Conn, applied map[int]time.Time) error) error {
+	// Advisory locks belong to a session, so everything is done on one
+	// connection.
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("error connecting to database: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLock); err != nil {
+		return fmt.Errorf("error locking migrations: %w", err)
+	}
+	// The lock is released with the session if unlocking fails.
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLock)
+
+	applied, err := appliedMigrations(ctx, conn, m.migrations)
+	if err != nil {
+		return err
+	}
+	return fn(conn, applied)
+}
+
+// appliedMigrations creates the applied_migrations table if it does not exist
+// and returns its contents. A new table takes over the versions of the
+// schema_migrations table of the golang-migrate tool, which only records
+// the newest version, so that databases migrated with it carry on.
+func appliedMigrations(ctx context.Context, conn *sqlx.Conn, migrations []Migration) (map[int]time.Time, error) {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error reading applied migrations: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.GetContext(ctx, &exists, `SELECT to_regclass('applied_migrations') IS NOT NULL`); err != nil {
+		return nil, fmt.Errorf("error reading applied migrations: %w", err)
+	}
+	if !exists {
+		if err := createAppliedMigrations(ctx, tx, migrations); err != nil {
+			return nil, err
+		}
+	}
+
+	var rows []struct {
+		Version   int       `db:"version"`
+		AppliedAt time.Time `db:"applied_at"`
+	}
+	if err := tx.SelectContext(ctx, &rows, `SELECT version, applied_at FROM applied_migrations`); err != nil {
+		return nil, fmt.Errorf("error reading applied migrations: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error reading applied migrations: %w", err)
+	}
+
+	applied := make(map[int]time.Time, len(rows))
+	for _, r := range rows {
+		applied[r.Version] = r.AppliedAt
+	}
+	return applied, nil
+}
+
+func createAppliedMigrations(ctx context.Context, tx *sqlx.Tx, migrations []Migration) error {
+	_, err := tx.ExecContext(ctx, `
+		CREATE TABLE applied_migrations (
+			version BIGINT NOT NULL PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating applied_migrations: %w", err)
+	}
+
+	var legacy bool
+	if err := tx.GetContext(ctx, &legacy, `SELECT to_regclass('schema_migrations') IS NOT NULL`); err != nil {
+		return fmt.Errorf("error reading schema_migrations: %w", err)
+	}
+	if !legacy {
+		return nil
+	}
+
+	var row struct {
+		Version int  `db:"version"`
+		Dirty   bool `db:"dirty"`
+	}
+	err = tx.GetContext(ctx, &row, `SELECT version, dirty FROM schema_migrations LIMIT 1`)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error reading schema_migrations: %w", err)
+	}
+	if row.Dirty {
+		return fmt.Errorf("migration %d was left half applied; repair the schema and clear schema_migrations.dirty", row.Version)
+	}
+
+	// golang-migrate applies the files in order, so every version up to
+	// the recorded one is applied.
+	for _, mig := range migrations {
+		if mig.Version > row.Version {
+			break
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO applied_migrations (version, name) VALUES ($1, $2)`, mig.Version, mig.Name)
+		if err != nil {
+			return fmt.Errorf("error importing schema_migrations: %w", err)
+		}
+	}
+	return nil
+}
+
+// apply runs the statements of a migration file and the query that records
+// it, all in one transaction.
+func apply(ctx context.Context, conn *sqlx.Conn, statements, record string, args ...interface{}) error {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, statements); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// loadMigrations reads the migrations in the migrations directory of fsys,
+// ordered by version. Every migration must have an up and a down file, and
+// neither may be empty, so that rolling back never silently does nothing.
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	// found counts the up and down files found for each version.
+	found := map[int]int{}
+	for _, file := range files {
+		base := strings.TrimSuffix(path.Base(file), ".sql")
+		direction := path.Ext(base)
+		base = strings.TrimSuffix(base, direction)
+		i := strings.Index(base, "_")
+		if i < 0 || (direction != ".up" && direction != ".down") {
+			return nil, fmt.Errorf("error loading migrations: malformed file name %s", file)
+		}
+		version, err := strconv.Atoi(base[:i])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("error loading migrations: malformed version in %s", file)
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("error loading migrations: %w", err)
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: base[i+1:]}
+			byVersion[version] = mig
+		} else if mig.Name != base[i+1:] {
+			return nil, fmt.Errorf("error loading migrations: version %d is used by %s and %s", version, mig.Name, base[i+1:])
+		}
+		if strings.TrimSpace(string(content)) == "" {
+			return nil, fmt.Errorf("error loading migrations: %s is empty", file)
+		}
+		if direction == ".up" {
+			mig.up = string(content)
+		} else {
+			mig.down = string(content)
+		}
+		found[version]++
+	}
+
+	mm := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if found[mig.Version] != 2 {
+			return nil, fmt.Errorf("error loading migrations: %d_%s needs both an up and a down file", mig.Version, mig.Name)
+		}
+		mm = append(mm, *mig)
+	}
+	sort.Slice(mm, func(i, j int) bool { return mm[i].Version < mm[j].Version })
+
+	return mm, nil
+}
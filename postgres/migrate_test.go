@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	mm, err := loadMigrations(migrationFiles)
+	if err != nil {
+		t.Fatalf("loadMigrations(embedded): %v", err)
+	}
+	for i, m := range mm {
+		if m.Version != i+1 {
+			t.Fatalf("migration %d has version %d, want versions without gaps", i, m.Version)
+		}
+	}
+
+	mm, err = loadMigrations(fstest.MapFS{
+		"migrations/10_second.up.sql":   {Data: []byte("CREATE TABLE b ();")},
+		"migrations/10_second.down.sql": {Data: []byte("DROP TABLE b;")},
+		"migrations/2_first.up.sql":     {Data: []byte("CREATE TABLE a ();")},
+		"migrations/2_first.down.sql":   {Data: []byte("DROP TABLE a;")},
+	})
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+	if len(mm) != 2 || mm[0].Version != 2 || mm[0].Name != "first" || mm[1].Version != 10 || mm[1].down != "DROP TABLE b;" {
+		t.Errorf("loadMigrations = %+v, want first then second", mm)
+	}
+
+	sql := &fstest.MapFile{Data: []byte("SELECT 1;")}
+	for name, fsys := range map[string]fstest.MapFS{
+		"missing down": {"migrations/1_a.up.sql": sql},
+		"empty down":   {"migrations/1_a.up.sql": sql, "migrations/1_a.down.sql": {Data: []byte("\n")}},
+		"bad version":  {"migrations/x_a.up.sql": sql, "migrations/x_a.down.sql": sql},
+		"bad name":     {"migrations/1.up.sql": sql, "migrations/1.down.sql": sql},
+		"two names":    {"migrations/1_a.up.sql": sql, "migrations/1_b.down.sql": sql},
+	} {
+		if _, err := loadMigrations(fsys); err == nil {
+			t.Errorf("loadMigrations with %s succeeded, want an error", name)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+-- The documents are indexed as expressions rather than stored in generated
+-- columns, so that queries selecting table.* keep matching their structs.
+-- postgres/search_store.go must use the same expressions for the indexes to
+-- be used.
+
+CREATE INDEX threads_search_idx ON threads USING GIN ((
+    setweight(to_tsvector('english', title), 'A') ||
+    setweight(to_tsvector('english', description), 'B')
+));
+
+CREATE INDEX posts_search_idx ON posts USING GIN ((
+    setweight(to_tsvector('english', title), 'A') ||
+    setweight(to_tsvector('english', content), 'B')
+));
+
+CREATE INDEX comments_search_idx ON comments USING GIN ((
+    setweight(to_tsvector('english', content), 'B')
+));
@@ -0,0 +1,2 @@
+DROP INDEX posts_thread_id_idx;
+DROP TABLE subscriptions;
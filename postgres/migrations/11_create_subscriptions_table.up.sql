@@ -0,0 +1,9 @@
+CREATE TABLE subscriptions (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    thread_id UUID NOT NULL REFERENCES threads (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, thread_id)
+);
+
+CREATE INDEX subscriptions_thread_id_idx ON subscriptions (thread_id);
+CREATE INDEX posts_thread_id_idx ON posts (thread_id);
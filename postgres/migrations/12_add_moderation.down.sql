@@ -0,0 +1,5 @@
+DROP INDEX posts_pinned_idx;
+ALTER TABLE comments DROP COLUMN removed;
+ALTER TABLE posts DROP COLUMN removed, DROP COLUMN locked, DROP COLUMN pinned;
+DROP TABLE moderator_invites;
+DROP TABLE moderators;
@@ -0,0 +1,32 @@
+CREATE TABLE moderators (
+    thread_id UUID NOT NULL REFERENCES threads (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (thread_id, user_id)
+);
+
+CREATE INDEX moderators_user_id_idx ON moderators (user_id);
+
+CREATE TABLE moderator_invites (
+    thread_id UUID NOT NULL REFERENCES threads (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    invited_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (thread_id, user_id)
+);
+
+CREATE INDEX moderator_invites_user_id_idx ON moderator_invites (user_id);
+
+-- Threads created so far are moderated by their authors.
+INSERT INTO moderators (thread_id, user_id, created_at)
+SELECT id, author_id, created_at FROM threads WHERE author_id IS NOT NULL;
+
+ALTER TABLE posts
+    ADD COLUMN removed BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN locked BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE comments
+    ADD COLUMN removed BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX posts_pinned_idx ON posts (thread_id) WHERE pinned;
@@ -0,0 +1,16 @@
+CREATE TABLE reports (
+    id UUID PRIMARY KEY,
+    post_id UUID NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    comment_id UUID REFERENCES comments (id) ON DELETE CASCADE,
+    reporter_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    reason TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX reports_post_id_idx ON reports (post_id);
+CREATE INDEX reports_comment_id_idx ON reports (comment_id);
+
+-- Users can report a post or comment only once.
+CREATE UNIQUE INDEX reports_post_reporter_idx ON reports (post_id, reporter_id) WHERE comment_id IS NULL;
+CREATE UNIQUE INDEX reports_comment_reporter_idx ON reports (comment_id, reporter_id) WHERE comment_id IS NOT NULL;
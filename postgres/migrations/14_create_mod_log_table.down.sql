@@ -0,0 +1,2 @@
+DROP TABLE mod_log;
+DROP FUNCTION mod_log_append_only();
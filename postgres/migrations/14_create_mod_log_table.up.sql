@@ -0,0 +1,34 @@
+-- The mod log keeps no foreign keys, so that entries outlive the users and
+-- content they name.
+CREATE TABLE mod_log (
+    id UUID PRIMARY KEY,
+    thread_id UUID,
+    thread_title TEXT NOT NULL DEFAULT '',
+    actor_id UUID,
+    actor_username TEXT NOT NULL DEFAULT '',
+    action TEXT NOT NULL,
+    target_kind TEXT NOT NULL,
+    target_id UUID NOT NULL,
+    target_label TEXT NOT NULL DEFAULT '',
+    post_id UUID,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX mod_log_created_at_idx ON mod_log (created_at DESC, id DESC);
+CREATE INDEX mod_log_thread_id_idx ON mod_log (thread_id, created_at DESC, id DESC);
+
+-- Entries can be added but never changed or deleted.
+CREATE FUNCTION mod_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'mod_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER mod_log_append_only
+    BEFORE UPDATE OR DELETE ON mod_log
+    FOR EACH ROW EXECUTE FUNCTION mod_log_append_only();
+
+CREATE TRIGGER mod_log_no_truncate
+    BEFORE TRUNCATE ON mod_log
+    FOR EACH STATEMENT EXECUTE FUNCTION mod_log_append_only();
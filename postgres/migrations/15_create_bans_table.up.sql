@@ -0,0 +1,14 @@
+CREATE TABLE bans (
+    id UUID PRIMARY KEY,
+    -- Bans without a thread apply to the whole site.
+    thread_id UUID REFERENCES threads (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reason TEXT NOT NULL DEFAULT '',
+    -- Bans without an expiry are permanent.
+    expires_at TIMESTAMPTZ,
+    banned_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX bans_user_id_idx ON bans (user_id, thread_id);
+CREATE INDEX bans_thread_id_idx ON bans (thread_id, created_at DESC);
@@ -0,0 +1,4 @@
+DROP TABLE comment_votes;
+DROP TABLE post_votes;
+DROP FUNCTION tally_comment_vote;
+DROP FUNCTION tally_post_vote;
\ No newline at end of file
@@ -0,0 +1,48 @@
+CREATE TABLE post_votes (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    post_id UUID NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    value SMALLINT NOT NULL CHECK (value BETWEEN -1 AND 1),
+    PRIMARY KEY (user_id, post_id)
+);
+
+CREATE TABLE comment_votes (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    comment_id UUID NOT NULL REFERENCES comments (id) ON DELETE CASCADE,
+    value SMALLINT NOT NULL CHECK (value BETWEEN -1 AND 1),
+    PRIMARY KEY (user_id, comment_id)
+);
+
+-- The votes columns are maintained from the ledgers by applying the
+-- difference between the old and new value of every changed vote row,
+-- which keeps concurrent votes on the same target from losing updates.
+CREATE FUNCTION tally_post_vote() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE posts SET votes = votes - OLD.value WHERE id = OLD.post_id;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE posts SET votes = votes + NEW.value WHERE id = NEW.post_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION tally_comment_vote() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE comments SET votes = votes - OLD.value WHERE id = OLD.comment_id;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE comments SET votes = votes + NEW.value WHERE id = NEW.comment_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER post_votes_tally
+AFTER INSERT OR UPDATE OR DELETE ON post_votes
+FOR EACH ROW EXECUTE FUNCTION tally_post_vote();
+
+CREATE TRIGGER comment_votes_tally
+AFTER INSERT OR UPDATE OR DELETE ON comment_votes
+FOR EACH ROW EXECUTE FUNCTION tally_comment_vote();
@@ -0,0 +1,3 @@
+ALTER TABLE threads ADD COLUMN author_id UUID REFERENCES users (id) ON DELETE SET NULL;
+ALTER TABLE posts ADD COLUMN author_id UUID REFERENCES users (id) ON DELETE SET NULL;
+ALTER TABLE comments ADD COLUMN author_id UUID REFERENCES users (id) ON DELETE SET NULL;
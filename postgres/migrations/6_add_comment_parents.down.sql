@@ -0,0 +1,4 @@
+DROP INDEX comments_parent_id_idx;
+DROP INDEX comments_post_id_idx;
+
+ALTER TABLE comments DROP COLUMN parent_id;
@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN created_at, DROP COLUMN updated_at;
+ALTER TABLE comments DROP COLUMN created_at, DROP COLUMN updated_at;
+ALTER TABLE posts DROP COLUMN created_at, DROP COLUMN updated_at;
+ALTER TABLE threads DROP COLUMN created_at, DROP COLUMN updated_at;
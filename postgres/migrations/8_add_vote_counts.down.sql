@@ -0,0 +1,29 @@
+CREATE OR REPLACE FUNCTION tally_post_vote() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE posts SET votes = votes - OLD.value WHERE id = OLD.post_id;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE posts SET votes = votes + NEW.value WHERE id = NEW.post_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION tally_comment_vote() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE comments SET votes = votes - OLD.value WHERE id = OLD.comment_id;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE comments SET votes = votes + NEW.value WHERE id = NEW.comment_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX comments_created_at_idx;
+DROP INDEX posts_created_at_idx;
+
+ALTER TABLE comments DROP COLUMN downvotes, DROP COLUMN upvotes;
+ALTER TABLE posts DROP COLUMN downvotes, DROP COLUMN upvotes;
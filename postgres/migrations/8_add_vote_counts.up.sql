@@ -0,0 +1,58 @@
+ALTER TABLE posts
+    ADD COLUMN upvotes INT NOT NULL DEFAULT 0,
+    ADD COLUMN downvotes INT NOT NULL DEFAULT 0;
+
+ALTER TABLE comments
+    ADD COLUMN upvotes INT NOT NULL DEFAULT 0,
+    ADD COLUMN downvotes INT NOT NULL DEFAULT 0;
+
+UPDATE posts SET
+    upvotes = (SELECT COUNT(*) FROM post_votes WHERE post_id = posts.id AND value = 1),
+    downvotes = (SELECT COUNT(*) FROM post_votes WHERE post_id = posts.id AND value = -1);
+
+UPDATE comments SET
+    upvotes = (SELECT COUNT(*) FROM comment_votes WHERE comment_id = comments.id AND value = 1),
+    downvotes = (SELECT COUNT(*) FROM comment_votes WHERE comment_id = comments.id AND value = -1);
+
+CREATE INDEX posts_created_at_idx ON posts (created_at);
+CREATE INDEX comments_created_at_idx ON comments (created_at);
+
+CREATE OR REPLACE FUNCTION tally_post_vote() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE posts SET
+            votes = votes - OLD.value,
+            upvotes = upvotes - (OLD.value = 1)::int,
+            downvotes = downvotes - (OLD.value = -1)::int
+        WHERE id = OLD.post_id;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE posts SET
+            votes = votes + NEW.value,
+            upvotes = upvotes + (NEW.value = 1)::int,
+            downvotes = downvotes + (NEW.value = -1)::int
+        WHERE id = NEW.post_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION tally_comment_vote() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE comments SET
+            votes = votes - OLD.value,
+            upvotes = upvotes - (OLD.value = 1)::int,
+            downvotes = downvotes - (OLD.value = -1)::int
+        WHERE id = OLD.comment_id;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE comments SET
+            votes = votes + NEW.value,
+            upvotes = upvotes + (NEW.value = 1)::int,
+            downvotes = downvotes + (NEW.value = -1)::int
+        WHERE id = NEW.comment_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
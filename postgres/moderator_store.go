@@ -0,0 +1,171 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type ModeratorStore struct {
+	*sqlx.DB
+}
+
+func (s *ModeratorStore) Moderators(ctx context.Context, threadID uuid.UUID) ([]goreddit.Moderator, error) {
+	var mm []goreddit.Moderator
+
+	var query string = `
+		SELECT moderators.*, users.username
+		FROM moderators
+		JOIN users ON users.id = moderators.user_id
+		WHERE moderators.thread_id = $1
+		ORDER BY moderators.created_at, moderators.user_id
+	`
+
+	err := s.SelectContext(ctx, &mm, query, threadID)
+	if err != nil {
+		return []goreddit.Moderator{}, fmt.Errorf("error getting moderators: %w", translateError(err))
+	}
+
+	return mm, nil
+}
+
+func (s *ModeratorStore) IsModerator(ctx context.Context, threadID, userID uuid.UUID) (bool, error) {
+	var moderator bool
+
+	query := `SELECT EXISTS (SELECT 1 FROM moderators WHERE thread_id = $1 AND user_id = $2)`
+
+	err := s.GetContext(ctx, &moderator, query, threadID, userID)
+	if err != nil {
+		return false, fmt.Errorf("error getting moderator: %w", translateError(err))
+	}
+
+	return moderator, nil
+}
+
+func (s *ModeratorStore) AddModerator(ctx context.Context, threadID, userID uuid.UUID) error {
+	query := `
+		INSERT INTO moderators (thread_id, user_id) VALUES ($1, $2)
+		ON CONFLICT (thread_id, user_id) DO NOTHING
+	`
+
+	_, err := s.ExecContext(ctx, query, threadID, userID)
+	if err != nil {
+		return fmt.Errorf("error adding moderator: %w", translateError(err))
+	}
+
+	return nil
+}
+
+func (s *ModeratorStore) RemoveModerator(ctx context.Context, threadID, userID uuid.UUID) error {
+	res, err := s.ExecContext(ctx, `DELETE FROM moderators WHERE thread_id = $1 AND user_id = $2`, threadID, userID)
+	if err != nil {
+		return fmt.Errorf("error removing moderator: %w", translateError(err))
+	}
+	if err := requireRows(res); err != nil {
+		return fmt.Errorf("error removing moderator: %w", err)
+	}
+	return nil
+}
+
+// inviteColumns selects an invite together with the names it refers to.
+const inviteColumns = `
+	moderator_invites.*,
+	threads.title as thread_title,
+	users.username,
+	COALESCE(inviters.username, '') as invited_by_username
+`
+
+// inviteJoins joins the tables read by inviteColumns.
+const inviteJoins = `
+	JOIN threads ON threads.id = moderator_invites.thread_id
+	JOIN users ON users.id = moderator_invites.user_id
+	LEFT JOIN users inviters ON inviters.id = moderator_invites.invited_by
+`
+
+func (s *ModeratorStore) Invite(ctx context.Context, threadID, userID uuid.UUID) (goreddit.Invite, error) {
+	var inv goreddit.Invite
+
+	query := `SELECT ` + inviteColumns + ` FROM moderator_invites ` + inviteJoins + `
+		WHERE moderator_invites.thread_id = $1 AND moderator_invites.user_id = $2`
+
+	err := s.GetContext(ctx, &inv, query, threadID, userID)
+	if err != nil {
+		return goreddit.Invite{}, fmt.Errorf("error getting invite: %w", translateError(err))
+	}
+
+	return inv, nil
+}
+
+func (s *ModeratorStore) Invites(ctx context.Context, userID uuid.UUID) ([]goreddit.Invite, error) {
+	var ii []goreddit.Invite
+
+	query := `SELECT ` + inviteColumns + ` FROM moderator_invites ` + inviteJoins + `
+		WHERE moderator_invites.user_id = $1
+		ORDER BY moderator_invites.created_at DESC, moderator_invites.thread_id`
+
+	err := s.SelectContext(ctx, &ii, query, userID)
+	if err != nil {
+		return []goreddit.Invite{}, fmt.Errorf("error getting invites: %w", translateError(err))
+	}
+
+	return ii, nil
+}
+
+func (s *ModeratorStore) CreateInvite(ctx context.Context, inv *goreddit.Invite) error {
+	query := `
+		WITH moderator_invites AS (
+			INSERT INTO moderator_invites (thread_id, user_id, invited_by, created_at)
+			VALUES ($1, $2, $3, COALESCE($4, now()))
+			RETURNING *
+		)
+		SELECT ` + inviteColumns + ` FROM moderator_invites ` + inviteJoins
+
+	err := s.GetContext(ctx, inv, query, inv.ThreadID, inv.UserID, inv.InvitedByID, nullTime(inv.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("error creating invite: %w", translateError(err))
+	}
+
+	return nil
+}
+
+func (s *ModeratorStore) DeleteInvite(ctx context.Context, threadID, userID uuid.UUID) error {
+	res, err := s.ExecContext(ctx, `DELETE FROM moderator_invites WHERE thread_id = $1 AND user_id = $2`, threadID, userID)
+	if err != nil {
+		return fmt.Errorf("error deleting invite: %w", translateError(err))
+	}
+	if err := requireRows(res); err != nil {
+		return fmt.Errorf("error deleting invite: %w", err)
+	}
+	return nil
+}
+
+// AcceptInvite deletes the invite and makes the invited user a moderator in
+// one statement, so that an invite is never used up without effect.
+func (s *ModeratorStore) AcceptInvite(ctx context.Context, threadID, userID uuid.UUID) error {
+	var accepted int
+
+	query := `
+		WITH invite AS (
+			DELETE FROM moderator_invites WHERE thread_id = $1 AND user_id = $2
+			RETURNING thread_id, user_id
+		), moderator AS (
+			INSERT INTO moderators (thread_id, user_id)
+			SELECT thread_id, user_id FROM invite
+			ON CONFLICT (thread_id, user_id) DO NOTHING
+		)
+		SELECT COUNT(*) FROM invite
+	`
+
+	err := s.GetContext(ctx, &accepted, query, threadID, userID)
+	if err != nil {
+		return fmt.Errorf("error accepting invite: %w", translateError(err))
+	}
+	if accepted == 0 {
+		return fmt.Errorf("error accepting invite: %w", goreddit.ErrNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aleury/goreddit"
+	"github.com/jmoiron/sqlx"
+)
+
+type ModLogStore struct {
+	*sqlx.DB
+}
+
+func (s *ModLogStore) ModLog(ctx context.Context, opts goreddit.ModLogOptions) ([]goreddit.ModLogEntry, goreddit.Page, error) {
+	cur, err := goreddit.DecodeCursor(opts.Cursor, goreddit.SortNew)
+	if err != nil {
+		return []goreddit.ModLogEntry{}, goreddit.Page{}, fmt.Errorf("error getting mod log: %w", err)
+	}
+
+	// The filters ignore the arguments that are NULL or empty.
+	args := []interface{}{opts.ThreadID, opts.Action, opts.Target, opts.Actor}
+	rank := rankExpr("mod_log", goreddit.SortNew, cur.At)
+	query := `
+		SELECT mod_log.*, ` + rank + ` as rank
+		FROM mod_log
+		WHERE ($1::uuid IS NULL OR mod_log.thread_id = $1)
+			AND ($2 = '' OR mod_log.action = $2)
+			AND ($3 = '' OR mod_log.target_kind = $3)
+			AND ($4 = '' OR mod_log.actor_username = $4)
+			AND ` + keysetCond(rank, "mod_log.id", cur, &args) + `
+		ORDER BY ` + keysetOrder(rank, "mod_log.id", cur) + `
+	`
+
+	var rows []struct {
+		goreddit.ModLogEntry
+		Rank float64 `db:"rank"`
+	}
+	err = s.SelectContext(ctx, &rows, pageQuery(query, opts.PageSize()), args...)
+	if err != nil {
+		return []goreddit.ModLogEntry{}, goreddit.Page{}, fmt.Errorf("error getting mod log: %w", translateError(err))
+	}
+
+	pos := make([]goreddit.Cursor, len(rows))
+	for i, row := range rows {
+		pos[i] = goreddit.Cursor{Sort: cur.Sort, Rank: row.Rank, ID: row.ID}
+	}
+	lo, hi, page := trimPage(cur, opts.PageSize(), pos)
+
+	ee := make([]goreddit.ModLogEntry, 0, hi-lo)
+	for _, row := range rows[lo:hi] {
+		ee = append(ee, row.ModLogEntry)
+	}
+	return ee, page, nil
+}
+
+func (s *ModLogStore) CreateModLogEntry(ctx context.Context, e *goreddit.ModLogEntry) error {
+	query := `
+		INSERT INTO mod_log (
+			id, thread_id, thread_title, actor_id, actor_username, action,
+			target_kind, target_id, target_label, post_id, reason, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, COALESCE($12, now()))
+		RETURNING *
+	`
+
+	err := s.GetContext(ctx, e, query,
+		e.ID, e.ThreadID, e.ThreadTitle, e.ActorID, e.ActorUsername, e.Action,
+		e.TargetKind, e.TargetID, e.TargetLabel, e.PostID, e.Reason, nullTime(e.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("error creating mod log entry: %w", translateError(err))
+	}
+
+	return nil
+}
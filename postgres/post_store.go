@@ -1,97 +1,298 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/aleury/goreddit"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type PostStore struct {
 	*sqlx.DB
 }
 
-func (s *PostStore) Post(id uuid.UUID) (goreddit.Post, error) {
+func (s *PostStore) Post(ctx context.Context, id uuid.UUID) (goreddit.Post, error) {
 	var p goreddit.Post
 
-	err := s.Get(&p, `SELECT * FROM posts WHERE id = $1`, id)
+	var query string = `
+		SELECT
+			posts.*,
+			threads.title as thread_title,
+			COALESCE(users.username, '') as author_username
+		FROM posts
+		JOIN threads ON threads.id = posts.thread_id
+		LEFT JOIN users ON users.id = posts.author_id
+		WHERE posts.id = $1
+	`
+
+	err := s.GetContext(ctx, &p, query, id)
 	if err != nil {
-		return goreddit.Post{}, fmt.Errorf("error getting post: %w", err)
+		return goreddit.Post{}, fmt.Errorf("error getting post: %w", translateError(err))
 	}
 
 	return p, nil
 }
 
-func (s *PostStore) Posts() ([]goreddit.Post, error) {
-	var pp []goreddit.Post
+func (s *PostStore) Posts(ctx context.Context, opts goreddit.ListOptions) ([]goreddit.Post, goreddit.Page, error) {
+	cur, err := goreddit.DecodeCursor(opts.Cursor, opts.Sort)
+	if err != nil {
+		return []goreddit.Post{}, goreddit.Page{}, fmt.Errorf("error getting posts: %w", err)
+	}
+
+	var args []interface{}
+	rank := rankExpr("posts", opts.Sort, cur.At)
 	var query string = `
 		SELECT
 			posts.*,
 			threads.title as thread_title,
-			COUNT(comments.*) as comments_count
+			COUNT(comments.*) as comments_count,
+			COALESCE(users.username, '') as author_username,
+			` + rank + ` as rank
 		FROM posts
 		LEFT JOIN threads ON threads.id = posts.thread_id
 		LEFT JOIN comments ON comments.post_id = posts.id
-		GROUP BY posts.id, threads.title
-		ORDER BY posts.votes DESC
+		LEFT JOIN users ON users.id = posts.author_id
+		WHERE ` + windowCond("posts", opts, cur.At) + ` AND ` + removedCond("posts", opts) + ` AND ` + keysetCond(rank, "posts.id", cur, &args) + `
+		GROUP BY posts.id, threads.title, users.username
+		ORDER BY ` + keysetOrder(rank, "posts.id", cur) + `
 	`
 
-	err := s.Select(&pp, query)
+	var rows []struct {
+		goreddit.Post
+		Rank float64 `db:"rank"`
+	}
+	err = s.SelectContext(ctx, &rows, pageQuery(query, opts.PageSize()), args...)
 	if err != nil {
-		return []goreddit.Post{}, fmt.Errorf("error getting posts: %w", err)
+		return []goreddit.Post{}, goreddit.Page{}, fmt.Errorf("error getting posts: %w", translateError(err))
 	}
 
-	return pp, nil
+	pos := make([]goreddit.Cursor, len(rows))
+	for i, row := range rows {
+		pos[i] = goreddit.Cursor{Sort: cur.Sort, Rank: row.Rank, ID: row.ID}
+	}
+	lo, hi, page := trimPage(cur, opts.PageSize(), pos)
+
+	pp := make([]goreddit.Post, 0, hi-lo)
+	for _, row := range rows[lo:hi] {
+		pp = append(pp, row.Post)
+	}
+	return pp, page, nil
+}
+
+func (s *PostStore) PostsByThread(ctx context.Context, threadID uuid.UUID, opts goreddit.ListOptions) ([]goreddit.Post, goreddit.Page, error) {
+	cur, err := goreddit.DecodeCursor(opts.Cursor, opts.Sort)
+	if err != nil {
+		return []goreddit.Post{}, goreddit.Page{}, fmt.Errorf("error getting posts: %w", err)
+	}
+
+	args := []interface{}{threadID}
+	rank := rankExpr("posts", opts.Sort, cur.At)
+	var query string = `
+		SELECT
+			posts.*,
+			COUNT(comments.*) as comments_count,
+			COALESCE(users.username, '') as author_username,
+			` + rank + ` as rank
+		FROM posts
+		LEFT JOIN comments ON comments.post_id = posts.id
+		LEFT JOIN users ON users.id = posts.author_id
+		WHERE posts.thread_id = $1 AND ` + windowCond("posts", opts, cur.At) + ` AND ` + removedCond("posts", opts) + ` AND ` + keysetCond(rank, "posts.id", cur, &args) + `
+		GROUP BY posts.id, users.username
+		ORDER BY ` + keysetOrder(rank, "posts.id", cur) + `
+	`
+
+	var rows []struct {
+		goreddit.Post
+		Rank float64 `db:"rank"`
+	}
+	err = s.SelectContext(ctx, &rows, pageQuery(query, opts.PageSize()), args...)
+	if err != nil {
+		return []goreddit.Post{}, goreddit.Page{}, fmt.Errorf("error getting posts: %w", translateError(err))
+	}
+
+	pos := make([]goreddit.Cursor, len(rows))
+	for i, row := range rows {
+		pos[i] = goreddit.Cursor{Sort: cur.Sort, Rank: row.Rank, ID: row.ID}
+	}
+	lo, hi, page := trimPage(cur, opts.PageSize(), pos)
+
+	pp := make([]goreddit.Post, 0, hi-lo)
+	for _, row := range rows[lo:hi] {
+		pp = append(pp, row.Post)
+	}
+	return pp, page, nil
+}
+
+func (s *PostStore) PostsByThreads(ctx context.Context, threadIDs []uuid.UUID, opts goreddit.ListOptions) ([]goreddit.Post, goreddit.Page, error) {
+	cur, err := goreddit.DecodeCursor(opts.Cursor, opts.Sort)
+	if err != nil {
+		return []goreddit.Post{}, goreddit.Page{}, fmt.Errorf("error getting posts: %w", err)
+	}
+
+	ids := make([]string, len(threadIDs))
+	for i, id := range threadIDs {
+		ids[i] = id.String()
+	}
+	args := []interface{}{pq.Array(ids)}
+	rank := rankExpr("posts", opts.Sort, cur.At)
+	var query string = `
+		SELECT
+			posts.*,
+			threads.title as thread_title,
+			COUNT(comments.*) as comments_count,
+			COALESCE(users.username, '') as author_username,
+			` + rank + ` as rank
+		FROM posts
+		LEFT JOIN threads ON threads.id = posts.thread_id
+		LEFT JOIN comments ON comments.post_id = posts.id
+		LEFT JOIN users ON users.id = posts.author_id
+		WHERE posts.thread_id = ANY($1::uuid[]) AND ` + windowCond("posts", opts, cur.At) + ` AND ` + removedCond("posts", opts) + ` AND ` + keysetCond(rank, "posts.id", cur, &args) + `
+		GROUP BY posts.id, threads.title, users.username
+		ORDER BY ` + keysetOrder(rank, "posts.id", cur) + `
+	`
+
+	var rows []struct {
+		goreddit.Post
+		Rank float64 `db:"rank"`
+	}
+	err = s.SelectContext(ctx, &rows, pageQuery(query, opts.PageSize()), args...)
+	if err != nil {
+		return []goreddit.Post{}, goreddit.Page{}, fmt.Errorf("error getting posts: %w", translateError(err))
+	}
+
+	pos := make([]goreddit.Cursor, len(rows))
+	for i, row := range rows {
+		pos[i] = goreddit.Cursor{Sort: cur.Sort, Rank: row.Rank, ID: row.ID}
+	}
+	lo, hi, page := trimPage(cur, opts.PageSize(), pos)
+
+	pp := make([]goreddit.Post, 0, hi-lo)
+	for _, row := range rows[lo:hi] {
+		pp = append(pp, row.Post)
+	}
+	return pp, page, nil
 }
 
-func (s *PostStore) PostsByThread(threadID uuid.UUID) ([]goreddit.Post, error) {
+func (s *PostStore) PinnedPosts(ctx context.Context, threadID uuid.UUID) ([]goreddit.Post, error) {
 	var pp []goreddit.Post
+
 	var query string = `
 		SELECT
 			posts.*,
-			COUNT(comments.*) as comments_count
+			COUNT(comments.*) as comments_count,
+			COALESCE(users.username, '') as author_username
 		FROM posts
 		LEFT JOIN comments ON comments.post_id = posts.id
-		WHERE posts.thread_id = $1
-		GROUP BY posts.id
-		ORDER BY posts.votes DESC
+		LEFT JOIN users ON users.id = posts.author_id
+		WHERE posts.thread_id = $1 AND posts.pinned AND NOT posts.removed
+		GROUP BY posts.id, users.username
+		ORDER BY posts.created_at DESC, posts.id DESC
 	`
 
-	err := s.Select(&pp, query, threadID)
+	err := s.SelectContext(ctx, &pp, query, threadID)
 	if err != nil {
-		return []goreddit.Post{}, fmt.Errorf("error getting posts: %w", err)
+		return []goreddit.Post{}, fmt.Errorf("error getting pinned posts: %w", translateError(err))
 	}
 
 	return pp, nil
 }
 
-func (s *PostStore) CreatePost(p *goreddit.Post) error {
-	query := `INSERT INTO posts VALUES ($1, $2, $3, $4, $5) RETURNING *`
+func (s *PostStore) CreatePost(ctx context.Context, p *goreddit.Post) error {
+	query := `
+		INSERT INTO posts (id, thread_id, title, content, votes, author_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7, now()), COALESCE($7, now()))
+		RETURNING *
+	`
 
-	err := s.Get(p, query, p.ID, p.ThreadID, p.Title, p.Content, p.Votes)
+	err := s.GetContext(ctx, p, query, p.ID, p.ThreadID, p.Title, p.Content, p.Votes, p.AuthorID, nullTime(p.CreatedAt))
 	if err != nil {
-		return fmt.Errorf("error creating post: %w", err)
+		return fmt.Errorf("error creating post: %w", translateError(err))
 	}
 
 	return nil
 }
 
-func (s *PostStore) UpdatePost(p *goreddit.Post) error {
-	query := `UPDATE posts SET thread_id = $1, title = $2, content = $3, votes = $4 WHERE id = $5 RETURNING *`
+func (s *PostStore) UpdatePost(ctx context.Context, p *goreddit.Post) error {
+	query := `UPDATE posts SET thread_id = $1, title = $2, content = $3, updated_at = now() WHERE id = $4 RETURNING *`
 
-	err := s.Get(p, query, p.ThreadID, p.Title, p.Content, p.Votes, p.ID)
+	err := s.GetContext(ctx, p, query, p.ThreadID, p.Title, p.Content, p.ID)
 	if err != nil {
-		return fmt.Errorf("error updating post: %w", err)
+		return fmt.Errorf("error updating post: %w", translateError(err))
 	}
 
 	return nil
 }
 
-func (s *PostStore) DeletePost(id uuid.UUID) error {
-	_, err := s.Exec(`DELETE FROM posts WHERE id = $1`, id)
+func (s *PostStore) DeletePost(ctx context.Context, id uuid.UUID) error {
+	res, err := s.ExecContext(ctx, `DELETE FROM posts WHERE id = $1`, id)
 	if err != nil {
+		return fmt.Errorf("error deleting post: %w", translateError(err))
+	}
+	if err := requireRows(res); err != nil {
 		return fmt.Errorf("error deleting post: %w", err)
 	}
 	return nil
 }
+
+func (s *PostStore) SetPostRemoved(ctx context.Context, id uuid.UUID, removed bool) error {
+	return s.setFlag(ctx, "removed", id, removed)
+}
+
+func (s *PostStore) SetPostLocked(ctx context.Context, id uuid.UUID, locked bool) error {
+	return s.setFlag(ctx, "locked", id, locked)
+}
+
+func (s *PostStore) SetPostPinned(ctx context.Context, id uuid.UUID, pinned bool) error {
+	if !pinned {
+		return s.setFlag(ctx, "pinned", id, false)
+	}
+
+	tx, err := s.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error pinning post: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locking the thread makes concurrent pins in it take turns, so that
+	// each one counts the pins committed before it.
+	var threadID uuid.UUID
+	err = tx.GetContext(ctx, &threadID, `
+		SELECT threads.id FROM posts JOIN threads ON threads.id = posts.thread_id
+		WHERE posts.id = $1 FOR UPDATE OF threads
+	`, id)
+	if err != nil {
+		return fmt.Errorf("error pinning post: %w", translateError(err))
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE posts SET pinned = TRUE
+		WHERE id = $1 AND (pinned OR (SELECT COUNT(*) FROM posts pins WHERE pins.thread_id = $2 AND pins.pinned) < $3)
+	`, id, threadID, goreddit.MaxPinnedPosts)
+	if err != nil {
+		return fmt.Errorf("error pinning post: %w", translateError(err))
+	}
+	if err := requireRows(res); err != nil {
+		return fmt.Errorf("error pinning post: %w", goreddit.ErrPinLimit)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error pinning post: %w", err)
+	}
+	return nil
+}
+
+// setFlag sets one of the moderation flags of a post. Setting a flag does
+// not count as editing the post, so updated_at is left alone.
+func (s *PostStore) setFlag(ctx context.Context, column string, id uuid.UUID, value bool) error {
+	res, err := s.ExecContext(ctx, `UPDATE posts SET `+column+` = $1 WHERE id = $2`, value, id)
+	if err != nil {
+		return fmt.Errorf("error updating post: %w", translateError(err))
+	}
+	if err := requireRows(res); err != nil {
+		return fmt.Errorf("error updating post: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type ReportStore struct {
+	*sqlx.DB
+}
+
+func (s *ReportStore) Reports(ctx context.Context, threadID uuid.UUID) ([]goreddit.Report, error) {
+	var rr []goreddit.Report
+
+	var query string = `
+		SELECT
+			reports.*,
+			posts.thread_id,
+			posts.title as post_title,
+			COALESCE(comments.content, posts.content) as content,
+			COALESCE(comments.removed, posts.removed) as removed,
+			CASE WHEN reports.comment_id IS NULL THEN posts.author_id ELSE comments.author_id END as author_id,
+			COALESCE(authors.username, '') as author_username,
+			COALESCE(reporters.username, '') as reporter_username
+		FROM reports
+		JOIN posts ON posts.id = reports.post_id
+		LEFT JOIN comments ON comments.id = reports.comment_id
+		LEFT JOIN users authors ON authors.id =
+			CASE WHEN reports.comment_id IS NULL THEN posts.author_id ELSE comments.author_id END
+		LEFT JOIN users reporters ON reporters.id = reports.reporter_id
+		WHERE posts.thread_id = $1
+		ORDER BY reports.created_at, reports.id
+	`
+
+	err := s.SelectContext(ctx, &rr, query, threadID)
+	if err != nil {
+		return []goreddit.Report{}, fmt.Errorf("error getting reports: %w", translateError(err))
+	}
+
+	return rr, nil
+}
+
+func (s *ReportStore) ReportCounts(ctx context.Context, threadID uuid.UUID) (map[uuid.UUID]int, error) {
+	var rows []struct {
+		TargetID uuid.UUID `db:"target_id"`
+		Count    int       `db:"count"`
+	}
+
+	var query string = `
+		SELECT COALESCE(reports.comment_id, reports.post_id) as target_id, COUNT(*) as count
+		FROM reports
+		JOIN posts ON posts.id = reports.post_id
+		WHERE posts.thread_id = $1
+		GROUP BY target_id
+	`
+
+	err := s.SelectContext(ctx, &rows, query, threadID)
+	if err != nil {
+		return map[uuid.UUID]int{}, fmt.Errorf("error getting report counts: %w", translateError(err))
+	}
+
+	counts := make(map[uuid.UUID]int, len(rows))
+	for _, row := range rows {
+		counts[row.TargetID] = row.Count
+	}
+	return counts, nil
+}
+
+func (s *ReportStore) CreateReport(ctx context.Context, r *goreddit.Report) error {
+	query := `
+		INSERT INTO reports (id, post_id, comment_id, reporter_id, reason, details, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7, now()))
+		RETURNING *
+	`
+
+	err := s.GetContext(ctx, r, query, r.ID, r.PostID, r.CommentID, r.ReporterID, r.Reason, r.Details, nullTime(r.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("error creating report: %w", translateError(err))
+	}
+
+	return nil
+}
+
+func (s *ReportStore) DeletePostReports(ctx context.Context, postID uuid.UUID) error {
+	_, err := s.ExecContext(ctx, `DELETE FROM reports WHERE post_id = $1 AND comment_id IS NULL`, postID)
+	if err != nil {
+		return fmt.Errorf("error deleting reports: %w", translateError(err))
+	}
+
+	return nil
+}
+
+func (s *ReportStore) DeleteCommentReports(ctx context.Context, commentID uuid.UUID) error {
+	_, err := s.ExecContext(ctx, `DELETE FROM reports WHERE comment_id = $1`, commentID)
+	if err != nil {
+		return fmt.Errorf("error deleting reports: %w", translateError(err))
+	}
+
+	return nil
+}
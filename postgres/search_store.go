@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aleury/goreddit"
+	"github.com/jmoiron/sqlx"
+)
+
+// The documents searched in each table. They must match the expressions
+// of the GIN indexes created by the migrations.
+const (
+	threadDocument = `(setweight(to_tsvector('english', threads.title), 'A') ||
+		setweight(to_tsvector('english', threads.description), 'B'))`
+	postDocument = `(setweight(to_tsvector('english', posts.title), 'A') ||
+		setweight(to_tsvector('english', posts.content), 'B'))`
+	commentDocument = `(setweight(to_tsvector('english', comments.content), 'B'))`
+)
+
+// headlineOptions configures the snippets made by ts_headline.
+var headlineOptions = fmt.Sprintf(`StartSel="%s", StopSel="%s", MaxWords=30, MinWords=12, MaxFragments=2, FragmentDelimiter=" … "`,
+	goreddit.HighlightStart, goreddit.HighlightStop)
+
+type SearchStore struct {
+	*sqlx.DB
+}
+
+func (s *SearchStore) Search(ctx context.Context, opts goreddit.SearchOptions) ([]goreddit.SearchResult, goreddit.Page, error) {
+	cur, err := goreddit.DecodeCursor(opts.Cursor, goreddit.SortRelevance)
+	if err != nil {
+		return []goreddit.SearchResult{}, goreddit.Page{}, fmt.Errorf("error searching: %w", err)
+	}
+	if strings.TrimSpace(opts.Query) == "" {
+		return []goreddit.SearchResult{}, goreddit.Page{}, nil
+	}
+
+	// The filters are shared by every kind of result and ignore the
+	// arguments that are NULL or empty.
+	args := []interface{}{opts.Query, opts.ThreadID, opts.Author, nullTime(opts.After), nullTime(opts.Before)}
+	filters := func(table, threadID string) string {
+		return fmt.Sprintf(`($2::uuid IS NULL OR %[2]s = $2)
+			AND ($3 = '' OR users.username = $3)
+			AND ($4::timestamptz IS NULL OR %[1]s.created_at >= $4)
+			AND ($5::timestamptz IS NULL OR %[1]s.created_at < $5)`, table, threadID)
+	}
+
+	var branches []string
+	if opts.Includes(goreddit.SearchThreads) {
+		branches = append(branches, `
+			SELECT
+				'thread' as kind,
+				threads.id,
+				threads.id as thread_id,
+				threads.title as thread_title,
+				NULL::uuid as post_id,
+				'' as post_title,
+				threads.description as body,
+				ts_rank(`+threadDocument+`, query)::float8 as rank,
+				threads.author_id,
+				COALESCE(users.username, '') as author_username,
+				threads.created_at
+			FROM threads
+			CROSS JOIN websearch_to_tsquery('english', $1) query
+			LEFT JOIN users ON users.id = threads.author_id
+			WHERE `+threadDocument+` @@ query AND `+filters("threads", "threads.id"))
+	}
+	if opts.Includes(goreddit.SearchPosts) {
+		branches = append(branches, `
+			SELECT
+				'post' as kind,
+				posts.id,
+				posts.thread_id,
+				threads.title as thread_title,
+				posts.id as post_id,
+				posts.title as post_title,
+				posts.content as body,
+				ts_rank(`+postDocument+`, query)::float8 as rank,
+				posts.author_id,
+				COALESCE(users.username, '') as author_username,
+				posts.created_at
+			FROM posts
+			CROSS JOIN websearch_to_tsquery('english', $1) query
+			JOIN threads ON threads.id = posts.thread_id
+			LEFT JOIN users ON users.id = posts.author_id
+			WHERE `+postDocument+` @@ query AND NOT posts.removed AND `+filters("posts", "posts.thread_id"))
+	}
+	if opts.Includes(goreddit.SearchComments) {
+		branches = append(branches, `
+			SELECT
+				'comment' as kind,
+				comments.id,
+				posts.thread_id,
+				threads.title as thread_title,
+				posts.id as post_id,
+				posts.title as post_title,
+				comments.content as body,
+				ts_rank(`+commentDocument+`, query)::float8 as rank,
+				comments.author_id,
+				COALESCE(users.username, '') as author_username,
+				comments.created_at
+			FROM comments
+			CROSS JOIN websearch_to_tsquery('english', $1) query
+			JOIN posts ON posts.id = comments.post_id
+			JOIN threads ON threads.id = posts.thread_id
+			LEFT JOIN users ON users.id = comments.author_id
+			WHERE `+commentDocument+` @@ query AND NOT comments.removed AND NOT posts.removed AND `+filters("comments", "posts.thread_id"))
+	}
+	if len(branches) == 0 {
+		return []goreddit.SearchResult{}, goreddit.Page{}, nil
+	}
+
+	var query string = `
+		SELECT * FROM (` + strings.Join(branches, " UNION ALL ") + `) matches
+		WHERE ` + keysetCond("matches.rank", "matches.id", cur, &args) + `
+		ORDER BY ` + keysetOrder("matches.rank", "matches.id", cur) + `
+	`
+
+	// Snippets are only made for the results on the page.
+	args = append(args, headlineOptions)
+	query = fmt.Sprintf(`
+		SELECT
+			kind, id, thread_id, thread_title, post_id, post_title, rank,
+			author_id, author_username, created_at,
+			ts_headline('english', body, websearch_to_tsquery('english', $1), $%d) as snippet
+		FROM (%s) results
+		ORDER BY rank DESC, id DESC
+	`, len(args), pageQuery(query, opts.PageSize()))
+
+	var rr []goreddit.SearchResult
+	err = s.SelectContext(ctx, &rr, query, args...)
+	if err != nil {
+		return []goreddit.SearchResult{}, goreddit.Page{}, fmt.Errorf("error searching: %w", translateError(err))
+	}
+
+	pos := make([]goreddit.Cursor, len(rr))
+	for i, r := range rr {
+		pos[i] = goreddit.Cursor{Sort: cur.Sort, Rank: r.Rank, ID: r.ID}
+	}
+	lo, hi, page := trimPage(cur, opts.PageSize(), pos)
+
+	return append([]goreddit.SearchResult{}, rr[lo:hi]...), page, nil
+}
@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aleury/goreddit"
+	"github.com/jmoiron/sqlx"
+)
+
+type StatsStore struct {
+	*sqlx.DB
+}
+
+func (s *StatsStore) Stats(ctx context.Context) (goreddit.Stats, error) {
+	var st goreddit.Stats
+
+	var query string = `
+		SELECT
+			(SELECT COUNT(*) FROM users) as users,
+			(SELECT COUNT(*) FROM threads) as threads,
+			(SELECT COUNT(*) FROM posts) as posts,
+			(SELECT COUNT(*) FROM posts WHERE removed) as removed_posts,
+			(SELECT COUNT(*) FROM comments) as comments,
+			(SELECT COUNT(*) FROM comments WHERE removed) as removed_comments,
+			(SELECT COUNT(*) FROM reports) as reports,
+			(SELECT COUNT(*) FROM bans WHERE ` + banActive + `) as bans
+	`
+
+	err := s.GetContext(ctx, &st, query)
+	if err != nil {
+		return goreddit.Stats{}, fmt.Errorf("error getting stats: %w", translateError(err))
+	}
+
+	return st, nil
+}
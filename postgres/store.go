@@ -1,7 +1,9 @@
 package postgres
 
 import (
+	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
@@ -12,6 +14,17 @@ type Store struct {
 	*PostStore
 	*CommentStore
 	*UserStore
+	*VoteStore
+	*TokenStore
+	*SearchStore
+	*SubscriptionStore
+	*ModeratorStore
+	*ReportStore
+	*ModLogStore
+	*BanStore
+	*StatsStore
+
+	db *sqlx.DB
 }
 
 func NewStore(dataSourceName string) (*Store, error) {
@@ -20,15 +33,38 @@ func NewStore(dataSourceName string) (*Store, error) {
 		return nil, fmt.Errorf("error opening database: %w", err)
 	}
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("error connecting to database: %w", err)
 	}
 
 	store := Store{
-		ThreadStore:  &ThreadStore{DB: db},
-		PostStore:    &PostStore{DB: db},
-		CommentStore: &CommentStore{DB: db},
-		UserStore:    &UserStore{DB: db},
+		ThreadStore:       &ThreadStore{DB: db},
+		PostStore:         &PostStore{DB: db},
+		CommentStore:      &CommentStore{DB: db},
+		UserStore:         &UserStore{DB: db},
+		VoteStore:         &VoteStore{DB: db},
+		TokenStore:        &TokenStore{DB: db},
+		SearchStore:       &SearchStore{DB: db},
+		SubscriptionStore: &SubscriptionStore{DB: db},
+		ModeratorStore:    &ModeratorStore{DB: db},
+		ReportStore:       &ReportStore{DB: db},
+		ModLogStore:       &ModLogStore{DB: db},
+		BanStore:          &BanStore{DB: db},
+		StatsStore:        &StatsStore{DB: db},
+		db:                db,
 	}
 
 	return &store, nil
 }
+
+// Close closes the connections to the database once the queries running on
+// them have finished.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// nullTime converts the zero time to NULL, so that inserts can fall back to
+// the current time unless the caller set a timestamp.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
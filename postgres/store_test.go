@@ -0,0 +1,40 @@
+package postgres_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/aleury/goreddit"
+	"github.com/aleury/goreddit/postgres"
+	"github.com/aleury/goreddit/storetest"
+)
+
+// TestStore runs the conformance suite against a migrated database named by
+// TEST_DATA_SOURCE_NAME. All data in that database is deleted, including the
+// append-only mod log, so the tests must connect as the owner of its table.
+func TestStore(t *testing.T) {
+	dsn := os.Getenv("TEST_DATA_SOURCE_NAME")
+	if dsn == "" {
+		t.Skip("TEST_DATA_SOURCE_NAME not set")
+	}
+
+	storetest.Run(t, func(t *testing.T) goreddit.Store {
+		store, err := postgres.NewStore(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { store.ThreadStore.Close() })
+
+		// The statements run in one transaction, so the mod log is only
+		// open to truncation while the test empties it.
+		if _, err := store.ThreadStore.Exec(`
+			ALTER TABLE mod_log DISABLE TRIGGER mod_log_no_truncate;
+			TRUNCATE mod_log, threads, users CASCADE;
+			ALTER TABLE mod_log ENABLE TRIGGER mod_log_no_truncate;
+		`); err != nil {
+			t.Fatal(err)
+		}
+
+		return store
+	})
+}
@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type SubscriptionStore struct {
+	*sqlx.DB
+}
+
+func (s *SubscriptionStore) Subscribed(ctx context.Context, userID, threadID uuid.UUID) (bool, error) {
+	var subscribed bool
+
+	query := `SELECT EXISTS (SELECT 1 FROM subscriptions WHERE user_id = $1 AND thread_id = $2)`
+
+	err := s.GetContext(ctx, &subscribed, query, userID, threadID)
+	if err != nil {
+		return false, fmt.Errorf("error getting subscription: %w", translateError(err))
+	}
+
+	return subscribed, nil
+}
+
+func (s *SubscriptionStore) Subscriptions(ctx context.Context, userID uuid.UUID) ([]goreddit.Thread, error) {
+	var tt []goreddit.Thread
+
+	var query string = `
+		SELECT
+			threads.*,
+			(SELECT COUNT(*) FROM subscriptions counted WHERE counted.thread_id = threads.id) as subscribers_count,
+			COALESCE(users.username, '') as author_username
+		FROM subscriptions
+		JOIN threads ON threads.id = subscriptions.thread_id
+		LEFT JOIN users ON users.id = threads.author_id
+		WHERE subscriptions.user_id = $1
+		ORDER BY threads.title, threads.id
+	`
+
+	err := s.SelectContext(ctx, &tt, query, userID)
+	if err != nil {
+		return []goreddit.Thread{}, fmt.Errorf("error getting subscriptions: %w", translateError(err))
+	}
+
+	return tt, nil
+}
+
+func (s *SubscriptionStore) PopularThreads(ctx context.Context, limit int) ([]goreddit.Thread, error) {
+	var tt []goreddit.Thread
+
+	var query string = `
+		SELECT * FROM (
+			SELECT
+				threads.*,
+				(SELECT COUNT(*) FROM subscriptions WHERE subscriptions.thread_id = threads.id) as subscribers_count,
+				COALESCE(users.username, '') as author_username
+			FROM threads
+			LEFT JOIN users ON users.id = threads.author_id
+		) popular
+		ORDER BY popular.subscribers_count DESC, popular.created_at, popular.id
+		LIMIT $1
+	`
+
+	err := s.SelectContext(ctx, &tt, query, limit)
+	if err != nil {
+		return []goreddit.Thread{}, fmt.Errorf("error getting popular threads: %w", translateError(err))
+	}
+
+	return tt, nil
+}
+
+func (s *SubscriptionStore) Subscribe(ctx context.Context, userID, threadID uuid.UUID) error {
+	query := `
+		INSERT INTO subscriptions (user_id, thread_id) VALUES ($1, $2)
+		ON CONFLICT (user_id, thread_id) DO NOTHING
+	`
+
+	_, err := s.ExecContext(ctx, query, userID, threadID)
+	if err != nil {
+		return fmt.Errorf("error subscribing: %w", translateError(err))
+	}
+
+	return nil
+}
+
+func (s *SubscriptionStore) Unsubscribe(ctx context.Context, userID, threadID uuid.UUID) error {
+	_, err := s.ExecContext(ctx, `DELETE FROM subscriptions WHERE user_id = $1 AND thread_id = $2`, userID, threadID)
+	if err != nil {
+		return fmt.Errorf("error unsubscribing: %w", translateError(err))
+	}
+
+	return nil
+}
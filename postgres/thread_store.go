@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/aleury/goreddit"
@@ -12,53 +13,108 @@ type ThreadStore struct {
 	*sqlx.DB
 }
 
-func (s *ThreadStore) Thread(id uuid.UUID) (goreddit.Thread, error) {
+func (s *ThreadStore) Thread(ctx context.Context, id uuid.UUID) (goreddit.Thread, error) {
 	var t goreddit.Thread
 
-	err := s.Get(&t, `SELECT * FROM threads WHERE id = $1`, id)
+	var query string = `
+		SELECT
+			threads.*,
+			(SELECT COUNT(*) FROM subscriptions WHERE subscriptions.thread_id = threads.id) as subscribers_count,
+			COALESCE(users.username, '') as author_username
+		FROM threads
+		LEFT JOIN users ON users.id = threads.author_id
+		WHERE threads.id = $1
+	`
+
+	err := s.GetContext(ctx, &t, query, id)
 	if err != nil {
-		return goreddit.Thread{}, fmt.Errorf("error getting thread: %w", err)
+		return goreddit.Thread{}, fmt.Errorf("error getting thread: %w", translateError(err))
 	}
 
 	return t, nil
 }
 
-func (s *ThreadStore) Threads() ([]goreddit.Thread, error) {
-	var tt []goreddit.Thread
+func (s *ThreadStore) Threads(ctx context.Context, opts goreddit.PageOptions) ([]goreddit.Thread, goreddit.Page, error) {
+	cur, err := goreddit.DecodeCursor(opts.Cursor, goreddit.SortNew)
+	if err != nil {
+		return []goreddit.Thread{}, goreddit.Page{}, fmt.Errorf("error getting threads: %w", err)
+	}
 
-	err := s.Select(&tt, `SELECT * FROM threads`)
+	var args []interface{}
+	rank := rankExpr("threads", goreddit.SortNew, cur.At)
+	var query string = `
+		SELECT
+			threads.*,
+			(SELECT COUNT(*) FROM subscriptions WHERE subscriptions.thread_id = threads.id) as subscribers_count,
+			COALESCE(users.username, '') as author_username,
+			` + rank + ` as rank
+		FROM threads
+		LEFT JOIN users ON users.id = threads.author_id
+		WHERE ` + keysetCond(rank, "threads.id", cur, &args) + `
+		ORDER BY ` + keysetOrder(rank, "threads.id", cur) + `
+	`
+
+	var rows []struct {
+		goreddit.Thread
+		Rank float64 `db:"rank"`
+	}
+	err = s.SelectContext(ctx, &rows, pageQuery(query, opts.PageSize()), args...)
 	if err != nil {
-		return []goreddit.Thread{}, fmt.Errorf("error getting threads: %w", err)
+		return []goreddit.Thread{}, goreddit.Page{}, fmt.Errorf("error getting threads: %w", translateError(err))
 	}
 
-	return tt, nil
-}
+	pos := make([]goreddit.Cursor, len(rows))
+	for i, row := range rows {
+		pos[i] = goreddit.Cursor{Sort: cur.Sort, Rank: row.Rank, ID: row.ID}
+	}
+	lo, hi, page := trimPage(cur, opts.PageSize(), pos)
 
-func (s *ThreadStore) CreateThread(t *goreddit.Thread) error {
-	query := `INSERT INTO threads VALUES ($1, $2, $3) RETURNING *`
+	tt := make([]goreddit.Thread, 0, hi-lo)
+	for _, row := range rows[lo:hi] {
+		tt = append(tt, row.Thread)
+	}
+	return tt, page, nil
+}
 
-	err := s.Get(t, query, t.ID, t.Title, t.Description)
+func (s *ThreadStore) CreateThread(ctx context.Context, t *goreddit.Thread) error {
+	// The author becomes the thread's first moderator.
+	query := `
+		WITH thread AS (
+			INSERT INTO threads (id, title, description, author_id, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, COALESCE($5, now()), COALESCE($5, now()))
+			RETURNING *
+		), moderator AS (
+			INSERT INTO moderators (thread_id, user_id, created_at)
+			SELECT id, author_id, created_at FROM thread WHERE author_id IS NOT NULL
+		)
+		SELECT * FROM thread
+	`
+
+	err := s.GetContext(ctx, t, query, t.ID, t.Title, t.Description, t.AuthorID, nullTime(t.CreatedAt))
 	if err != nil {
-		return fmt.Errorf("error creating thread: %w", err)
+		return fmt.Errorf("error creating thread: %w", translateError(err))
 	}
 
 	return nil
 }
 
-func (s *ThreadStore) UpdateThread(t *goreddit.Thread) error {
-	query := `UPDATE threads SET title = $1, description = $2 WHERE id = $3 RETURNING *`
+func (s *ThreadStore) UpdateThread(ctx context.Context, t *goreddit.Thread) error {
+	query := `UPDATE threads SET title = $1, description = $2, updated_at = now() WHERE id = $3 RETURNING *`
 
-	err := s.Get(t, query, t.Title, t.Description, t.ID)
+	err := s.GetContext(ctx, t, query, t.Title, t.Description, t.ID)
 	if err != nil {
-		return fmt.Errorf("error updating thread: %w", err)
+		return fmt.Errorf("error updating thread: %w", translateError(err))
 	}
 
 	return nil
 }
 
-func (s *ThreadStore) DeleteThread(id uuid.UUID) error {
-	_, err := s.Exec(`DELETE FROM threads WHERE id = $1`, id)
+func (s *ThreadStore) DeleteThread(ctx context.Context, id uuid.UUID) error {
+	res, err := s.ExecContext(ctx, `DELETE FROM threads WHERE id = $1`, id)
 	if err != nil {
+		return fmt.Errorf("error deleting thread: %w", translateError(err))
+	}
+	if err := requireRows(res); err != nil {
 		return fmt.Errorf("error deleting thread: %w", err)
 	}
 	return nil
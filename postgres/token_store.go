@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type TokenStore struct {
+	*sqlx.DB
+}
+
+func (s *TokenStore) Token(ctx context.Context, id uuid.UUID) (goreddit.Token, error) {
+	var t goreddit.Token
+
+	err := s.GetContext(ctx, &t, `SELECT * FROM api_tokens WHERE id = $1`, id)
+	if err != nil {
+		return goreddit.Token{}, fmt.Errorf("error getting token: %w", translateError(err))
+	}
+
+	return t, nil
+}
+
+func (s *TokenStore) TokenByHash(ctx context.Context, hash []byte) (goreddit.Token, error) {
+	var t goreddit.Token
+
+	err := s.GetContext(ctx, &t, `SELECT * FROM api_tokens WHERE hash = $1`, hash)
+	if err != nil {
+		return goreddit.Token{}, fmt.Errorf("error getting token: %w", translateError(err))
+	}
+
+	return t, nil
+}
+
+func (s *TokenStore) TokensByUser(ctx context.Context, userID uuid.UUID) ([]goreddit.Token, error) {
+	var tt []goreddit.Token
+
+	err := s.SelectContext(ctx, &tt, `SELECT * FROM api_tokens WHERE user_id = $1 ORDER BY created_at DESC, id DESC`, userID)
+	if err != nil {
+		return []goreddit.Token{}, fmt.Errorf("error getting tokens: %w", translateError(err))
+	}
+
+	return tt, nil
+}
+
+func (s *TokenStore) CreateToken(ctx context.Context, t *goreddit.Token) error {
+	query := `
+		INSERT INTO api_tokens (id, user_id, name, hash, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5, COALESCE($6, now()))
+		RETURNING *
+	`
+
+	err := s.GetContext(ctx, t, query, t.ID, t.UserID, t.Name, t.Hash, t.Scopes, nullTime(t.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("error creating token: %w", translateError(err))
+	}
+
+	return nil
+}
+
+func (s *TokenStore) DeleteToken(ctx context.Context, id uuid.UUID) error {
+	res, err := s.ExecContext(ctx, `DELETE FROM api_tokens WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error deleting token: %w", translateError(err))
+	}
+	if err := requireRows(res); err != nil {
+		return fmt.Errorf("error deleting token: %w", err)
+	}
+	return nil
+}
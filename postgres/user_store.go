@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/aleury/goreddit"
@@ -12,53 +13,108 @@ type UserStore struct {
 	*sqlx.DB
 }
 
-func (s *UserStore) User(id uuid.UUID) (goreddit.User, error) {
+func (s *UserStore) User(ctx context.Context, id uuid.UUID) (goreddit.User, error) {
 	var t goreddit.User
 
-	err := s.Get(&t, `SELECT * FROM users WHERE id = $1`, id)
+	err := s.GetContext(ctx, &t, `SELECT * FROM users WHERE id = $1`, id)
 	if err != nil {
-		return goreddit.User{}, fmt.Errorf("error getting user: %w", err)
+		return goreddit.User{}, fmt.Errorf("error getting user: %w", translateError(err))
 	}
 
 	return t, nil
 }
 
-func (s *UserStore) UserByUsername(username string) (goreddit.User, error) {
+func (s *UserStore) UserByUsername(ctx context.Context, username string) (goreddit.User, error) {
 	var u goreddit.User
 
-	err := s.Get(&u, `SELECT * FROM users WHERE username = $1`, username)
+	err := s.GetContext(ctx, &u, `SELECT * FROM users WHERE username = $1`, username)
 	if err != nil {
-		return goreddit.User{}, fmt.Errorf("error getting user: %w", err)
+		return goreddit.User{}, fmt.Errorf("error getting user: %w", translateError(err))
 	}
 
 	return u, nil
 }
 
-func (s *UserStore) CreateUser(u *goreddit.User) error {
-	query := `INSERT INTO users VALUES ($1, $2, $3) RETURNING *`
+func (s *UserStore) Users(ctx context.Context, opts goreddit.PageOptions) ([]goreddit.User, goreddit.Page, error) {
+	cur, err := goreddit.DecodeCursor(opts.Cursor, goreddit.SortNew)
+	if err != nil {
+		return []goreddit.User{}, goreddit.Page{}, fmt.Errorf("error getting users: %w", err)
+	}
 
-	err := s.Get(u, query, u.ID, u.Username, u.Password)
+	var args []interface{}
+	rank := rankExpr("users", goreddit.SortNew, cur.At)
+	var query string = `
+		SELECT users.*, ` + rank + ` as rank
+		FROM users
+		WHERE ` + keysetCond(rank, "users.id", cur, &args) + `
+		ORDER BY ` + keysetOrder(rank, "users.id", cur) + `
+	`
+
+	var rows []struct {
+		goreddit.User
+		Rank float64 `db:"rank"`
+	}
+	err = s.SelectContext(ctx, &rows, pageQuery(query, opts.PageSize()), args...)
 	if err != nil {
-		return fmt.Errorf("error creating user: %w", err)
+		return []goreddit.User{}, goreddit.Page{}, fmt.Errorf("error getting users: %w", translateError(err))
+	}
+
+	pos := make([]goreddit.Cursor, len(rows))
+	for i, row := range rows {
+		pos[i] = goreddit.Cursor{Sort: cur.Sort, Rank: row.Rank, ID: row.ID}
+	}
+	lo, hi, page := trimPage(cur, opts.PageSize(), pos)
+
+	uu := make([]goreddit.User, 0, hi-lo)
+	for _, row := range rows[lo:hi] {
+		uu = append(uu, row.User)
+	}
+	return uu, page, nil
+}
+
+func (s *UserStore) CreateUser(ctx context.Context, u *goreddit.User) error {
+	query := `
+		INSERT INTO users (id, username, password, role, created_at, updated_at)
+		VALUES ($1, $2, $3, COALESCE(NULLIF($4, ''), 'user'), COALESCE($5, now()), COALESCE($5, now()))
+		RETURNING *
+	`
+
+	err := s.GetContext(ctx, u, query, u.ID, u.Username, u.Password, u.Role, nullTime(u.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("error creating user: %w", translateError(err))
 	}
 
 	return nil
 }
 
-func (s *UserStore) UpdateUser(u *goreddit.User) error {
-	query := `UPDATE users SET username = $1, password = $2 WHERE id = $3 RETURNING *`
+func (s *UserStore) UpdateUser(ctx context.Context, u *goreddit.User) error {
+	query := `UPDATE users SET username = $1, password = $2, updated_at = now() WHERE id = $3 RETURNING *`
 
-	err := s.Get(u, query, u.Username, u.Password, u.ID)
+	err := s.GetContext(ctx, u, query, u.Username, u.Password, u.ID)
 	if err != nil {
-		return fmt.Errorf("error updating user: %w", err)
+		return fmt.Errorf("error updating user: %w", translateError(err))
 	}
 
 	return nil
 }
 
-func (s *UserStore) DeleteUser(id uuid.UUID) error {
-	_, err := s.Exec(`DELETE FROM users WHERE id = $1`, id)
+func (s *UserStore) SetUserRole(ctx context.Context, id uuid.UUID, role goreddit.Role) error {
+	res, err := s.ExecContext(ctx, `UPDATE users SET role = $1, updated_at = now() WHERE id = $2`, role, id)
 	if err != nil {
+		return fmt.Errorf("error setting user role: %w", translateError(err))
+	}
+	if err := requireRows(res); err != nil {
+		return fmt.Errorf("error setting user role: %w", err)
+	}
+	return nil
+}
+
+func (s *UserStore) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	res, err := s.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error deleting user: %w", translateError(err))
+	}
+	if err := requireRows(res); err != nil {
 		return fmt.Errorf("error deleting user: %w", err)
 	}
 	return nil
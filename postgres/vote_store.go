@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type VoteStore struct {
+	*sqlx.DB
+}
+
+func (s *VoteStore) UserPostVote(ctx context.Context, userID, postID uuid.UUID) (int, error) {
+	var v int
+
+	err := s.GetContext(ctx, &v, `SELECT value FROM post_votes WHERE user_id = $1 AND post_id = $2`, userID, postID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error getting post vote: %w", translateError(err))
+	}
+
+	return v, nil
+}
+
+func (s *VoteStore) UserCommentVote(ctx context.Context, userID, commentID uuid.UUID) (int, error) {
+	var v int
+
+	err := s.GetContext(ctx, &v, `SELECT value FROM comment_votes WHERE user_id = $1 AND comment_id = $2`, userID, commentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error getting comment vote: %w", translateError(err))
+	}
+
+	return v, nil
+}
+
+func (s *VoteStore) CastPostVote(ctx context.Context, userID, postID uuid.UUID, value int) error {
+	if value < -1 || value > 1 {
+		return fmt.Errorf("error casting post vote: invalid value %d", value)
+	}
+
+	query := `
+		INSERT INTO post_votes (user_id, post_id, value) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, post_id) DO UPDATE SET value = EXCLUDED.value
+	`
+
+	_, err := s.ExecContext(ctx, query, userID, postID, value)
+	if err != nil {
+		return fmt.Errorf("error casting post vote: %w", translateError(err))
+	}
+
+	return nil
+}
+
+func (s *VoteStore) CastCommentVote(ctx context.Context, userID, commentID uuid.UUID, value int) error {
+	if value < -1 || value > 1 {
+		return fmt.Errorf("error casting comment vote: invalid value %d", value)
+	}
+
+	query := `
+		INSERT INTO comment_votes (user_id, comment_id, value) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, comment_id) DO UPDATE SET value = EXCLUDED.value
+	`
+
+	_, err := s.ExecContext(ctx, query, userID, commentID, value)
+	if err != nil {
+		return fmt.Errorf("error casting comment vote: %w", translateError(err))
+	}
+
+	return nil
+}
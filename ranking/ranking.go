@@ -0,0 +1,91 @@
+// Package ranking implements the scoring functions behind the goreddit sort
+// orders. Stores that cannot compute rankings in their query language use
+// these directly; the postgres package implements the same formulas in SQL.
+package ranking
+
+import (
+	"math"
+	"time"
+
+	"github.com/aleury/goreddit"
+)
+
+// epoch is the reference point for hot rankings. Any fixed time works; it
+// only keeps the numbers small.
+var epoch = time.Date(2005, 12, 8, 7, 46, 43, 0, time.UTC)
+
+// hotDecay is how many seconds newer content must be to rank as high as content
+// with ten times its score.
+const hotDecay = 12.5 * 60 * 60
+
+// Hot ranks content by the order of magnitude of its score plus its age, so
+// that every 12.5 hours of age is worth a tenfold difference in score.
+func Hot(score int, createdAt time.Time) float64 {
+	order := math.Log10(math.Max(math.Abs(float64(score)), 1))
+
+	sign := 0.0
+	if score > 0 {
+		sign = 1
+	} else if score < 0 {
+		sign = -1
+	}
+
+	seconds := float64(createdAt.Sub(epoch)) / float64(time.Second)
+	return sign*order + seconds/hotDecay
+}
+
+// Controversial ranks content by its total number of votes, weighted by how
+// evenly they are split between up and down. Content with only up or only
+// down votes is not controversial at all.
+func Controversial(upvotes, downvotes int) float64 {
+	if upvotes <= 0 || downvotes <= 0 {
+		return 0
+	}
+
+	magnitude := float64(upvotes + downvotes)
+	balance := float64(upvotes) / float64(downvotes)
+	if upvotes > downvotes {
+		balance = float64(downvotes) / float64(upvotes)
+	}
+	return math.Pow(magnitude, balance)
+}
+
+// Rising ranks content by its score per hour of age, with a little extra age
+// so that brand new content does not dominate.
+func Rising(score int, createdAt, now time.Time) float64 {
+	hours := now.Sub(createdAt).Hours()
+	return float64(score) / math.Pow(hours+2, 1.5)
+}
+
+// New ranks content by its creation time.
+func New(createdAt time.Time) float64 {
+	return float64(createdAt.UnixNano()) / float64(time.Second)
+}
+
+// Rank returns the value to sort by, in descending order, for content with
+// the given votes and age.
+func Rank(sort goreddit.Sort, score, upvotes, downvotes int, createdAt, now time.Time) float64 {
+	switch sort {
+	case goreddit.SortNew:
+		return New(createdAt)
+	case goreddit.SortTop:
+		return float64(score)
+	case goreddit.SortControversial:
+		return Controversial(upvotes, downvotes)
+	case goreddit.SortRising:
+		return Rising(score, createdAt, now)
+	}
+	return Hot(score, createdAt)
+}
+
+// Includes reports whether content created at createdAt belongs in a listing
+// with opts at time now.
+func Includes(opts goreddit.ListOptions, createdAt, now time.Time) bool {
+	if opts.Sort == goreddit.SortRising {
+		return now.Sub(createdAt) < goreddit.RisingWindow
+	}
+	if d := opts.Window.Duration(); opts.Sort.Windowed() && d > 0 {
+		return now.Sub(createdAt) < d
+	}
+	return true
+}
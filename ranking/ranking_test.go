@@ -0,0 +1,84 @@
+package ranking
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/aleury/goreddit"
+)
+
+func TestHot(t *testing.T) {
+	created := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if Hot(10, created) <= Hot(1, created) {
+		t.Error("Hot(10) <= Hot(1) at the same age, want higher scores to rank higher")
+	}
+	if Hot(-10, created) >= Hot(0, created) {
+		t.Error("Hot(-10) >= Hot(0) at the same age, want negative scores to rank lower")
+	}
+
+	// Every 12.5 hours of age is worth a tenfold difference in score.
+	older, newer := Hot(100, created), Hot(10, created.Add(12*time.Hour+30*time.Minute))
+	if math.Abs(older-newer) > 1e-9 {
+		t.Errorf("Hot(100, t) = %v, Hot(10, t+12.5h) = %v; want them equal", older, newer)
+	}
+}
+
+func TestControversial(t *testing.T) {
+	tests := []struct {
+		up, down int
+		want     float64
+	}{
+		{0, 0, 0},
+		{10, 0, 0},
+		{0, 10, 0},
+		{5, 5, 10},
+		{2, 8, math.Pow(10, 0.25)},
+		{8, 2, math.Pow(10, 0.25)},
+	}
+	for _, tt := range tests {
+		if got := Controversial(tt.up, tt.down); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Controversial(%d, %d) = %v, want %v", tt.up, tt.down, got, tt.want)
+		}
+	}
+
+	if Controversial(50, 50) <= Controversial(5, 5) {
+		t.Error("Controversial(50, 50) <= Controversial(5, 5), want more votes to rank higher")
+	}
+}
+
+func TestRising(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if Rising(10, now.Add(-time.Hour), now) <= Rising(10, now.Add(-10*time.Hour), now) {
+		t.Error("Rising ranks older content as high as newer content with the same score")
+	}
+	if got := Rising(0, now, now); got != 0 {
+		t.Errorf("Rising(0) = %v, want 0", got)
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	twoDays := now.Add(-48 * time.Hour)
+
+	tests := []struct {
+		opts goreddit.ListOptions
+		want bool
+	}{
+		{goreddit.ListOptions{}, true},
+		{goreddit.ListOptions{Sort: goreddit.SortTop}, true},
+		{goreddit.ListOptions{Sort: goreddit.SortTop, Window: goreddit.WindowAll}, true},
+		{goreddit.ListOptions{Sort: goreddit.SortTop, Window: goreddit.WindowWeek}, true},
+		{goreddit.ListOptions{Sort: goreddit.SortTop, Window: goreddit.WindowDay}, false},
+		{goreddit.ListOptions{Sort: goreddit.SortControversial, Window: goreddit.WindowDay}, false},
+		{goreddit.ListOptions{Sort: goreddit.SortHot, Window: goreddit.WindowDay}, true},
+		{goreddit.ListOptions{Sort: goreddit.SortRising}, false},
+	}
+	for _, tt := range tests {
+		if got := Includes(tt.opts, twoDays, now); got != tt.want {
+			t.Errorf("Includes(%+v, 2 days ago) = %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+}
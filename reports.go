@@ -0,0 +1,42 @@
+package goreddit
+
+// ReportReason is why a user reported a post or comment.
+type ReportReason string
+
+const (
+	ReportSpam       ReportReason = "spam"
+	ReportHarassment ReportReason = "harassment"
+	ReportOffTopic   ReportReason = "off-topic"
+	// ReportOther reports content for a reason the reporter describes in
+	// the report's details.
+	ReportOther ReportReason = "other"
+)
+
+// ReportReasons lists every valid ReportReason.
+var ReportReasons = []ReportReason{ReportSpam, ReportHarassment, ReportOffTopic, ReportOther}
+
+func (r ReportReason) Valid() bool {
+	for _, v := range ReportReasons {
+		if r == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Label returns the reason as shown to users.
+func (r ReportReason) Label() string {
+	switch r {
+	case ReportSpam:
+		return "Spam"
+	case ReportHarassment:
+		return "Harassment"
+	case ReportOffTopic:
+		return "Off-topic"
+	}
+	return "Other"
+}
+
+// ReportFilterThreshold is how many reports remove a post or comment
+// automatically until a moderator reviews it.
+const ReportFilterThreshold = 3
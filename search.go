@@ -0,0 +1,77 @@
+package goreddit
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SearchKind is the kind of content a search result is.
+type SearchKind string
+
+const (
+	SearchThreads  SearchKind = "thread"
+	SearchPosts    SearchKind = "post"
+	SearchComments SearchKind = "comment"
+)
+
+// SearchKinds lists every valid SearchKind.
+var SearchKinds = []SearchKind{SearchThreads, SearchPosts, SearchComments}
+
+func (k SearchKind) Valid() bool {
+	for _, v := range SearchKinds {
+		if k == v {
+			return true
+		}
+	}
+	return false
+}
+
+// SortRelevance lists search results by how well they match the query. It
+// only applies to searches and is not one of Sorts.
+const SortRelevance Sort = "relevance"
+
+// Snippets mark the words that matched a search by enclosing them in
+// HighlightStart and HighlightStop. The markers are private use characters,
+// so they cannot be confused with anything users write.
+const (
+	HighlightStart = "\uE000"
+	HighlightStop  = "\uE001"
+)
+
+// SearchOptions selects the results of a search.
+type SearchOptions struct {
+	// Query holds the words to search for. Results contain every word,
+	// except words prefixed with a minus, which they must not contain.
+	// Words in double quotes must appear as a phrase.
+	Query string
+
+	// Kinds restricts the results to some kinds of content. Empty means
+	// all kinds.
+	Kinds []SearchKind
+	// ThreadID restricts the results to a thread and its posts and
+	// comments.
+	ThreadID uuid.NullUUID
+	// Author restricts the results to content by the user with this
+	// username.
+	Author string
+	// After and Before restrict the results to content created at or after
+	// After and before Before. Zero times are ignored.
+	After  time.Time
+	Before time.Time
+
+	PageOptions
+}
+
+// Includes reports whether opts searches content of kind.
+func (o SearchOptions) Includes(kind SearchKind) bool {
+	if len(o.Kinds) == 0 {
+		return true
+	}
+	for _, k := range o.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
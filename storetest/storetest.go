@@ -0,0 +1,1949 @@
+// Package storetest provides a conformance test suite that every
+// implementation of goreddit.Store must pass.
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+)
+
+// Run exercises every method of goreddit.Store. newStore must return an empty
+// store each time it is called.
+func Run(t *testing.T, newStore func(t *testing.T) goreddit.Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s goreddit.Store)
+	}{
+		{"Threads", testThreads},
+		{"Posts", testPosts},
+		{"Comments", testComments},
+		{"CommentTree", testCommentTree},
+		{"Users", testUsers},
+		{"Votes", testVotes},
+		{"Authors", testAuthors},
+		{"Timestamps", testTimestamps},
+		{"Ranking", testRanking},
+		{"Pagination", testPagination},
+		{"Tokens", testTokens},
+		{"Search", testSearch},
+		{"Subscriptions", testSubscriptions},
+		{"Moderators", testModerators},
+		{"Moderation", testModeration},
+		{"PinLimit", testPinLimit},
+		{"Reports", testReports},
+		{"ModLog", testModLog},
+		{"Bans", testBans},
+		{"Stats", testStats},
+		{"CascadeDelete", testCascadeDelete},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+// top lists by score alone, so that tests that order by votes do not depend
+// on when their fixtures were created.
+var top = goreddit.ListOptions{Sort: goreddit.SortTop}
+
+func testThreads(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+	if _, err := s.Thread(ctx, uuid.New()); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("Thread for unknown id = %v, want ErrNotFound", err)
+	}
+
+	a := createThread(t, s, "Alpha")
+	b := createThread(t, s, "Beta")
+
+	got, err := s.Thread(ctx, a.ID)
+	if err != nil {
+		t.Fatalf("Thread: %v", err)
+	}
+	if !sameThread(got, a) {
+		t.Errorf("Thread = %+v, want %+v", got, a)
+	}
+
+	tt, _, err := s.Threads(ctx, goreddit.PageOptions{})
+	if err != nil {
+		t.Fatalf("Threads: %v", err)
+	}
+	if len(tt) != 2 || !containsThread(tt, a.ID) || !containsThread(tt, b.ID) {
+		t.Errorf("Threads = %+v, want Alpha and Beta", tt)
+	}
+
+	a.Title, a.Description = "Alpha (renamed)", "Updated"
+	if err := s.UpdateThread(ctx, &a); err != nil {
+		t.Fatalf("UpdateThread: %v", err)
+	}
+	if got, _ := s.Thread(ctx, a.ID); !sameThread(got, a) {
+		t.Errorf("Thread after update = %+v, want %+v", got, a)
+	}
+
+	missing := goreddit.Thread{ID: uuid.New(), Title: "Missing", Description: "Missing"}
+	if err := s.UpdateThread(ctx, &missing); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("UpdateThread for unknown id = %v, want ErrNotFound", err)
+	}
+
+	if err := s.CreateThread(ctx, &goreddit.Thread{ID: a.ID, Title: "Dup", Description: "Dup"}); !errors.Is(err, goreddit.ErrConflict) {
+		t.Errorf("CreateThread for duplicate id = %v, want ErrConflict", err)
+	}
+
+	if err := s.DeleteThread(ctx, a.ID); err != nil {
+		t.Fatalf("DeleteThread: %v", err)
+	}
+	if _, err := s.Thread(ctx, a.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("Thread after delete = %v, want ErrNotFound", err)
+	}
+	if err := s.DeleteThread(ctx, a.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("DeleteThread for unknown id = %v, want ErrNotFound", err)
+	}
+}
+
+func testPosts(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+	if _, err := s.Post(ctx, uuid.New()); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("Post for unknown id = %v, want ErrNotFound", err)
+	}
+
+	orphan := goreddit.Post{ID: uuid.New(), ThreadID: uuid.New(), Title: "Orphan", Content: "Orphan"}
+	if err := s.CreatePost(ctx, &orphan); !errors.Is(err, goreddit.ErrInvalidReference) {
+		t.Errorf("CreatePost for unknown thread = %v, want ErrInvalidReference", err)
+	}
+
+	ta := createThread(t, s, "Alpha")
+	tb := createThread(t, s, "Beta")
+
+	low := createPost(t, s, ta.ID, "Low", 1)
+	high := createPost(t, s, ta.ID, "High", 10)
+	mid := createPost(t, s, tb.ID, "Mid", 5)
+	createComment(t, s, high.ID, "First", 0)
+	createComment(t, s, high.ID, "Second", 0)
+	createComment(t, s, mid.ID, "Third", 0)
+
+	got, err := s.Post(ctx, low.ID)
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if got.Title != "Low" || got.Content != low.Content || got.ThreadID != ta.ID || got.Votes != 1 {
+		t.Errorf("Post = %+v, want %+v", got, low)
+	}
+	if got.ThreadTitle != "Alpha" {
+		t.Errorf("Post.ThreadTitle = %q, want %q", got.ThreadTitle, "Alpha")
+	}
+
+	pp, _, err := s.Posts(ctx, top)
+	if err != nil {
+		t.Fatalf("Posts: %v", err)
+	}
+	assertPostTitles(t, "Posts", pp, "High", "Mid", "Low")
+	for _, p := range pp {
+		wantThread, wantComments := ta.Title, 0
+		switch p.ID {
+		case high.ID:
+			wantComments = 2
+		case mid.ID:
+			wantThread, wantComments = tb.Title, 1
+		}
+		if p.ThreadTitle != wantThread {
+			t.Errorf("Posts: %s ThreadTitle = %q, want %q", p.Title, p.ThreadTitle, wantThread)
+		}
+		if p.CommentsCount != wantComments {
+			t.Errorf("Posts: %s CommentsCount = %d, want %d", p.Title, p.CommentsCount, wantComments)
+		}
+	}
+
+	pp, _, err = s.PostsByThread(ctx, ta.ID, top)
+	if err != nil {
+		t.Fatalf("PostsByThread: %v", err)
+	}
+	assertPostTitles(t, "PostsByThread", pp, "High", "Low")
+	if pp[0].CommentsCount != 2 {
+		t.Errorf("PostsByThread: High CommentsCount = %d, want 2", pp[0].CommentsCount)
+	}
+
+	pp, _, err = s.PostsByThread(ctx, uuid.New(), top)
+	if err != nil {
+		t.Fatalf("PostsByThread: %v", err)
+	}
+	if len(pp) != 0 {
+		t.Errorf("PostsByThread for unknown thread returned %d posts", len(pp))
+	}
+
+	low.Title, low.Content, low.ThreadID = "Moved", "Edited", tb.ID
+	low.Votes = 100
+	if err := s.UpdatePost(ctx, &low); err != nil {
+		t.Fatalf("UpdatePost: %v", err)
+	}
+	got, _ = s.Post(ctx, low.ID)
+	if got.Title != "Moved" || got.Content != "Edited" || got.ThreadID != tb.ID {
+		t.Errorf("Post after update = %+v", got)
+	}
+	if got.Votes != 1 {
+		t.Errorf("UpdatePost changed votes to %d; votes are only changed through the vote ledger", got.Votes)
+	}
+
+	missing := goreddit.Post{ID: uuid.New(), ThreadID: ta.ID, Title: "Missing", Content: "Missing"}
+	if err := s.UpdatePost(ctx, &missing); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("UpdatePost for unknown id = %v, want ErrNotFound", err)
+	}
+
+	if err := s.DeletePost(ctx, high.ID); err != nil {
+		t.Fatalf("DeletePost: %v", err)
+	}
+	if _, err := s.Post(ctx, high.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("Post after delete = %v, want ErrNotFound", err)
+	}
+	if err := s.DeletePost(ctx, high.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("DeletePost for unknown id = %v, want ErrNotFound", err)
+	}
+	cc, _, err := s.CommentsbyPost(ctx, high.ID, top)
+	if err != nil {
+		t.Fatalf("CommentsbyPost: %v", err)
+	}
+	if len(cc) != 0 {
+		t.Errorf("DeletePost left %d comments behind", len(cc))
+	}
+}
+
+func testComments(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+	if _, err := s.Comment(ctx, uuid.New()); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("Comment for unknown id = %v, want ErrNotFound", err)
+	}
+
+	orphan := goreddit.Comment{ID: uuid.New(), PostID: uuid.New(), Content: "Orphan"}
+	if err := s.CreateComment(ctx, &orphan); !errors.Is(err, goreddit.ErrInvalidReference) {
+		t.Errorf("CreateComment for unknown post = %v, want ErrInvalidReference", err)
+	}
+
+	th := createThread(t, s, "Alpha")
+	p := createPost(t, s, th.ID, "Post", 0)
+	other := createPost(t, s, th.ID, "Other", 0)
+
+	meh := createComment(t, s, p.ID, "Meh", 2)
+	best := createComment(t, s, p.ID, "Best", 7)
+	worst := createComment(t, s, p.ID, "Worst", -3)
+	createComment(t, s, other.ID, "Elsewhere", 50)
+
+	got, err := s.Comment(ctx, best.ID)
+	if err != nil {
+		t.Fatalf("Comment: %v", err)
+	}
+	if got.ID != best.ID || got.PostID != p.ID || got.Content != "Best" || got.Votes != 7 || got.ParentID.Valid {
+		t.Errorf("Comment = %+v, want %+v", got, best)
+	}
+
+	cc, _, err := s.CommentsbyPost(ctx, p.ID, top)
+	if err != nil {
+		t.Fatalf("CommentsbyPost: %v", err)
+	}
+	assertCommentContents(t, cc, "Best", "Meh", "Worst")
+
+	meh.Content = "Edited"
+	if err := s.UpdateComment(ctx, &meh); err != nil {
+		t.Fatalf("UpdateComment: %v", err)
+	}
+	if got, _ := s.Comment(ctx, meh.ID); got.Content != "Edited" || got.Votes != 2 {
+		t.Errorf("Comment after update = %+v", got)
+	}
+
+	missing := goreddit.Comment{ID: uuid.New(), PostID: p.ID, Content: "Missing"}
+	if err := s.UpdateComment(ctx, &missing); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("UpdateComment for unknown id = %v, want ErrNotFound", err)
+	}
+
+	if err := s.DeleteComment(ctx, worst.ID); err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+	if _, err := s.Comment(ctx, worst.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("Comment after delete = %v, want ErrNotFound", err)
+	}
+	if err := s.DeleteComment(ctx, worst.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("DeleteComment for unknown id = %v, want ErrNotFound", err)
+	}
+	cc, _, _ = s.CommentsbyPost(ctx, p.ID, top)
+	assertCommentContents(t, cc, "Best", "Edited")
+
+	if err := s.SetCommentRemoved(ctx, best.ID, true); err != nil {
+		t.Fatalf("SetCommentRemoved: %v", err)
+	}
+	cc, _, err = s.Comments(ctx, top)
+	if err != nil {
+		t.Fatalf("Comments: %v", err)
+	}
+	assertCommentContents(t, cc, "Elsewhere", "Edited")
+	if cc[0].ThreadID != th.ID || cc[0].PostTitle != "Other" {
+		t.Errorf("Comments[0] has thread %v and post %q, want %v and %q", cc[0].ThreadID, cc[0].PostTitle, th.ID, "Other")
+	}
+	cc, _, _ = s.Comments(ctx, goreddit.ListOptions{Sort: goreddit.SortTop, IncludeRemoved: true})
+	assertCommentContents(t, cc, "Elsewhere", "Best", "Edited")
+}
+
+func testCommentTree(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+
+	th := createThread(t, s, "Alpha")
+	p := createPost(t, s, th.ID, "Post", 0)
+	other := createPost(t, s, th.ID, "Other", 0)
+
+	a := createComment(t, s, p.ID, "a", 5)
+	b := createComment(t, s, p.ID, "b", 1)
+	createReply(t, s, a, "a1", 3)
+	a2 := createReply(t, s, a, "a2", 4)
+	a2x := createReply(t, s, a2, "a2x", 0)
+	a2xy := createReply(t, s, a2x, "a2xy", 0)
+	createComment(t, s, other.ID, "elsewhere", 0)
+
+	orphan := goreddit.Comment{
+		ID:       uuid.New(),
+		PostID:   p.ID,
+		ParentID: uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		Content:  "orphan",
+	}
+	if err := s.CreateComment(ctx, &orphan); !errors.Is(err, goreddit.ErrInvalidReference) {
+		t.Errorf("CreateComment for unknown parent = %v, want ErrInvalidReference", err)
+	}
+
+	if got := mustComment(t, s, a2.ID); got.ParentID != (uuid.NullUUID{UUID: a.ID, Valid: true}) {
+		t.Errorf("Comment a2 ParentID = %v, want %v", got.ParentID, a.ID)
+	}
+
+	tree, err := s.CommentTree(ctx, p.ID, 1, goreddit.SortTop)
+	if err != nil {
+		t.Fatalf("CommentTree: %v", err)
+	}
+	assertCommentContents(t, tree, "a", "b")
+	assertCommentContents(t, tree[0].Replies, "a2", "a1")
+	if tree[0].Depth != 0 || tree[0].RepliesCount != 2 || len(tree[1].Replies) != 0 {
+		t.Errorf("CommentTree: a = %+v, b = %+v", tree[0], tree[1])
+	}
+	if got := tree[0].Replies[0]; got.Depth != 1 || got.RepliesCount != 1 || len(got.Replies) != 0 {
+		t.Errorf("CommentTree: a2 beyond max depth = depth %d, %d replies counted, %d loaded; want 1, 1, 0",
+			got.Depth, got.RepliesCount, len(got.Replies))
+	}
+
+	tree, err = s.CommentTree(ctx, p.ID, 10, goreddit.SortTop)
+	if err != nil {
+		t.Fatalf("CommentTree: %v", err)
+	}
+	assertCommentContents(t, tree[0].Replies[0].Replies, "a2x")
+	assertCommentContents(t, tree[0].Replies[0].Replies[0].Replies, "a2xy")
+	if got := tree[0].Replies[0].Replies[0].Replies[0].Depth; got != 3 {
+		t.Errorf("CommentTree: a2xy depth = %d, want 3", got)
+	}
+
+	sub, err := s.CommentSubtree(ctx, a2.ID, 1, goreddit.SortTop)
+	if err != nil {
+		t.Fatalf("CommentSubtree: %v", err)
+	}
+	if sub.ID != a2.ID || sub.Depth != 0 || sub.AuthorUsername != "" {
+		t.Errorf("CommentSubtree root = %+v, want a2 at depth 0", sub)
+	}
+	assertCommentContents(t, sub.Replies, "a2x")
+	if got := sub.Replies[0]; got.RepliesCount != 1 || len(got.Replies) != 0 {
+		t.Errorf("CommentSubtree: a2x = %+v, want 1 unloaded reply", got)
+	}
+
+	if _, err := s.CommentSubtree(ctx, uuid.New(), 1, goreddit.SortTop); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("CommentSubtree for unknown id = %v, want ErrNotFound", err)
+	}
+
+	// Deleting a comment deletes its replies.
+	if err := s.DeleteComment(ctx, a2.ID); err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+	for _, c := range []goreddit.Comment{a2x, a2xy} {
+		if _, err := s.Comment(ctx, c.ID); !errors.Is(err, goreddit.ErrNotFound) {
+			t.Errorf("Comment %s after deleting its parent = %v, want ErrNotFound", c.Content, err)
+		}
+	}
+	if _, err := s.Comment(ctx, b.ID); err != nil {
+		t.Errorf("Comment b after deleting a2: %v", err)
+	}
+}
+
+func testUsers(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+	if _, err := s.User(ctx, uuid.New()); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("User for unknown id = %v, want ErrNotFound", err)
+	}
+	if _, err := s.UserByUsername(ctx, "nobody"); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("UserByUsername for unknown username = %v, want ErrNotFound", err)
+	}
+
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	if alice.Role != goreddit.RoleUser {
+		t.Errorf("CreateUser role = %q, want %q", alice.Role, goreddit.RoleUser)
+	}
+
+	got, err := s.User(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("User: %v", err)
+	}
+	if !sameUser(got, alice) {
+		t.Errorf("User = %+v, want %+v", got, alice)
+	}
+	got, err = s.UserByUsername(ctx, "bob")
+	if err != nil {
+		t.Fatalf("UserByUsername: %v", err)
+	}
+	if !sameUser(got, bob) {
+		t.Errorf("UserByUsername = %+v, want %+v", got, bob)
+	}
+
+	dup := goreddit.User{ID: uuid.New(), Username: "alice", Password: "secret"}
+	if err := s.CreateUser(ctx, &dup); !errors.Is(err, goreddit.ErrConflict) {
+		t.Errorf("CreateUser for duplicate username = %v, want ErrConflict", err)
+	}
+
+	bob.Username = "alice"
+	if err := s.UpdateUser(ctx, &bob); !errors.Is(err, goreddit.ErrConflict) {
+		t.Errorf("UpdateUser for duplicate username = %v, want ErrConflict", err)
+	}
+	bob.Username, bob.Password = "robert", "changed"
+	if err := s.UpdateUser(ctx, &bob); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	if got, _ := s.UserByUsername(ctx, "robert"); !sameUser(got, bob) {
+		t.Errorf("UserByUsername after update = %+v, want %+v", got, bob)
+	}
+
+	if err := s.SetUserRole(ctx, bob.ID, goreddit.RoleAdmin); err != nil {
+		t.Fatalf("SetUserRole: %v", err)
+	}
+	if got, _ := s.User(ctx, bob.ID); !got.IsAdmin() {
+		t.Errorf("User after SetUserRole = %+v, want an admin", got)
+	}
+	bob.Password = "changed again"
+	if err := s.UpdateUser(ctx, &bob); err != nil || !bob.IsAdmin() {
+		t.Errorf("UpdateUser of an admin = %v with role %q, want the role kept", err, bob.Role)
+	}
+	if err := s.SetUserRole(ctx, uuid.New(), goreddit.RoleAdmin); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("SetUserRole for unknown id = %v, want ErrNotFound", err)
+	}
+	if err := s.SetUserRole(ctx, alice.ID, "owner"); err == nil {
+		t.Error("SetUserRole for unknown role succeeded, want an error")
+	}
+
+	old := goreddit.User{ID: uuid.New(), Username: "old", Password: "hashed-old", CreatedAt: time.Now().Add(-time.Hour)}
+	if err := s.CreateUser(ctx, &old); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	uu, page, err := s.Users(ctx, goreddit.PageOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("Users: %v", err)
+	}
+	if len(uu) != 2 || uu[0].ID == old.ID || uu[1].ID == old.ID || page.Next == "" {
+		t.Errorf("Users first page = %+v, %+v, want alice and bob and a next page", uu, page)
+	}
+	uu, _, err = s.Users(ctx, goreddit.PageOptions{Cursor: page.Next, Limit: 2})
+	if err != nil || len(uu) != 1 || uu[0].ID != old.ID {
+		t.Errorf("Users second page = %+v, %v, want the oldest user", uu, err)
+	}
+
+	if err := s.DeleteUser(ctx, alice.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if _, err := s.User(ctx, alice.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("User after delete = %v, want ErrNotFound", err)
+	}
+	if err := s.DeleteUser(ctx, alice.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("DeleteUser for unknown id = %v, want ErrNotFound", err)
+	}
+}
+
+func testVotes(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+	th := createThread(t, s, "Alpha")
+	p := createPost(t, s, th.ID, "Post", 0)
+	c := createComment(t, s, p.ID, "Comment", 0)
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+
+	if v, err := s.UserPostVote(ctx, alice.ID, p.ID); err != nil || v != 0 {
+		t.Errorf("UserPostVote before voting = %d, %v; want 0, nil", v, err)
+	}
+
+	steps := []struct {
+		user  goreddit.User
+		value int
+		want  int
+	}{
+		{alice, 1, 1},
+		{alice, 1, 1},
+		{bob, 1, 2},
+		{alice, -1, 0},
+		{bob, 0, -1},
+		{alice, 0, 0},
+	}
+	for i, step := range steps {
+		if err := s.CastPostVote(ctx, step.user.ID, p.ID, step.value); err != nil {
+			t.Fatalf("step %d: CastPostVote: %v", i, err)
+		}
+		if got := mustPost(t, s, p.ID).Votes; got != step.want {
+			t.Errorf("step %d: post votes = %d, want %d", i, got, step.want)
+		}
+		if v, _ := s.UserPostVote(ctx, step.user.ID, p.ID); v != step.value {
+			t.Errorf("step %d: UserPostVote = %d, want %d", i, v, step.value)
+		}
+
+		if err := s.CastCommentVote(ctx, step.user.ID, c.ID, step.value); err != nil {
+			t.Fatalf("step %d: CastCommentVote: %v", i, err)
+		}
+		if got := mustComment(t, s, c.ID).Votes; got != step.want {
+			t.Errorf("step %d: comment votes = %d, want %d", i, got, step.want)
+		}
+		if v, _ := s.UserCommentVote(ctx, step.user.ID, c.ID); v != step.value {
+			t.Errorf("step %d: UserCommentVote = %d, want %d", i, v, step.value)
+		}
+	}
+
+	if err := s.CastPostVote(ctx, alice.ID, p.ID, 2); err == nil {
+		t.Error("CastPostVote: expected error for value 2")
+	}
+	if err := s.CastCommentVote(ctx, alice.ID, c.ID, -2); err == nil {
+		t.Error("CastCommentVote: expected error for value -2")
+	}
+	if err := s.CastPostVote(ctx, alice.ID, uuid.New(), 1); !errors.Is(err, goreddit.ErrInvalidReference) {
+		t.Errorf("CastPostVote for unknown post = %v, want ErrInvalidReference", err)
+	}
+	if err := s.CastCommentVote(ctx, uuid.New(), c.ID, 1); !errors.Is(err, goreddit.ErrInvalidReference) {
+		t.Errorf("CastCommentVote for unknown user = %v, want ErrInvalidReference", err)
+	}
+
+	// Deleting a user removes their votes from the tallies.
+	if err := s.CastPostVote(ctx, bob.ID, p.ID, 1); err != nil {
+		t.Fatalf("CastPostVote: %v", err)
+	}
+	if err := s.CastCommentVote(ctx, bob.ID, c.ID, -1); err != nil {
+		t.Fatalf("CastCommentVote: %v", err)
+	}
+	if err := s.DeleteUser(ctx, bob.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if got := mustPost(t, s, p.ID).Votes; got != 0 {
+		t.Errorf("post votes after deleting voter = %d, want 0", got)
+	}
+	if got := mustComment(t, s, c.ID).Votes; got != 0 {
+		t.Errorf("comment votes after deleting voter = %d, want 0", got)
+	}
+}
+
+func testAuthors(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+
+	alice := createUser(t, s, "alice")
+	author := uuid.NullUUID{UUID: alice.ID, Valid: true}
+
+	th := goreddit.Thread{ID: uuid.New(), Title: "Alpha", Description: "Alpha", AuthorID: author}
+	if err := s.CreateThread(ctx, &th); err != nil {
+		t.Fatalf("CreateThread: %v", err)
+	}
+	p := goreddit.Post{ID: uuid.New(), ThreadID: th.ID, Title: "Post", Content: "Post", AuthorID: author}
+	if err := s.CreatePost(ctx, &p); err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	c := goreddit.Comment{ID: uuid.New(), PostID: p.ID, Content: "Comment", AuthorID: author}
+	if err := s.CreateComment(ctx, &c); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	unknown := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	bad := goreddit.Thread{ID: uuid.New(), Title: "Bad", Description: "Bad", AuthorID: unknown}
+	if err := s.CreateThread(ctx, &bad); !errors.Is(err, goreddit.ErrInvalidReference) {
+		t.Errorf("CreateThread for unknown author = %v, want ErrInvalidReference", err)
+	}
+
+	check := func(when string, wantID uuid.NullUUID, wantName string) {
+		t.Helper()
+		if got := mustThread(t, s, th.ID); got.AuthorID != wantID || got.AuthorUsername != wantName {
+			t.Errorf("Thread %s: author = %v %q, want %v %q", when, got.AuthorID, got.AuthorUsername, wantID, wantName)
+		}
+		if got := mustPost(t, s, p.ID); got.AuthorID != wantID || got.AuthorUsername != wantName {
+			t.Errorf("Post %s: author = %v %q, want %v %q", when, got.AuthorID, got.AuthorUsername, wantID, wantName)
+		}
+		if got := mustComment(t, s, c.ID); got.AuthorID != wantID || got.AuthorUsername != wantName {
+			t.Errorf("Comment %s: author = %v %q, want %v %q", when, got.AuthorID, got.AuthorUsername, wantID, wantName)
+		}
+
+		tt, _, err := s.Threads(ctx, goreddit.PageOptions{})
+		if err != nil || len(tt) != 1 || tt[0].AuthorUsername != wantName {
+			t.Errorf("Threads %s = %+v, %v; want author %q", when, tt, err, wantName)
+		}
+		pp, _, err := s.Posts(ctx, top)
+		if err != nil || len(pp) != 1 || pp[0].AuthorUsername != wantName {
+			t.Errorf("Posts %s = %+v, %v; want author %q", when, pp, err, wantName)
+		}
+		pp, _, err = s.PostsByThread(ctx, th.ID, top)
+		if err != nil || len(pp) != 1 || pp[0].AuthorUsername != wantName {
+			t.Errorf("PostsByThread %s = %+v, %v; want author %q", when, pp, err, wantName)
+		}
+		cc, _, err := s.CommentsbyPost(ctx, p.ID, top)
+		if err != nil || len(cc) != 1 || cc[0].AuthorUsername != wantName {
+			t.Errorf("CommentsbyPost %s = %+v, %v; want author %q", when, cc, err, wantName)
+		}
+	}
+	check("after create", author, "alice")
+
+	// Updates never change the author.
+	th.AuthorID = uuid.NullUUID{}
+	if err := s.UpdateThread(ctx, &th); err != nil {
+		t.Fatalf("UpdateThread: %v", err)
+	}
+	check("after update", author, "alice")
+
+	// Content outlives its author.
+	if err := s.DeleteUser(ctx, alice.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	check("after deleting author", uuid.NullUUID{}, "")
+}
+
+func testTimestamps(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+	start := time.Now().Add(-time.Minute)
+
+	th := createThread(t, s, "Alpha")
+	p := createPost(t, s, th.ID, "Post", 0)
+	c := createComment(t, s, p.ID, "Comment", 0)
+	u := createUser(t, s, "alice")
+
+	created := map[string][2]time.Time{
+		"thread":  {th.CreatedAt, th.UpdatedAt},
+		"post":    {p.CreatedAt, p.UpdatedAt},
+		"comment": {c.CreatedAt, c.UpdatedAt},
+		"user":    {u.CreatedAt, u.UpdatedAt},
+	}
+	for name, ts := range created {
+		if ts[0].Before(start) || !ts[1].Equal(ts[0]) {
+			t.Errorf("created %s: CreatedAt = %v, UpdatedAt = %v; want the current time for both", name, ts[0], ts[1])
+		}
+	}
+
+	// Callers may backdate content, e.g. when importing it.
+	past := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	old := goreddit.Post{ID: uuid.New(), ThreadID: th.ID, Title: "Old", Content: "Old", CreatedAt: past}
+	if err := s.CreatePost(ctx, &old); err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	if got := mustPost(t, s, old.ID); !got.CreatedAt.Equal(past) || !got.UpdatedAt.Equal(past) {
+		t.Errorf("backdated post: CreatedAt = %v, UpdatedAt = %v; want %v", got.CreatedAt, got.UpdatedAt, past)
+	}
+
+	// Votes are not edits.
+	if err := s.CastPostVote(ctx, u.ID, old.ID, 1); err != nil {
+		t.Fatalf("CastPostVote: %v", err)
+	}
+	if got := mustPost(t, s, old.ID); !got.UpdatedAt.Equal(past) {
+		t.Errorf("UpdatedAt after vote = %v, want %v", got.UpdatedAt, past)
+	}
+
+	old.Content = "Edited"
+	if err := s.UpdatePost(ctx, &old); err != nil {
+		t.Fatalf("UpdatePost: %v", err)
+	}
+	got := mustPost(t, s, old.ID)
+	if !got.CreatedAt.Equal(past) || got.UpdatedAt.Before(start) {
+		t.Errorf("edited post: CreatedAt = %v, UpdatedAt = %v; want %v and the current time", got.CreatedAt, got.UpdatedAt, past)
+	}
+
+	th.Title = "Renamed"
+	if err := s.UpdateThread(ctx, &th); err != nil {
+		t.Fatalf("UpdateThread: %v", err)
+	}
+	if got := mustThread(t, s, th.ID); got.UpdatedAt.Before(got.CreatedAt) || !got.CreatedAt.Equal(created["thread"][0]) {
+		t.Errorf("edited thread: CreatedAt = %v, UpdatedAt = %v", got.CreatedAt, got.UpdatedAt)
+	}
+
+	u.Password = "changed"
+	if err := s.UpdateUser(ctx, &u); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	if got, _ := s.User(ctx, u.ID); !got.CreatedAt.Equal(created["user"][0]) || got.UpdatedAt.Before(got.CreatedAt) {
+		t.Errorf("edited user: CreatedAt = %v, UpdatedAt = %v", got.CreatedAt, got.UpdatedAt)
+	}
+}
+
+func testRanking(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+	now := time.Now()
+
+	th := createThread(t, s, "Alpha")
+	other := createThread(t, s, "Beta")
+	createPostAt(t, s, th.ID, "Old", 100, now.Add(-30*24*time.Hour))
+	createPostAt(t, s, th.ID, "Yesterday", 20, now.Add(-40*time.Hour))
+	divisive := createPostAt(t, s, th.ID, "Divisive", 0, now.Add(-3*time.Hour))
+	createPostAt(t, s, other.ID, "Fresh", 3, now)
+
+	for i, value := range []int{1, 1, 1, -1, -1} {
+		u := createUser(t, s, fmt.Sprintf("voter%d", i))
+		if err := s.CastPostVote(ctx, u.ID, divisive.ID, value); err != nil {
+			t.Fatalf("CastPostVote: %v", err)
+		}
+	}
+	if got := mustPost(t, s, divisive.ID); got.Votes != 1 || got.Upvotes != 3 || got.Downvotes != 2 {
+		t.Errorf("votes = %d (+%d/-%d), want 1 (+3/-2)", got.Votes, got.Upvotes, got.Downvotes)
+	}
+
+	tests := []struct {
+		opts   goreddit.ListOptions
+		titles []string
+	}{
+		{goreddit.ListOptions{}, []string{"Fresh", "Divisive", "Yesterday", "Old"}},
+		{goreddit.ListOptions{Sort: goreddit.SortHot}, []string{"Fresh", "Divisive", "Yesterday", "Old"}},
+		{goreddit.ListOptions{Sort: goreddit.SortNew}, []string{"Fresh", "Divisive", "Yesterday", "Old"}},
+		{goreddit.ListOptions{Sort: goreddit.SortTop}, []string{"Old", "Yesterday", "Fresh", "Divisive"}},
+		{goreddit.ListOptions{Sort: goreddit.SortTop, Window: goreddit.WindowAll}, []string{"Old", "Yesterday", "Fresh", "Divisive"}},
+		{goreddit.ListOptions{Sort: goreddit.SortTop, Window: goreddit.WindowWeek}, []string{"Yesterday", "Fresh", "Divisive"}},
+		{goreddit.ListOptions{Sort: goreddit.SortTop, Window: goreddit.WindowDay}, []string{"Fresh", "Divisive"}},
+		{goreddit.ListOptions{Sort: goreddit.SortTop, Window: goreddit.WindowHour}, []string{"Fresh"}},
+		{goreddit.ListOptions{Sort: goreddit.SortRising}, []string{"Fresh", "Divisive"}},
+		// Windows only apply to top and controversial.
+		{goreddit.ListOptions{Sort: goreddit.SortNew, Window: goreddit.WindowHour}, []string{"Fresh", "Divisive", "Yesterday", "Old"}},
+	}
+	for _, tt := range tests {
+		pp, _, err := s.Posts(ctx, tt.opts)
+		if err != nil {
+			t.Fatalf("Posts(%+v): %v", tt.opts, err)
+		}
+		assertPostTitles(t, fmt.Sprintf("Posts(%+v)", tt.opts), pp, tt.titles...)
+	}
+
+	pp, _, err := s.Posts(ctx, goreddit.ListOptions{Sort: goreddit.SortControversial, Window: goreddit.WindowDay})
+	if err != nil {
+		t.Fatalf("Posts: %v", err)
+	}
+	if len(pp) != 2 || pp[0].ID != divisive.ID {
+		t.Errorf("controversial posts of the day = %+v, want Divisive first of 2", pp)
+	}
+
+	pp, _, err = s.PostsByThread(ctx, th.ID, goreddit.ListOptions{Sort: goreddit.SortTop, Window: goreddit.WindowWeek})
+	if err != nil {
+		t.Fatalf("PostsByThread: %v", err)
+	}
+	assertPostTitles(t, "PostsByThread", pp, "Yesterday", "Divisive")
+
+	early := createCommentAt(t, s, divisive.ID, "Early", 5, now.Add(-time.Hour))
+	createCommentAt(t, s, divisive.ID, "Late", 1, now)
+	createReply(t, s, early, "Early reply", 0)
+
+	cc, _, err := s.CommentsbyPost(ctx, divisive.ID, goreddit.ListOptions{Sort: goreddit.SortNew})
+	if err != nil {
+		t.Fatalf("CommentsbyPost: %v", err)
+	}
+	assertCommentContents(t, cc, "Early reply", "Late", "Early")
+
+	tree, err := s.CommentTree(ctx, divisive.ID, 1, goreddit.SortNew)
+	if err != nil {
+		t.Fatalf("CommentTree: %v", err)
+	}
+	assertCommentContents(t, tree, "Late", "Early")
+
+	tree, err = s.CommentTree(ctx, divisive.ID, 1, goreddit.SortTop)
+	if err != nil {
+		t.Fatalf("CommentTree: %v", err)
+	}
+	assertCommentContents(t, tree, "Early", "Late")
+}
+
+func testPagination(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+
+	th := createThread(t, s, "Alpha")
+	for i := 1; i <= 7; i++ {
+		createPost(t, s, th.ID, fmt.Sprintf("P%d", i), i)
+	}
+
+	opts := goreddit.ListOptions{Sort: goreddit.SortTop, PageOptions: goreddit.PageOptions{Limit: 3}}
+	list := func(cursor string) ([]goreddit.Post, goreddit.Page) {
+		t.Helper()
+		opts.Cursor = cursor
+		pp, page, err := s.PostsByThread(ctx, th.ID, opts)
+		if err != nil {
+			t.Fatalf("PostsByThread: %v", err)
+		}
+		return pp, page
+	}
+
+	pp, first := list("")
+	assertPostTitles(t, "first page", pp, "P7", "P6", "P5")
+	if first.Next == "" || first.Prev != "" {
+		t.Fatalf("first page = %+v, want only a next page", first)
+	}
+
+	// Pages stay put when posts are added before them.
+	createPost(t, s, th.ID, "P10", 10)
+
+	pp, second := list(first.Next)
+	assertPostTitles(t, "second page", pp, "P4", "P3", "P2")
+	if second.Next == "" || second.Prev == "" {
+		t.Fatalf("second page = %+v, want next and previous pages", second)
+	}
+
+	pp, last := list(second.Next)
+	assertPostTitles(t, "last page", pp, "P1")
+	if last.Next != "" || last.Prev == "" {
+		t.Fatalf("last page = %+v, want only a previous page", last)
+	}
+
+	pp, back := list(last.Prev)
+	assertPostTitles(t, "previous page", pp, "P4", "P3", "P2")
+	if back.Next == "" || back.Prev == "" {
+		t.Fatalf("previous page = %+v, want next and previous pages", back)
+	}
+
+	// Going back reveals the post added in the meantime.
+	pp, front := list(back.Prev)
+	assertPostTitles(t, "front page", pp, "P7", "P6", "P5")
+	if front.Next == "" || front.Prev == "" {
+		t.Fatalf("front page = %+v, want next and previous pages", front)
+	}
+	pp, newest := list(front.Prev)
+	assertPostTitles(t, "newest page", pp, "P10")
+	if newest.Next == "" || newest.Prev != "" {
+		t.Fatalf("newest page = %+v, want only a next page", newest)
+	}
+
+	for _, cursor := range []string{"garbage", first.Next} {
+		_, _, err := s.Posts(ctx, goreddit.ListOptions{Sort: goreddit.SortNew, PageOptions: goreddit.PageOptions{Cursor: cursor}})
+		if !errors.Is(err, goreddit.ErrInvalidCursor) {
+			t.Errorf("Posts with cursor %q = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+
+	p := createPost(t, s, th.ID, "Discussed", 0)
+	for i := 0; i < 5; i++ {
+		createComment(t, s, p.ID, fmt.Sprintf("C%d", i), 0)
+	}
+	createThread(t, s, "Beta")
+	createThread(t, s, "Gamma")
+
+	seen := map[uuid.UUID]bool{}
+	var page goreddit.Page
+	for {
+		cc, next, err := s.CommentsbyPost(ctx, p.ID, goreddit.ListOptions{Sort: goreddit.SortNew, PageOptions: goreddit.PageOptions{Cursor: page.Next, Limit: 2}})
+		if err != nil {
+			t.Fatalf("CommentsbyPost: %v", err)
+		}
+		for _, c := range cc {
+			seen[c.ID] = true
+		}
+		if page = next; page.Next == "" {
+			break
+		}
+	}
+	if len(seen) != 5 {
+		t.Errorf("paged through %d comments, want 5", len(seen))
+	}
+
+	tt, page, err := s.Threads(ctx, goreddit.PageOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("Threads: %v", err)
+	}
+	more, rest, err := s.Threads(ctx, goreddit.PageOptions{Cursor: page.Next, Limit: 2})
+	if err != nil {
+		t.Fatalf("Threads: %v", err)
+	}
+	if tt = append(tt, more...); len(tt) != 3 || rest.Next != "" {
+		t.Errorf("paged through %d threads with next cursor %q, want 3 and none", len(tt), rest.Next)
+	}
+	if !containsThread(tt, th.ID) {
+		t.Errorf("paged threads = %+v, want them to include %v", tt, th.ID)
+	}
+}
+
+func testTokens(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+
+	if _, err := s.Token(ctx, uuid.New()); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("Token for unknown id = %v, want ErrNotFound", err)
+	}
+	if _, err := s.TokenByHash(ctx, []byte("unknown")); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("TokenByHash for unknown hash = %v, want ErrNotFound", err)
+	}
+
+	old := goreddit.Token{
+		ID:        uuid.New(),
+		UserID:    alice.ID,
+		Name:      "old bot",
+		Hash:      []byte("hash-1"),
+		Scopes:    goreddit.Scopes{goreddit.ScopeRead},
+		CreatedAt: time.Now().Add(-time.Hour),
+	}
+	bot := goreddit.Token{
+		ID:     uuid.New(),
+		UserID: alice.ID,
+		Name:   "bot",
+		Hash:   []byte("hash-2"),
+		Scopes: goreddit.Scopes{goreddit.ScopeRead, goreddit.ScopeVote},
+	}
+	for _, tok := range []*goreddit.Token{&old, &bot} {
+		if err := s.CreateToken(ctx, tok); err != nil {
+			t.Fatalf("CreateToken: %v", err)
+		}
+	}
+	if bot.CreatedAt.IsZero() {
+		t.Error("CreateToken did not set CreatedAt")
+	}
+
+	got, err := s.TokenByHash(ctx, []byte("hash-2"))
+	if err != nil {
+		t.Fatalf("TokenByHash: %v", err)
+	}
+	if got.ID != bot.ID || got.UserID != alice.ID || got.Name != "bot" || got.Scopes.String() != "read,vote" {
+		t.Errorf("TokenByHash = %+v, want %+v", got, bot)
+	}
+	if got, err := s.Token(ctx, old.ID); err != nil || got.Name != "old bot" || !got.Scopes.Has(goreddit.ScopeRead) || got.Scopes.Has(goreddit.ScopeWrite) {
+		t.Errorf("Token = %+v, %v; want %+v", got, err, old)
+	}
+
+	dup := goreddit.Token{ID: uuid.New(), UserID: bob.ID, Name: "dup", Hash: []byte("hash-1"), Scopes: goreddit.Scopes{goreddit.ScopeRead}}
+	if err := s.CreateToken(ctx, &dup); !errors.Is(err, goreddit.ErrConflict) {
+		t.Errorf("CreateToken with a taken hash = %v, want ErrConflict", err)
+	}
+	orphan := goreddit.Token{ID: uuid.New(), UserID: uuid.New(), Name: "orphan", Hash: []byte("hash-3"), Scopes: goreddit.Scopes{goreddit.ScopeRead}}
+	if err := s.CreateToken(ctx, &orphan); !errors.Is(err, goreddit.ErrInvalidReference) {
+		t.Errorf("CreateToken for unknown user = %v, want ErrInvalidReference", err)
+	}
+
+	tt, err := s.TokensByUser(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("TokensByUser: %v", err)
+	}
+	if len(tt) != 2 || tt[0].ID != bot.ID || tt[1].ID != old.ID {
+		t.Errorf("TokensByUser = %+v, want the newest token first", tt)
+	}
+	if tt, _ := s.TokensByUser(ctx, bob.ID); len(tt) != 0 {
+		t.Errorf("TokensByUser for user without tokens = %+v, want none", tt)
+	}
+
+	if err := s.DeleteToken(ctx, old.ID); err != nil {
+		t.Fatalf("DeleteToken: %v", err)
+	}
+	if err := s.DeleteToken(ctx, old.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("DeleteToken twice = %v, want ErrNotFound", err)
+	}
+
+	// Tokens are revoked along with their user.
+	if err := s.DeleteUser(ctx, alice.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if _, err := s.TokenByHash(ctx, []byte("hash-2")); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("TokenByHash after deleting its user = %v, want ErrNotFound", err)
+	}
+}
+
+func testSearch(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	by := func(u goreddit.User) uuid.NullUUID { return uuid.NullUUID{UUID: u.ID, Valid: true} }
+	now := time.Now()
+
+	zebras := goreddit.Thread{ID: uuid.New(), Title: "Zebra sightings", Description: "Stripes and more", AuthorID: by(alice)}
+	cooking := goreddit.Thread{ID: uuid.New(), Title: "Cooking", Description: "Recipes", AuthorID: by(bob)}
+	for _, th := range []*goreddit.Thread{&zebras, &cooking} {
+		if err := s.CreateThread(ctx, th); err != nil {
+			t.Fatalf("CreateThread: %v", err)
+		}
+	}
+	question := goreddit.Post{ID: uuid.New(), ThreadID: zebras.ID, Title: "Question", Content: "Has anyone seen a zebra near the river?", AuthorID: by(bob), CreatedAt: now.Add(-48 * time.Hour)}
+	cake := goreddit.Post{ID: uuid.New(), ThreadID: cooking.ID, Title: "Zebra cake", Content: "A striped cake", AuthorID: by(alice)}
+	for _, p := range []*goreddit.Post{&question, &cake} {
+		if err := s.CreatePost(ctx, p); err != nil {
+			t.Fatalf("CreatePost: %v", err)
+		}
+	}
+	sighting := goreddit.Comment{ID: uuid.New(), PostID: question.ID, Content: "I saw a zebra yesterday", AuthorID: by(alice)}
+	other := goreddit.Comment{ID: uuid.New(), PostID: cake.ID, Content: "No animals here", AuthorID: by(bob)}
+	for _, c := range []*goreddit.Comment{&sighting, &other} {
+		if err := s.CreateComment(ctx, c); err != nil {
+			t.Fatalf("CreateComment: %v", err)
+		}
+	}
+
+	search := func(opts goreddit.SearchOptions) []goreddit.SearchResult {
+		t.Helper()
+		rr, _, err := s.Search(ctx, opts)
+		if err != nil {
+			t.Fatalf("Search(%+v): %v", opts, err)
+		}
+		return rr
+	}
+	ids := func(rr []goreddit.SearchResult) map[uuid.UUID]bool {
+		m := map[uuid.UUID]bool{}
+		for _, r := range rr {
+			m[r.ID] = true
+		}
+		return m
+	}
+	assertResults := func(name string, rr []goreddit.SearchResult, want ...uuid.UUID) {
+		t.Helper()
+		got := ids(rr)
+		if len(rr) != len(want) {
+			t.Errorf("%s: got %d results, want %d", name, len(rr), len(want))
+		}
+		for _, id := range want {
+			if !got[id] {
+				t.Errorf("%s: missing result %v", name, id)
+			}
+		}
+	}
+
+	rr := search(goreddit.SearchOptions{Query: "zebra"})
+	assertResults("zebra", rr, zebras.ID, question.ID, cake.ID, sighting.ID)
+	if len(rr) == 4 {
+		// Matches in titles rank above matches in the content.
+		if top := ids(rr[:2]); !top[zebras.ID] || !top[cake.ID] {
+			t.Errorf("zebra: title matches are not ranked first: %+v", rr)
+		}
+		for i := 1; i < len(rr); i++ {
+			if rr[i].Rank > rr[i-1].Rank {
+				t.Errorf("zebra: results not ordered by rank: %+v", rr)
+			}
+		}
+	}
+	for _, r := range rr {
+		switch r.ID {
+		case question.ID:
+			if r.Kind != goreddit.SearchPosts || r.ThreadID != zebras.ID || r.ThreadTitle != "Zebra sightings" || r.PostID.UUID != question.ID || r.PostTitle != "Question" || r.AuthorUsername != "bob" {
+				t.Errorf("post result = %+v", r)
+			}
+		case sighting.ID:
+			if r.Kind != goreddit.SearchComments || r.ThreadID != zebras.ID || r.PostID.UUID != question.ID || r.PostTitle != "Question" || r.AuthorUsername != "alice" {
+				t.Errorf("comment result = %+v", r)
+			}
+			if want := goreddit.HighlightStart + "zebra" + goreddit.HighlightStop; !strings.Contains(r.Snippet, want) {
+				t.Errorf("comment snippet = %q, want it to contain %q", r.Snippet, want)
+			}
+		case zebras.ID:
+			if r.Kind != goreddit.SearchThreads || r.ThreadID != zebras.ID || r.PostID.Valid || r.AuthorUsername != "alice" {
+				t.Errorf("thread result = %+v", r)
+			}
+		}
+	}
+
+	assertResults("case", search(goreddit.SearchOptions{Query: "ZEBRA"}), zebras.ID, question.ID, cake.ID, sighting.ID)
+	assertResults("comments", search(goreddit.SearchOptions{Query: "zebra", Kinds: []goreddit.SearchKind{goreddit.SearchComments}}), sighting.ID)
+	assertResults("threads and posts", search(goreddit.SearchOptions{Query: "zebra", Kinds: []goreddit.SearchKind{goreddit.SearchThreads, goreddit.SearchPosts}}), zebras.ID, question.ID, cake.ID)
+	assertResults("author", search(goreddit.SearchOptions{Query: "zebra", Author: "alice"}), zebras.ID, cake.ID, sighting.ID)
+	assertResults("thread", search(goreddit.SearchOptions{Query: "zebra", ThreadID: uuid.NullUUID{UUID: zebras.ID, Valid: true}}), zebras.ID, question.ID, sighting.ID)
+	assertResults("after", search(goreddit.SearchOptions{Query: "zebra", After: now.Add(-24 * time.Hour)}), zebras.ID, cake.ID, sighting.ID)
+	assertResults("before", search(goreddit.SearchOptions{Query: "zebra", Before: now.Add(-24 * time.Hour)}), question.ID)
+	assertResults("all words", search(goreddit.SearchOptions{Query: "zebra river"}), question.ID)
+	assertResults("excluded word", search(goreddit.SearchOptions{Query: "zebra -cake"}), zebras.ID, question.ID, sighting.ID)
+	assertResults("phrase", search(goreddit.SearchOptions{Query: `"saw a zebra"`}), sighting.ID)
+	assertResults("no match", search(goreddit.SearchOptions{Query: "giraffe"}))
+	assertResults("empty", search(goreddit.SearchOptions{Query: "  "}))
+
+	first, page, err := s.Search(ctx, goreddit.SearchOptions{Query: "zebra", PageOptions: goreddit.PageOptions{Limit: 3}})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(first) != 3 || page.Next == "" || page.Prev != "" {
+		t.Fatalf("first page = %d results %+v, want 3 and a next page", len(first), page)
+	}
+	second, page, err := s.Search(ctx, goreddit.SearchOptions{Query: "zebra", PageOptions: goreddit.PageOptions{Limit: 3, Cursor: page.Next}})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(second) != 1 || page.Next != "" || page.Prev == "" || ids(first)[second[0].ID] {
+		t.Errorf("second page = %+v %+v, want the remaining result", second, page)
+	}
+
+	if _, _, err := s.Search(ctx, goreddit.SearchOptions{Query: "zebra", PageOptions: goreddit.PageOptions{Cursor: "bogus"}}); !errors.Is(err, goreddit.ErrInvalidCursor) {
+		t.Errorf("Search with a bad cursor = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func testSubscriptions(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	golang := createThread(t, s, "Go")
+	rust := createThread(t, s, "Rust")
+	zig := createThread(t, s, "Zig")
+
+	if ok, err := s.Subscribed(ctx, alice.ID, golang.ID); err != nil || ok {
+		t.Errorf("Subscribed before subscribing = %v, %v; want false", ok, err)
+	}
+	for _, th := range []goreddit.Thread{zig, golang} {
+		if err := s.Subscribe(ctx, alice.ID, th.ID); err != nil {
+			t.Fatalf("Subscribe: %v", err)
+		}
+	}
+	if err := s.Subscribe(ctx, alice.ID, golang.ID); err != nil {
+		t.Errorf("Subscribe twice = %v, want nil", err)
+	}
+	if err := s.Subscribe(ctx, bob.ID, golang.ID); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := s.Subscribe(ctx, alice.ID, uuid.New()); !errors.Is(err, goreddit.ErrInvalidReference) {
+		t.Errorf("Subscribe to unknown thread = %v, want ErrInvalidReference", err)
+	}
+	if err := s.Subscribe(ctx, uuid.New(), golang.ID); !errors.Is(err, goreddit.ErrInvalidReference) {
+		t.Errorf("Subscribe unknown user = %v, want ErrInvalidReference", err)
+	}
+
+	if ok, err := s.Subscribed(ctx, alice.ID, golang.ID); err != nil || !ok {
+		t.Errorf("Subscribed = %v, %v; want true", ok, err)
+	}
+	tt, err := s.Subscriptions(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("Subscriptions: %v", err)
+	}
+	if len(tt) != 2 || tt[0].ID != golang.ID || tt[1].ID != zig.ID {
+		t.Errorf("Subscriptions = %+v, want Go and Zig", tt)
+	} else if tt[0].SubscribersCount != 2 || tt[1].SubscribersCount != 1 {
+		t.Errorf("Subscriptions: subscriber counts = %d, %d; want 2, 1", tt[0].SubscribersCount, tt[1].SubscribersCount)
+	}
+	if th := mustThread(t, s, golang.ID); th.SubscribersCount != 2 {
+		t.Errorf("Thread: SubscribersCount = %d, want 2", th.SubscribersCount)
+	}
+
+	popular, err := s.PopularThreads(ctx, 2)
+	if err != nil {
+		t.Fatalf("PopularThreads: %v", err)
+	}
+	if len(popular) != 2 || popular[0].ID != golang.ID || popular[1].ID != zig.ID {
+		t.Errorf("PopularThreads = %+v, want Go and Zig", popular)
+	}
+
+	createPost(t, s, golang.ID, "Go post", 0)
+	createPost(t, s, rust.ID, "Rust post", 0)
+	createPost(t, s, zig.ID, "Zig post", 0)
+	pp, _, err := s.PostsByThreads(ctx, []uuid.UUID{golang.ID, zig.ID}, top)
+	if err != nil {
+		t.Fatalf("PostsByThreads: %v", err)
+	}
+	if len(pp) != 2 || pp[0].ThreadID == rust.ID || pp[1].ThreadID == rust.ID || pp[0].ThreadTitle == "" {
+		t.Errorf("PostsByThreads = %+v, want the Go and Zig posts", pp)
+	}
+	if pp, _, err := s.PostsByThreads(ctx, nil, top); err != nil || len(pp) != 0 {
+		t.Errorf("PostsByThreads(nil) = %+v, %v; want no posts", pp, err)
+	}
+
+	if err := s.Unsubscribe(ctx, alice.ID, golang.ID); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+	if err := s.Unsubscribe(ctx, alice.ID, golang.ID); err != nil {
+		t.Errorf("Unsubscribe twice = %v, want nil", err)
+	}
+	if ok, _ := s.Subscribed(ctx, alice.ID, golang.ID); ok {
+		t.Error("Subscribed after Unsubscribe = true, want false")
+	}
+
+	if err := s.DeleteThread(ctx, zig.ID); err != nil {
+		t.Fatalf("DeleteThread: %v", err)
+	}
+	if tt, err := s.Subscriptions(ctx, alice.ID); err != nil || len(tt) != 0 {
+		t.Errorf("Subscriptions after deleting the thread = %+v, %v; want none", tt, err)
+	}
+	if err := s.DeleteUser(ctx, bob.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if th := mustThread(t, s, golang.ID); th.SubscribersCount != 0 {
+		t.Errorf("SubscribersCount after deleting the subscriber = %d, want 0", th.SubscribersCount)
+	}
+}
+
+func testModerators(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	carol := createUser(t, s, "carol")
+
+	th := goreddit.Thread{ID: uuid.New(), Title: "Moderated", AuthorID: uuid.NullUUID{UUID: alice.ID, Valid: true}}
+	if err := s.CreateThread(ctx, &th); err != nil {
+		t.Fatalf("CreateThread: %v", err)
+	}
+	if ok, err := s.IsModerator(ctx, th.ID, alice.ID); err != nil || !ok {
+		t.Errorf("IsModerator for the thread's author = %v, %v; want true", ok, err)
+	}
+	if ok, err := s.IsModerator(ctx, th.ID, bob.ID); err != nil || ok {
+		t.Errorf("IsModerator before being invited = %v, %v; want false", ok, err)
+	}
+
+	inv := goreddit.Invite{ThreadID: th.ID, UserID: bob.ID, InvitedByID: uuid.NullUUID{UUID: alice.ID, Valid: true}}
+	if err := s.CreateInvite(ctx, &inv); err != nil {
+		t.Fatalf("CreateInvite: %v", err)
+	}
+	if inv.CreatedAt.IsZero() || inv.ThreadTitle != "Moderated" || inv.Username != "bob" || inv.InvitedByUsername != "alice" {
+		t.Errorf("CreateInvite = %+v, want the invite with its thread and usernames", inv)
+	}
+	dup := goreddit.Invite{ThreadID: th.ID, UserID: bob.ID}
+	if err := s.CreateInvite(ctx, &dup); !errors.Is(err, goreddit.ErrConflict) {
+		t.Errorf("CreateInvite twice = %v, want ErrConflict", err)
+	}
+	unknown := goreddit.Invite{ThreadID: th.ID, UserID: uuid.New()}
+	if err := s.CreateInvite(ctx, &unknown); !errors.Is(err, goreddit.ErrInvalidReference) {
+		t.Errorf("CreateInvite for unknown user = %v, want ErrInvalidReference", err)
+	}
+	if got, err := s.Invite(ctx, th.ID, bob.ID); err != nil || got.Username != "bob" {
+		t.Errorf("Invite = %+v, %v; want bob's invite", got, err)
+	}
+	if _, err := s.Invite(ctx, th.ID, carol.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("Invite for uninvited user = %v, want ErrNotFound", err)
+	}
+	if ii, err := s.Invites(ctx, bob.ID); err != nil || len(ii) != 1 || ii[0].ThreadID != th.ID {
+		t.Errorf("Invites = %+v, %v; want the invite to Moderated", ii, err)
+	}
+
+	if err := s.AcceptInvite(ctx, th.ID, bob.ID); err != nil {
+		t.Fatalf("AcceptInvite: %v", err)
+	}
+	if err := s.AcceptInvite(ctx, th.ID, bob.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("AcceptInvite twice = %v, want ErrNotFound", err)
+	}
+	if ii, err := s.Invites(ctx, bob.ID); err != nil || len(ii) != 0 {
+		t.Errorf("Invites after accepting = %+v, %v; want none", ii, err)
+	}
+	mm, err := s.Moderators(ctx, th.ID)
+	if err != nil {
+		t.Fatalf("Moderators: %v", err)
+	}
+	if len(mm) != 2 || mm[0].Username != "alice" || mm[1].Username != "bob" {
+		t.Errorf("Moderators = %+v, want alice and bob", mm)
+	}
+
+	inv = goreddit.Invite{ThreadID: th.ID, UserID: carol.ID, InvitedByID: uuid.NullUUID{UUID: bob.ID, Valid: true}}
+	if err := s.CreateInvite(ctx, &inv); err != nil {
+		t.Fatalf("CreateInvite: %v", err)
+	}
+	if err := s.DeleteInvite(ctx, th.ID, carol.ID); err != nil {
+		t.Fatalf("DeleteInvite: %v", err)
+	}
+	if err := s.DeleteInvite(ctx, th.ID, carol.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("DeleteInvite twice = %v, want ErrNotFound", err)
+	}
+
+	if err := s.AddModerator(ctx, th.ID, carol.ID); err != nil {
+		t.Fatalf("AddModerator: %v", err)
+	}
+	if err := s.AddModerator(ctx, th.ID, carol.ID); err != nil {
+		t.Errorf("AddModerator twice = %v, want nil", err)
+	}
+	if err := s.AddModerator(ctx, uuid.New(), carol.ID); !errors.Is(err, goreddit.ErrInvalidReference) {
+		t.Errorf("AddModerator to unknown thread = %v, want ErrInvalidReference", err)
+	}
+	if err := s.RemoveModerator(ctx, th.ID, carol.ID); err != nil {
+		t.Fatalf("RemoveModerator: %v", err)
+	}
+	if err := s.RemoveModerator(ctx, th.ID, carol.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("RemoveModerator twice = %v, want ErrNotFound", err)
+	}
+
+	inv = goreddit.Invite{ThreadID: th.ID, UserID: carol.ID, InvitedByID: uuid.NullUUID{UUID: bob.ID, Valid: true}}
+	if err := s.CreateInvite(ctx, &inv); err != nil {
+		t.Fatalf("CreateInvite: %v", err)
+	}
+	if err := s.DeleteUser(ctx, bob.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if ok, _ := s.IsModerator(ctx, th.ID, bob.ID); ok {
+		t.Error("IsModerator after deleting the user = true, want false")
+	}
+	if got, err := s.Invite(ctx, th.ID, carol.ID); err != nil || got.InvitedByID.Valid || got.InvitedByUsername != "" {
+		t.Errorf("Invite after deleting the inviter = %+v, %v; want no inviter", got, err)
+	}
+
+	if err := s.DeleteThread(ctx, th.ID); err != nil {
+		t.Fatalf("DeleteThread: %v", err)
+	}
+	if mm, err := s.Moderators(ctx, th.ID); err != nil || len(mm) != 0 {
+		t.Errorf("Moderators after deleting the thread = %+v, %v; want none", mm, err)
+	}
+	if ii, err := s.Invites(ctx, carol.ID); err != nil || len(ii) != 0 {
+		t.Errorf("Invites after deleting the thread = %+v, %v; want none", ii, err)
+	}
+}
+
+func testModeration(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+	th := createThread(t, s, "Moderated")
+	kept := createPost(t, s, th.ID, "Kept", 2)
+	removed := createPost(t, s, th.ID, "Removed", 1)
+	pinned := createPost(t, s, th.ID, "Pinned", 0)
+	c := createComment(t, s, kept.ID, "Removed comment", 0)
+	reply := createReply(t, s, c, "Reply", 0)
+
+	if err := s.SetPostRemoved(ctx, removed.ID, true); err != nil {
+		t.Fatalf("SetPostRemoved: %v", err)
+	}
+	if err := s.SetPostLocked(ctx, kept.ID, true); err != nil {
+		t.Fatalf("SetPostLocked: %v", err)
+	}
+	if err := s.SetPostPinned(ctx, pinned.ID, true); err != nil {
+		t.Fatalf("SetPostPinned: %v", err)
+	}
+	if err := s.SetCommentRemoved(ctx, c.ID, true); err != nil {
+		t.Fatalf("SetCommentRemoved: %v", err)
+	}
+	if err := s.SetPostRemoved(ctx, uuid.New(), true); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("SetPostRemoved for unknown post = %v, want ErrNotFound", err)
+	}
+	if err := s.SetCommentRemoved(ctx, uuid.New(), true); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("SetCommentRemoved for unknown comment = %v, want ErrNotFound", err)
+	}
+
+	if p := mustPost(t, s, removed.ID); !p.Removed || p.Locked || p.Pinned {
+		t.Errorf("Post after SetPostRemoved = %+v, want only Removed", p)
+	}
+	if p := mustPost(t, s, kept.ID); !p.Locked || !p.UpdatedAt.Equal(kept.UpdatedAt) {
+		t.Errorf("Post after SetPostLocked = %+v, want Locked and UpdatedAt unchanged", p)
+	}
+	if got := mustComment(t, s, c.ID); !got.Removed {
+		t.Errorf("Comment after SetCommentRemoved = %+v, want Removed", got)
+	}
+
+	pp, _, err := s.PostsByThread(ctx, th.ID, top)
+	if err != nil {
+		t.Fatalf("PostsByThread: %v", err)
+	}
+	assertPostTitles(t, "PostsByThread", pp, "Kept", "Pinned")
+	pp, _, err = s.PostsByThread(ctx, th.ID, goreddit.ListOptions{Sort: goreddit.SortTop, IncludeRemoved: true})
+	if err != nil {
+		t.Fatalf("PostsByThread: %v", err)
+	}
+	assertPostTitles(t, "PostsByThread including removed posts", pp, "Kept", "Removed", "Pinned")
+	pp, _, err = s.Posts(ctx, top)
+	if err != nil {
+		t.Fatalf("Posts: %v", err)
+	}
+	assertPostTitles(t, "Posts", pp, "Kept", "Pinned")
+	pp, _, err = s.PostsByThreads(ctx, []uuid.UUID{th.ID}, top)
+	if err != nil {
+		t.Fatalf("PostsByThreads: %v", err)
+	}
+	assertPostTitles(t, "PostsByThreads", pp, "Kept", "Pinned")
+
+	tree, err := s.CommentTree(ctx, kept.ID, 5, goreddit.SortTop)
+	if err != nil {
+		t.Fatalf("CommentTree: %v", err)
+	}
+	if len(tree) != 1 || !tree[0].Removed || len(tree[0].Replies) != 1 || tree[0].Replies[0].ID != reply.ID {
+		t.Errorf("CommentTree = %+v, want the removed comment keeping its reply", tree)
+	}
+
+	rr, _, err := s.Search(ctx, goreddit.SearchOptions{Query: "removed"})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(rr) != 0 {
+		t.Errorf("Search for removed content = %+v, want no results", rr)
+	}
+
+	if err := s.SetPostPinned(ctx, removed.ID, true); err != nil {
+		t.Fatalf("SetPostPinned: %v", err)
+	}
+	pinnedPosts, err := s.PinnedPosts(ctx, th.ID)
+	if err != nil {
+		t.Fatalf("PinnedPosts: %v", err)
+	}
+	assertPostTitles(t, "PinnedPosts", pinnedPosts, "Pinned")
+	if err := s.SetPostPinned(ctx, pinned.ID, false); err != nil {
+		t.Fatalf("SetPostPinned: %v", err)
+	}
+	if pp, err := s.PinnedPosts(ctx, th.ID); err != nil || len(pp) != 0 {
+		t.Errorf("PinnedPosts after unpinning = %+v, %v; want none", pp, err)
+	}
+
+	if err := s.SetPostRemoved(ctx, removed.ID, false); err != nil {
+		t.Fatalf("SetPostRemoved: %v", err)
+	}
+	pp, _, err = s.PostsByThread(ctx, th.ID, top)
+	if err != nil {
+		t.Fatalf("PostsByThread: %v", err)
+	}
+	assertPostTitles(t, "PostsByThread after approving", pp, "Kept", "Removed", "Pinned")
+}
+
+func testPinLimit(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+	th := createThread(t, s, "Pins")
+	var pp []goreddit.Post
+	for i := 0; i < goreddit.MaxPinnedPosts+3; i++ {
+		pp = append(pp, createPost(t, s, th.ID, fmt.Sprintf("Post %d", i), 0))
+	}
+
+	if err := s.SetPostPinned(ctx, uuid.New(), true); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("SetPostPinned for unknown id = %v, want ErrNotFound", err)
+	}
+
+	// Removed posts keep their pin, so they count against the limit.
+	if err := s.SetPostRemoved(ctx, pp[0].ID, true); err != nil {
+		t.Fatalf("SetPostRemoved: %v", err)
+	}
+	for _, p := range pp[:goreddit.MaxPinnedPosts] {
+		if err := s.SetPostPinned(ctx, p.ID, true); err != nil {
+			t.Fatalf("SetPostPinned: %v", err)
+		}
+	}
+	if err := s.SetPostPinned(ctx, pp[goreddit.MaxPinnedPosts].ID, true); !errors.Is(err, goreddit.ErrPinLimit) {
+		t.Errorf("SetPostPinned beyond the limit = %v, want ErrPinLimit", err)
+	}
+	if err := s.SetPostPinned(ctx, pp[1].ID, true); err != nil {
+		t.Errorf("SetPostPinned for a pinned post at the limit = %v, want nil", err)
+	}
+
+	// Of posts pinned at the same time, only as many as fit are pinned.
+	if err := s.SetPostPinned(ctx, pp[0].ID, false); err != nil {
+		t.Fatalf("SetPostPinned: %v", err)
+	}
+	rest := pp[goreddit.MaxPinnedPosts:]
+	errs := make(chan error, len(rest))
+	for _, p := range rest {
+		go func(id uuid.UUID) { errs <- s.SetPostPinned(ctx, id, true) }(p.ID)
+	}
+	pinned := 0
+	for range rest {
+		switch err := <-errs; {
+		case err == nil:
+			pinned++
+		case !errors.Is(err, goreddit.ErrPinLimit):
+			t.Errorf("SetPostPinned = %v, want nil or ErrPinLimit", err)
+		}
+	}
+	if pinned != 1 {
+		t.Errorf("%d of %d concurrent pins succeeded with one pin left, want 1", pinned, len(rest))
+	}
+}
+
+func testReports(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	th := createThread(t, s, "Reported")
+	other := createThread(t, s, "Other")
+	p := createPost(t, s, th.ID, "Spam", 0)
+	c := createComment(t, s, p.ID, "Rude comment", 0)
+	elsewhere := createPost(t, s, other.ID, "Elsewhere", 0)
+
+	report := func(reporter goreddit.User, postID uuid.UUID, commentID uuid.NullUUID, reason goreddit.ReportReason) error {
+		r := goreddit.Report{
+			ID:         uuid.New(),
+			PostID:     postID,
+			CommentID:  commentID,
+			ReporterID: uuid.NullUUID{UUID: reporter.ID, Valid: true},
+			Reason:     reason,
+		}
+		return s.CreateReport(ctx, &r)
+	}
+	onComment := uuid.NullUUID{UUID: c.ID, Valid: true}
+
+	if err := report(alice, p.ID, uuid.NullUUID{}, goreddit.ReportSpam); err != nil {
+		t.Fatalf("CreateReport: %v", err)
+	}
+	if err := report(bob, p.ID, uuid.NullUUID{}, goreddit.ReportOffTopic); err != nil {
+		t.Fatalf("CreateReport: %v", err)
+	}
+	if err := report(alice, p.ID, onComment, goreddit.ReportHarassment); err != nil {
+		t.Fatalf("CreateReport on a comment: %v", err)
+	}
+	if err := report(alice, elsewhere.ID, uuid.NullUUID{}, goreddit.ReportSpam); err != nil {
+		t.Fatalf("CreateReport: %v", err)
+	}
+	if err := report(alice, p.ID, uuid.NullUUID{}, goreddit.ReportSpam); !errors.Is(err, goreddit.ErrConflict) {
+		t.Errorf("CreateReport twice = %v, want ErrConflict", err)
+	}
+	if err := report(alice, uuid.New(), uuid.NullUUID{}, goreddit.ReportSpam); !errors.Is(err, goreddit.ErrInvalidReference) {
+		t.Errorf("CreateReport for unknown post = %v, want ErrInvalidReference", err)
+	}
+
+	rr, err := s.Reports(ctx, th.ID)
+	if err != nil {
+		t.Fatalf("Reports: %v", err)
+	}
+	if len(rr) != 3 {
+		t.Fatalf("Reports = %+v, want the 3 reports of the thread", rr)
+	}
+	for _, r := range rr {
+		if r.ThreadID != th.ID || r.PostTitle != "Spam" || r.ReporterUsername == "" {
+			t.Errorf("Reports: %+v, want the thread, post title and reporter joined in", r)
+		}
+		if r.CommentID.Valid && (r.Content != "Rude comment" || r.TargetID() != c.ID) {
+			t.Errorf("Reports: comment report = %+v, want the comment's content", r)
+		}
+		if !r.CommentID.Valid && (r.Content != p.Content || r.TargetID() != p.ID) {
+			t.Errorf("Reports: post report = %+v, want the post's content", r)
+		}
+	}
+
+	counts, err := s.ReportCounts(ctx, th.ID)
+	if err != nil {
+		t.Fatalf("ReportCounts: %v", err)
+	}
+	if len(counts) != 2 || counts[p.ID] != 2 || counts[c.ID] != 1 {
+		t.Errorf("ReportCounts = %v, want 2 for the post and 1 for the comment", counts)
+	}
+
+	if err := s.DeletePostReports(ctx, p.ID); err != nil {
+		t.Fatalf("DeletePostReports: %v", err)
+	}
+	if counts, _ := s.ReportCounts(ctx, th.ID); len(counts) != 1 || counts[c.ID] != 1 {
+		t.Errorf("ReportCounts after DeletePostReports = %v, want only the comment's report", counts)
+	}
+	if err := s.DeleteCommentReports(ctx, c.ID); err != nil {
+		t.Fatalf("DeleteCommentReports: %v", err)
+	}
+	if rr, _ := s.Reports(ctx, th.ID); len(rr) != 0 {
+		t.Errorf("Reports after deleting them = %+v, want none", rr)
+	}
+
+	if err := s.DeleteUser(ctx, alice.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if rr, _ := s.Reports(ctx, other.ID); len(rr) != 1 || rr[0].ReporterID.Valid {
+		t.Errorf("Reports after deleting the reporter = %+v, want the report without a reporter", rr)
+	}
+	if err := s.DeletePost(ctx, elsewhere.ID); err != nil {
+		t.Fatalf("DeletePost: %v", err)
+	}
+	if rr, _ := s.Reports(ctx, other.ID); len(rr) != 0 {
+		t.Errorf("Reports after deleting the post = %+v, want none", rr)
+	}
+}
+
+func testModLog(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	th := createThread(t, s, "Logged")
+	other := createThread(t, s, "Other")
+	p := createPost(t, s, th.ID, "Spam", 0)
+	base := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	record := func(threadID uuid.UUID, action goreddit.ModAction, kind goreddit.ModTarget, targetID uuid.UUID, at time.Time) goreddit.ModLogEntry {
+		e := goreddit.ModLogEntry{
+			ID:            uuid.New(),
+			ThreadID:      uuid.NullUUID{UUID: threadID, Valid: true},
+			ActorID:       uuid.NullUUID{UUID: alice.ID, Valid: true},
+			ActorUsername: alice.Username,
+			Action:        action,
+			TargetKind:    kind,
+			TargetID:      targetID,
+			TargetLabel:   "label",
+			Reason:        "because",
+			CreatedAt:     at,
+		}
+		if err := s.CreateModLogEntry(ctx, &e); err != nil {
+			t.Fatalf("CreateModLogEntry: %v", err)
+		}
+		return e
+	}
+	removed := record(th.ID, goreddit.ModRemove, goreddit.ModTargetPost, p.ID, base)
+	approved := record(th.ID, goreddit.ModApprove, goreddit.ModTargetPost, p.ID, base.Add(time.Minute))
+	edited := record(other.ID, goreddit.ModEditThread, goreddit.ModTargetThread, other.ID, base.Add(2*time.Minute))
+
+	dup := removed
+	if err := s.CreateModLogEntry(ctx, &dup); !errors.Is(err, goreddit.ErrConflict) {
+		t.Errorf("CreateModLogEntry with a used ID = %v, want ErrConflict", err)
+	}
+
+	ids := func(ee []goreddit.ModLogEntry) []string {
+		var ss []string
+		for _, e := range ee {
+			ss = append(ss, e.ID.String())
+		}
+		return ss
+	}
+	list := func(opts goreddit.ModLogOptions, want ...goreddit.ModLogEntry) goreddit.Page {
+		t.Helper()
+		ee, page, err := s.ModLog(ctx, opts)
+		if err != nil {
+			t.Fatalf("ModLog(%+v): %v", opts, err)
+		}
+		if !equal(ids(ee), ids(want)) {
+			t.Errorf("ModLog(%+v) = %v, want %v", opts, ids(ee), ids(want))
+		}
+		return page
+	}
+
+	list(goreddit.ModLogOptions{}, edited, approved, removed)
+	list(goreddit.ModLogOptions{ThreadID: uuid.NullUUID{UUID: th.ID, Valid: true}}, approved, removed)
+	list(goreddit.ModLogOptions{Action: goreddit.ModRemove}, removed)
+	list(goreddit.ModLogOptions{Target: goreddit.ModTargetThread}, edited)
+	list(goreddit.ModLogOptions{Actor: "alice"}, edited, approved, removed)
+	list(goreddit.ModLogOptions{Actor: "bob"})
+
+	page := list(goreddit.ModLogOptions{PageOptions: goreddit.PageOptions{Limit: 2}}, edited, approved)
+	if page.Next == "" {
+		t.Fatalf("ModLog page 1 has no next page")
+	}
+	list(goreddit.ModLogOptions{PageOptions: goreddit.PageOptions{Limit: 2, Cursor: page.Next}}, removed)
+
+	// Entries outlive the users and content they name.
+	if err := s.DeleteThread(ctx, th.ID); err != nil {
+		t.Fatalf("DeleteThread: %v", err)
+	}
+	if err := s.DeleteUser(ctx, alice.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	ee, _, err := s.ModLog(ctx, goreddit.ModLogOptions{ThreadID: uuid.NullUUID{UUID: th.ID, Valid: true}})
+	if err != nil {
+		t.Fatalf("ModLog: %v", err)
+	}
+	if len(ee) != 2 || ee[1].ActorUsername != "alice" || ee[1].Reason != "because" || !ee[1].CreatedAt.Equal(base) {
+		t.Errorf("ModLog after deleting the thread and actor = %+v, want both entries unchanged", ee)
+	}
+}
+
+func testBans(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	th := createThread(t, s, "Banned")
+	other := createThread(t, s, "Other")
+	inThread := uuid.NullUUID{UUID: th.ID, Valid: true}
+	inOther := uuid.NullUUID{UUID: other.ID, Valid: true}
+	siteWide := uuid.NullUUID{}
+
+	ban := func(threadID uuid.NullUUID, user goreddit.User, expiresIn time.Duration) goreddit.Ban {
+		t.Helper()
+		b := goreddit.Ban{
+			ID:         uuid.New(),
+			ThreadID:   threadID,
+			UserID:     user.ID,
+			Reason:     "trolling",
+			BannedByID: uuid.NullUUID{UUID: alice.ID, Valid: true},
+		}
+		if expiresIn != 0 {
+			b.ExpiresAt = sql.NullTime{Time: time.Now().Add(expiresIn), Valid: true}
+		}
+		if err := s.CreateBan(ctx, &b); err != nil {
+			t.Fatalf("CreateBan: %v", err)
+		}
+		return b
+	}
+	active := func(user goreddit.User, threadID uuid.NullUUID) (goreddit.Ban, bool) {
+		t.Helper()
+		b, err := s.ActiveBan(ctx, user.ID, threadID)
+		if errors.Is(err, goreddit.ErrNotFound) {
+			return goreddit.Ban{}, false
+		}
+		if err != nil {
+			t.Fatalf("ActiveBan: %v", err)
+		}
+		return b, true
+	}
+
+	expired := ban(inThread, bob, -time.Hour)
+	if _, ok := active(bob, inThread); ok {
+		t.Error("ActiveBan with only an expired ban = banned, want not banned")
+	}
+
+	week := ban(inThread, bob, 7*24*time.Hour)
+	if week.Username != "bob" || week.BannedByUsername != "alice" || week.ThreadTitle != "Banned" {
+		t.Errorf("CreateBan = %+v, want the names joined in", week)
+	}
+	if b, ok := active(bob, inThread); !ok || b.ID != week.ID {
+		t.Errorf("ActiveBan in the thread = %+v, %v, want the week-long ban", b, ok)
+	}
+	if _, ok := active(bob, inOther); ok {
+		t.Error("ActiveBan in another thread = banned, want not banned")
+	}
+	if _, ok := active(bob, siteWide); ok {
+		t.Error("ActiveBan site-wide with a thread ban = banned, want not banned")
+	}
+
+	day := ban(siteWide, bob, 24*time.Hour)
+	if b, ok := active(bob, inOther); !ok || b.ID != day.ID {
+		t.Errorf("ActiveBan in another thread = %+v, %v, want the site-wide ban", b, ok)
+	}
+	if b, ok := active(bob, inThread); !ok || b.ID != week.ID {
+		t.Errorf("ActiveBan in the thread = %+v, %v, want the longer thread ban", b, ok)
+	}
+	permanent := ban(siteWide, bob, 0)
+	if b, ok := active(bob, inThread); !ok || b.ID != permanent.ID || b.ExpiresAt.Valid {
+		t.Errorf("ActiveBan in the thread = %+v, %v, want the permanent ban", b, ok)
+	}
+	if _, ok := active(alice, inThread); ok {
+		t.Error("ActiveBan for another user = banned, want not banned")
+	}
+
+	bb, err := s.Bans(ctx, inThread)
+	if err != nil {
+		t.Fatalf("Bans: %v", err)
+	}
+	if len(bb) != 1 || bb[0].ID != week.ID {
+		t.Errorf("Bans in the thread = %+v, want only the ban in effect", bb)
+	}
+	if bb, _ := s.Bans(ctx, siteWide); len(bb) != 2 {
+		t.Errorf("Bans site-wide = %+v, want 2", bb)
+	}
+
+	if err := s.LiftBan(ctx, permanent.ID); err != nil {
+		t.Fatalf("LiftBan: %v", err)
+	}
+	if err := s.LiftBan(ctx, permanent.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("LiftBan twice = %v, want ErrNotFound", err)
+	}
+	if err := s.LiftBan(ctx, expired.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("LiftBan of an expired ban = %v, want ErrNotFound", err)
+	}
+	if b, err := s.Ban(ctx, permanent.ID); err != nil || !b.ExpiresAt.Valid {
+		t.Errorf("Ban after LiftBan = %+v, %v, want it kept with an expiry", b, err)
+	}
+	if b, ok := active(bob, inOther); !ok || b.ID != day.ID {
+		t.Errorf("ActiveBan after lifting the permanent ban = %+v, %v, want the day-long ban", b, ok)
+	}
+
+	if err := s.DeleteThread(ctx, th.ID); err != nil {
+		t.Fatalf("DeleteThread: %v", err)
+	}
+	if _, err := s.Ban(ctx, week.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("Ban after deleting its thread = %v, want ErrNotFound", err)
+	}
+	if err := s.DeleteUser(ctx, alice.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if b, err := s.Ban(ctx, day.ID); err != nil || b.BannedByID.Valid {
+		t.Errorf("Ban after deleting the banning user = %+v, %v, want it kept without them", b, err)
+	}
+	if err := s.DeleteUser(ctx, bob.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if _, err := s.Ban(ctx, day.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("Ban after deleting the banned user = %v, want ErrNotFound", err)
+	}
+}
+
+func testStats(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+	alice := createUser(t, s, "alice")
+	createUser(t, s, "bob")
+	th := createThread(t, s, "Alpha")
+	p := createPost(t, s, th.ID, "Post", 0)
+	removed := createPost(t, s, th.ID, "Removed", 0)
+	c := createComment(t, s, p.ID, "Comment", 0)
+	createComment(t, s, p.ID, "Another", 0)
+	if err := s.SetPostRemoved(ctx, removed.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetCommentRemoved(ctx, c.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	r := goreddit.Report{ID: uuid.New(), PostID: p.ID, Reason: goreddit.ReportSpam}
+	if err := s.CreateReport(ctx, &r); err != nil {
+		t.Fatal(err)
+	}
+	expired := sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true}
+	for _, b := range []goreddit.Ban{
+		{ID: uuid.New(), UserID: alice.ID},
+		{ID: uuid.New(), UserID: alice.ID, ThreadID: uuid.NullUUID{UUID: th.ID, Valid: true}, ExpiresAt: expired},
+	} {
+		b := b
+		if err := s.CreateBan(ctx, &b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := s.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	want := goreddit.Stats{Users: 2, Threads: 1, Posts: 2, RemovedPosts: 1, Comments: 2, RemovedComments: 1, Reports: 1, Bans: 1}
+	if got != want {
+		t.Errorf("Stats = %+v, want %+v", got, want)
+	}
+}
+
+func testCascadeDelete(t *testing.T, s goreddit.Store) {
+	ctx := context.Background()
+	th := createThread(t, s, "Doomed")
+	keep := createThread(t, s, "Kept")
+	p := createPost(t, s, th.ID, "Doomed post", 0)
+	kept := createPost(t, s, keep.ID, "Kept post", 0)
+	c := createComment(t, s, p.ID, "Doomed comment", 0)
+	createComment(t, s, kept.ID, "Kept comment", 0)
+
+	if err := s.DeleteThread(ctx, th.ID); err != nil {
+		t.Fatalf("DeleteThread: %v", err)
+	}
+	if _, err := s.Post(ctx, p.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("Post after deleting its thread = %v, want ErrNotFound", err)
+	}
+	if _, err := s.Comment(ctx, c.ID); !errors.Is(err, goreddit.ErrNotFound) {
+		t.Errorf("Comment after deleting its thread = %v, want ErrNotFound", err)
+	}
+
+	pp, _, err := s.Posts(ctx, top)
+	if err != nil {
+		t.Fatalf("Posts: %v", err)
+	}
+	assertPostTitles(t, "Posts", pp, "Kept post")
+	if pp[0].CommentsCount != 1 {
+		t.Errorf("Posts: kept post CommentsCount = %d, want 1", pp[0].CommentsCount)
+	}
+}
+
+func createThread(t *testing.T, s goreddit.Store, title string) goreddit.Thread {
+	t.Helper()
+	ctx := context.Background()
+	th := goreddit.Thread{ID: uuid.New(), Title: title, Description: title + " description"}
+	if err := s.CreateThread(ctx, &th); err != nil {
+		t.Fatalf("CreateThread: %v", err)
+	}
+	return th
+}
+
+func createPost(t *testing.T, s goreddit.Store, threadID uuid.UUID, title string, votes int) goreddit.Post {
+	t.Helper()
+	ctx := context.Background()
+	p := goreddit.Post{ID: uuid.New(), ThreadID: threadID, Title: title, Content: title + " content", Votes: votes}
+	if err := s.CreatePost(ctx, &p); err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	return p
+}
+
+func createPostAt(t *testing.T, s goreddit.Store, threadID uuid.UUID, title string, votes int, createdAt time.Time) goreddit.Post {
+	t.Helper()
+	ctx := context.Background()
+	p := goreddit.Post{ID: uuid.New(), ThreadID: threadID, Title: title, Content: title + " content", Votes: votes, CreatedAt: createdAt}
+	if err := s.CreatePost(ctx, &p); err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	return p
+}
+
+func createComment(t *testing.T, s goreddit.Store, postID uuid.UUID, content string, votes int) goreddit.Comment {
+	t.Helper()
+	ctx := context.Background()
+	c := goreddit.Comment{ID: uuid.New(), PostID: postID, Content: content, Votes: votes}
+	if err := s.CreateComment(ctx, &c); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	return c
+}
+
+func createCommentAt(t *testing.T, s goreddit.Store, postID uuid.UUID, content string, votes int, createdAt time.Time) goreddit.Comment {
+	t.Helper()
+	ctx := context.Background()
+	c := goreddit.Comment{ID: uuid.New(), PostID: postID, Content: content, Votes: votes, CreatedAt: createdAt}
+	if err := s.CreateComment(ctx, &c); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	return c
+}
+
+func createReply(t *testing.T, s goreddit.Store, parent goreddit.Comment, content string, votes int) goreddit.Comment {
+	t.Helper()
+	ctx := context.Background()
+	c := goreddit.Comment{
+		ID:       uuid.New(),
+		PostID:   parent.PostID,
+		ParentID: uuid.NullUUID{UUID: parent.ID, Valid: true},
+		Content:  content,
+		Votes:    votes,
+	}
+	if err := s.CreateComment(ctx, &c); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	return c
+}
+
+func createUser(t *testing.T, s goreddit.Store, username string) goreddit.User {
+	t.Helper()
+	ctx := context.Background()
+	u := goreddit.User{ID: uuid.New(), Username: username, Password: "hashed-" + username}
+	if err := s.CreateUser(ctx, &u); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return u
+}
+
+func mustThread(t *testing.T, s goreddit.Store, id uuid.UUID) goreddit.Thread {
+	t.Helper()
+	ctx := context.Background()
+	th, err := s.Thread(ctx, id)
+	if err != nil {
+		t.Fatalf("Thread: %v", err)
+	}
+	return th
+}
+
+func mustPost(t *testing.T, s goreddit.Store, id uuid.UUID) goreddit.Post {
+	t.Helper()
+	ctx := context.Background()
+	p, err := s.Post(ctx, id)
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	return p
+}
+
+func mustComment(t *testing.T, s goreddit.Store, id uuid.UUID) goreddit.Comment {
+	t.Helper()
+	ctx := context.Background()
+	c, err := s.Comment(ctx, id)
+	if err != nil {
+		t.Fatalf("Comment: %v", err)
+	}
+	return c
+}
+
+func sameThread(a, b goreddit.Thread) bool {
+	return a.ID == b.ID && a.Title == b.Title && a.Description == b.Description &&
+		a.AuthorID == b.AuthorID && a.AuthorUsername == b.AuthorUsername &&
+		a.CreatedAt.Equal(b.CreatedAt) && a.UpdatedAt.Equal(b.UpdatedAt)
+}
+
+func sameUser(a, b goreddit.User) bool {
+	return a.ID == b.ID && a.Username == b.Username && a.Password == b.Password && a.Role == b.Role &&
+		a.CreatedAt.Equal(b.CreatedAt) && a.UpdatedAt.Equal(b.UpdatedAt)
+}
+
+func containsThread(tt []goreddit.Thread, id uuid.UUID) bool {
+	for _, t := range tt {
+		if t.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func assertPostTitles(t *testing.T, method string, pp []goreddit.Post, titles ...string) {
+	t.Helper()
+	got := make([]string, len(pp))
+	for i, p := range pp {
+		got[i] = p.Title
+	}
+	if !equal(got, titles) {
+		t.Fatalf("%s = %q, want %q", method, got, titles)
+	}
+}
+
+func assertCommentContents(t *testing.T, cc []goreddit.Comment, contents ...string) {
+	t.Helper()
+	got := make([]string, len(cc))
+	for i, c := range cc {
+		got[i] = c.Content
+	}
+	if !equal(got, contents) {
+		t.Fatalf("comments = %q, want %q", got, contents)
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package goreddit
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// Scope limits what a personal access token may be used for.
+type Scope string
+
+const (
+	// ScopeRead allows reading threads, posts, comments and users.
+	ScopeRead Scope = "read"
+	// ScopeWrite allows creating, editing and deleting content.
+	ScopeWrite Scope = "write"
+	// ScopeVote allows voting on posts and comments.
+	ScopeVote Scope = "vote"
+	// ScopeModerate allows moderating the threads the user moderates.
+	ScopeModerate Scope = "moderate"
+)
+
+// AllScopes lists every valid Scope.
+var AllScopes = []Scope{ScopeRead, ScopeWrite, ScopeVote, ScopeModerate}
+
+func (s Scope) Valid() bool {
+	for _, v := range AllScopes {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Scopes is a set of scopes. It is stored as a comma-separated list.
+type Scopes []Scope
+
+// Has reports whether s includes scope.
+func (s Scopes) Has(scope Scope) bool {
+	for _, v := range s {
+		if v == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Scopes) String() string {
+	names := make([]string, len(s))
+	for i, scope := range s {
+		names[i] = string(scope)
+	}
+	return strings.Join(names, ",")
+}
+
+// Value implements driver.Valuer.
+func (s Scopes) Value() (driver.Value, error) {
+	return s.String(), nil
+}
+
+// Scan implements sql.Scanner.
+func (s *Scopes) Scan(src interface{}) error {
+	var list string
+	switch src := src.(type) {
+	case string:
+		list = src
+	case []byte:
+		list = string(src)
+	default:
+		return fmt.Errorf("cannot scan %T into Scopes", src)
+	}
+
+	*s = Scopes{}
+	for _, name := range strings.Split(list, ",") {
+		if name != "" {
+			*s = append(*s, Scope(name))
+		}
+	}
+	return nil
+}
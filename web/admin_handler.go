@@ -0,0 +1,357 @@
+package web
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/aleury/goreddit"
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/csrf"
+)
+
+// adminRecent is how many of the newest users, posts and comments the admin
+// dashboard shows.
+const adminRecent = 5
+
+// AdminHandler serves the admin area. Its routes are only reachable by admins.
+type AdminHandler struct {
+	store    goreddit.Store
+	sessions *scs.SessionManager
+	pageSize int
+}
+
+// recentContent lists the newest posts or comments, including removed ones,
+// as admins see them.
+func recentContent(opts goreddit.PageOptions) goreddit.ListOptions {
+	return goreddit.ListOptions{Sort: goreddit.SortNew, IncludeRemoved: true, PageOptions: opts}
+}
+
+func (h *AdminHandler) Dashboard() http.HandlerFunc {
+	type data struct {
+		SessionData
+
+		Stats    goreddit.Stats
+		Users    []goreddit.User
+		Posts    []goreddit.Post
+		Comments []goreddit.Comment
+	}
+
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"admin.html",
+		"admin_nav.html",
+	))
+	return func(rw http.ResponseWriter, r *http.Request) {
+		stats, err := h.store.Stats(r.Context())
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		recent := goreddit.PageOptions{Limit: adminRecent}
+		uu, _, err := h.store.Users(r.Context(), recent)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		pp, _, err := h.store.Posts(r.Context(), recentContent(recent))
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		cc, _, err := h.store.Comments(r.Context(), recentContent(recent))
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		tmpl.Execute(rw, data{
+			Stats:       stats,
+			Users:       uu,
+			Posts:       pp,
+			Comments:    cc,
+			SessionData: GetSessionData(h.sessions, r.Context()),
+		})
+	}
+}
+
+func (h *AdminHandler) Users() http.HandlerFunc {
+	type data struct {
+		SessionData
+
+		CSRF  template.HTML
+		Users []goreddit.User
+		Pager pager
+	}
+
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"admin_users.html",
+		"admin_nav.html",
+		"pager.html",
+	))
+	return func(rw http.ResponseWriter, r *http.Request) {
+		uu, page, err := h.store.Users(r.Context(), pageOptions(r, h.pageSize))
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		tmpl.Execute(rw, data{
+			Users:       uu,
+			Pager:       pageLinks(r, page),
+			CSRF:        csrf.TemplateField(r),
+			SessionData: GetSessionData(h.sessions, r.Context()),
+		})
+	}
+}
+
+func (h *AdminHandler) Promote() http.HandlerFunc {
+	return h.setRole(goreddit.RoleAdmin, goreddit.ModPromoteAdmin, " is now an admin.")
+}
+
+func (h *AdminHandler) Demote() http.HandlerFunc {
+	return h.setRole(goreddit.RoleUser, goreddit.ModDemoteAdmin, " is no longer an admin.")
+}
+
+// setRole returns a handler that gives the user named by the id URL parameter
+// the role, records action in the mod log and flashes the user's name followed
+// by done. Admins cannot demote themselves, so that the site keeps an admin.
+func (h *AdminHandler) setRole(role goreddit.Role, action goreddit.ModAction, done string) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		u, err := h.store.User(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		admin, _ := userFromContext(r.Context())
+		if u.ID == admin.ID {
+			h.sessions.Put(r.Context(), "flash", "You cannot change your own role.")
+			http.Redirect(rw, r, "/admin/users", http.StatusFound)
+			return
+		}
+
+		if u.Role != role {
+			err = h.store.SetUserRole(r.Context(), u.ID, role)
+			if err != nil {
+				httpError(rw, r, err)
+				return
+			}
+
+			if err := logModAction(r.Context(), h.store, siteUserEntry(action, u)); err != nil {
+				httpError(rw, r, err)
+				return
+			}
+		}
+
+		h.sessions.Put(r.Context(), "flash", u.Username+done)
+
+		http.Redirect(rw, r, "/admin/users", http.StatusFound)
+	}
+}
+
+func (h *AdminHandler) Threads() http.HandlerFunc {
+	type data struct {
+		SessionData
+
+		CSRF    template.HTML
+		Threads []goreddit.Thread
+		Pager   pager
+	}
+
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"admin_threads.html",
+		"admin_nav.html",
+		"pager.html",
+	))
+	return func(rw http.ResponseWriter, r *http.Request) {
+		tt, page, err := h.store.Threads(r.Context(), pageOptions(r, h.pageSize))
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		tmpl.Execute(rw, data{
+			Threads:     tt,
+			Pager:       pageLinks(r, page),
+			CSRF:        csrf.TemplateField(r),
+			SessionData: GetSessionData(h.sessions, r.Context()),
+		})
+	}
+}
+
+func (h *AdminHandler) Posts() http.HandlerFunc {
+	type data struct {
+		SessionData
+
+		CSRF  template.HTML
+		Posts []goreddit.Post
+		Pager pager
+	}
+
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"admin_posts.html",
+		"admin_nav.html",
+		"pager.html",
+	))
+	return func(rw http.ResponseWriter, r *http.Request) {
+		pp, page, err := h.store.Posts(r.Context(), recentContent(pageOptions(r, h.pageSize)))
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		tmpl.Execute(rw, data{
+			Posts:       pp,
+			Pager:       pageLinks(r, page),
+			CSRF:        csrf.TemplateField(r),
+			SessionData: GetSessionData(h.sessions, r.Context()),
+		})
+	}
+}
+
+func (h *AdminHandler) Comments() http.HandlerFunc {
+	type data struct {
+		SessionData
+
+		CSRF     template.HTML
+		Comments []goreddit.Comment
+		Pager    pager
+	}
+
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"admin_comments.html",
+		"admin_nav.html",
+		"pager.html",
+	))
+	return func(rw http.ResponseWriter, r *http.Request) {
+		cc, page, err := h.store.Comments(r.Context(), recentContent(pageOptions(r, h.pageSize)))
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		tmpl.Execute(rw, data{
+			Comments:    cc,
+			Pager:       pageLinks(r, page),
+			CSRF:        csrf.TemplateField(r),
+			SessionData: GetSessionData(h.sessions, r.Context()),
+		})
+	}
+}
+
+// Bans lists the users banned from the whole site.
+func (h *AdminHandler) Bans() http.HandlerFunc {
+	type data struct {
+		SessionData
+
+		CSRF      template.HTML
+		Bans      []goreddit.Ban
+		Durations []banDuration
+		// Username fills in the form when following a link to ban a user.
+		Username string
+	}
+
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"admin_bans.html",
+		"admin_nav.html",
+	))
+	return func(rw http.ResponseWriter, r *http.Request) {
+		bb, err := h.store.Bans(r.Context(), uuid.NullUUID{})
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		tmpl.Execute(rw, data{
+			Bans:        bb,
+			Durations:   banDurations,
+			Username:    r.URL.Query().Get("username"),
+			CSRF:        csrf.TemplateField(r),
+			SessionData: GetSessionData(h.sessions, r.Context()),
+		})
+	}
+}
+
+func (h *AdminHandler) CreateBan() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		form := BanForm{
+			Username: r.FormValue("username"),
+			Duration: r.FormValue("duration"),
+			Reason:   strings.TrimSpace(r.FormValue("reason")),
+		}
+		banned, err := checkBan(r.Context(), h.store, &form, uuid.NullUUID{})
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+		if !form.Validate() {
+			h.sessions.Put(r.Context(), "form", form)
+			http.Redirect(rw, r, "/admin/bans", http.StatusFound)
+			return
+		}
+
+		err = createBan(r.Context(), h.store, form, uuid.NullUUID{}, siteUserEntry(goreddit.ModBan, banned))
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", banned.Username+" has been banned from the site.")
+
+		http.Redirect(rw, r, "/admin/bans", http.StatusFound)
+	}
+}
+
+// LiftBan ends a site-wide ban before it expires.
+func (h *AdminHandler) LiftBan() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		b, err := h.store.Ban(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+		if b.ThreadID.Valid {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		err = h.store.LiftBan(r.Context(), b.ID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		unbanned := goreddit.User{ID: b.UserID, Username: b.Username}
+		if err := logModAction(r.Context(), h.store, siteUserEntry(goreddit.ModUnban, unbanned)); err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", b.Username+" is no longer banned from the site.")
+
+		http.Redirect(rw, r, "/admin/bans", http.StatusFound)
+	}
+}
@@ -0,0 +1,324 @@
+package web
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aleury/goreddit"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/csrf"
+)
+
+// maxRequestBody limits the size of JSON request bodies.
+const maxRequestBody = 1 << 20
+
+// APIHandler serves the JSON API mounted at /api/v1. Successful responses
+// wrap their payload in {"data": ...}, lists add {"pagination": ...}, and
+// failures respond with {"error": ...}.
+type APIHandler struct {
+	store    goreddit.Store
+	policy   *Policy
+	pageSize int
+}
+
+type apiAuthor struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+}
+
+type apiThread struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Subscribers int        `json:"subscribers"`
+	Author      *apiAuthor `json:"author"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type apiPost struct {
+	ID            uuid.UUID  `json:"id"`
+	ThreadID      uuid.UUID  `json:"thread_id"`
+	ThreadTitle   string     `json:"thread_title,omitempty"`
+	Title         string     `json:"title"`
+	Content       string     `json:"content"`
+	Votes         int        `json:"votes"`
+	Upvotes       int        `json:"upvotes"`
+	Downvotes     int        `json:"downvotes"`
+	CommentsCount int        `json:"comments_count"`
+	Removed       bool       `json:"removed"`
+	Locked        bool       `json:"locked"`
+	Pinned        bool       `json:"pinned"`
+	Author        *apiAuthor `json:"author"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type apiComment struct {
+	ID        uuid.UUID  `json:"id"`
+	PostID    uuid.UUID  `json:"post_id"`
+	ParentID  *uuid.UUID `json:"parent_id"`
+	Content   string     `json:"content"`
+	Votes     int        `json:"votes"`
+	Upvotes   int        `json:"upvotes"`
+	Downvotes int        `json:"downvotes"`
+	Removed   bool       `json:"removed"`
+	Author    *apiAuthor `json:"author"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type apiModerator struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type apiUser struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type apiPagination struct {
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Limit int    `json:"limit"`
+}
+
+type apiError struct {
+	Status  int               `json:"status"`
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// author returns the author of content, or nil if the author's account has
+// been deleted.
+func author(id uuid.NullUUID, username string) *apiAuthor {
+	if !id.Valid {
+		return nil
+	}
+	return &apiAuthor{ID: id.UUID, Username: username}
+}
+
+func newAPIThread(t goreddit.Thread) apiThread {
+	return apiThread{
+		ID:          t.ID,
+		Title:       t.Title,
+		Description: t.Description,
+		Subscribers: t.SubscribersCount,
+		Author:      author(t.AuthorID, t.AuthorUsername),
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+	}
+}
+
+func newAPIPost(p goreddit.Post) apiPost {
+	return apiPost{
+		ID:            p.ID,
+		ThreadID:      p.ThreadID,
+		ThreadTitle:   p.ThreadTitle,
+		Title:         p.Title,
+		Content:       p.Content,
+		Votes:         p.Votes,
+		Upvotes:       p.Upvotes,
+		Downvotes:     p.Downvotes,
+		CommentsCount: p.CommentsCount,
+		Removed:       p.Removed,
+		Locked:        p.Locked,
+		Pinned:        p.Pinned,
+		Author:        author(p.AuthorID, p.AuthorUsername),
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+	}
+}
+
+func newAPIComment(c goreddit.Comment) apiComment {
+	ac := apiComment{
+		ID:        c.ID,
+		PostID:    c.PostID,
+		Content:   c.Content,
+		Votes:     c.Votes,
+		Upvotes:   c.Upvotes,
+		Downvotes: c.Downvotes,
+		Removed:   c.Removed,
+		Author:    author(c.AuthorID, c.AuthorUsername),
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+	if c.ParentID.Valid {
+		ac.ParentID = &c.ParentID.UUID
+	}
+	return ac
+}
+
+// redactPost blanks the content of a removed post unless can moderates its
+// thread.
+func redactPost(p goreddit.Post, can Permissions) goreddit.Post {
+	if p.Removed && !can.Moderate(p.ThreadID) {
+		p.Content = ""
+	}
+	return p
+}
+
+// redactComment blanks the content of a removed comment on a post of the
+// thread unless can moderates the thread.
+func redactComment(c goreddit.Comment, threadID uuid.UUID, can Permissions) goreddit.Comment {
+	if c.Removed && !can.Moderate(threadID) {
+		c.Content = ""
+	}
+	return c
+}
+
+func newAPIModerator(m goreddit.Moderator) apiModerator {
+	return apiModerator{ID: m.UserID, Username: m.Username, CreatedAt: m.CreatedAt}
+}
+
+func newAPIUser(u goreddit.User) apiUser {
+	return apiUser{ID: u.ID, Username: u.Username, CreatedAt: u.CreatedAt}
+}
+
+// writeJSON responds with v encoded as JSON.
+func writeJSON(rw http.ResponseWriter, status int, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	if err := json.NewEncoder(rw).Encode(v); err != nil {
+		log.Printf("error encoding response: %v", err)
+	}
+}
+
+// writeData responds with data in a {"data": ...} envelope.
+func writeData(rw http.ResponseWriter, status int, data interface{}) {
+	writeJSON(rw, status, map[string]interface{}{"data": data})
+}
+
+// writePage responds with a page of a listing and the cursors around it.
+func writePage(rw http.ResponseWriter, data interface{}, page goreddit.Page, limit int) {
+	writeJSON(rw, http.StatusOK, map[string]interface{}{
+		"data": data,
+		"pagination": apiPagination{
+			Next:  page.Next,
+			Prev:  page.Prev,
+			Limit: limit,
+		},
+	})
+}
+
+// writeAPIError responds with an error envelope for status. fields holds
+// validation errors keyed by request field.
+func writeAPIError(rw http.ResponseWriter, status int, fields map[string]string) {
+	text := http.StatusText(status)
+	writeJSON(rw, status, map[string]interface{}{
+		"error": apiError{
+			Status:  status,
+			Code:    strings.ReplaceAll(strings.ToLower(text), " ", "_"),
+			Message: text,
+			Fields:  fields,
+		},
+	})
+}
+
+// apiFail responds to a failed store call with the error envelope matching
+// the error. Internal errors are logged rather than shown to the client.
+func apiFail(rw http.ResponseWriter, r *http.Request, err error) {
+	status := statusFor(err)
+	if status == http.StatusInternalServerError {
+		log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+	}
+	writeAPIError(rw, status, nil)
+}
+
+// apiInvalid responds that a request failed validation with the errors of a
+// form, keyed by the lowercase name of each field.
+func apiInvalid(rw http.ResponseWriter, errs FormErrors) {
+	fields := make(map[string]string, len(errs))
+	for name, msg := range errs {
+		fields[strings.ToLower(name)] = msg
+	}
+	writeAPIError(rw, http.StatusUnprocessableEntity, fields)
+}
+
+// decodeJSON decodes the JSON request body into v, responding with a bad
+// request error if it cannot.
+func decodeJSON(rw http.ResponseWriter, r *http.Request, v interface{}) bool {
+	dec := json.NewDecoder(io.LimitReader(r.Body, maxRequestBody))
+	if err := dec.Decode(v); err != nil {
+		writeAPIError(rw, http.StatusBadRequest, nil)
+		return false
+	}
+	return true
+}
+
+// urlID parses the UUID in the URL parameter key, responding with a not
+// found error if it is malformed.
+func urlID(rw http.ResponseWriter, r *http.Request, key string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, key))
+	if err != nil {
+		writeAPIError(rw, http.StatusNotFound, nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// requireAPIUser responds with an unauthorized error to anonymous requests.
+func requireAPIUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if _, ok := userFromContext(r.Context()); !ok {
+			writeAPIError(rw, http.StatusUnauthorized, nil)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// withCSRFToken hands cookie-authenticated API clients the token they must
+// send in the X-CSRF-Token header of unsafe requests.
+func withCSRFToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if !hasBearerToken(r) {
+			rw.Header().Set("X-CSRF-Token", csrf.Token(r))
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// hasBearerToken reports whether r carries an Authorization bearer token.
+// Browsers only send such headers when a script sets them, so requests with
+// one cannot be forged across sites and need no CSRF protection.
+func hasBearerToken(r *http.Request) bool {
+	parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+	return len(parts) == 2 && strings.EqualFold(parts[0], "Bearer")
+}
+
+// skipCSRFForTokens exempts requests with a bearer token from CSRF checks.
+// It must run before csrf.Protect.
+func skipCSRFForTokens(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if hasBearerToken(r) {
+			r = csrf.UnsafeSkipCheck(r)
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// csrfFailure responds to requests that failed the CSRF check.
+func csrfFailure(rw http.ResponseWriter, r *http.Request) {
+	if isAPIRequest(r) {
+		writeAPIError(rw, http.StatusForbidden, map[string]string{
+			"csrf": csrf.FailureReason(r).Error(),
+		})
+		return
+	}
+	renderError(rw, r, http.StatusForbidden)
+}
+
+func isAPIRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/api/")
+}
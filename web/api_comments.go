@@ -0,0 +1,214 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+)
+
+func (h *APIHandler) ListComments() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, ok := urlID(rw, r, "id")
+		if !ok {
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), id)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		opts := listOptions(r, goreddit.SortTop, h.pageSize)
+		cc, page, err := h.store.CommentsbyPost(r.Context(), p.ID, opts)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		can := h.policy.For(r.Context())
+		data := make([]apiComment, len(cc))
+		for i, c := range cc {
+			data[i] = newAPIComment(redactComment(c, p.ThreadID, can))
+		}
+		writePage(rw, data, page, opts.PageSize())
+	}
+}
+
+func (h *APIHandler) ShowComment() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, ok := urlID(rw, r, "id")
+		if !ok {
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), c.PostID)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		writeData(rw, http.StatusOK, newAPIComment(redactComment(c, p.ThreadID, h.policy.For(r.Context()))))
+	}
+}
+
+func (h *APIHandler) CreateComment() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, ok := urlID(rw, r, "id")
+		if !ok {
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), id)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		can := h.policy.For(r.Context())
+		if ban := can.Ban(p.ThreadID); ban != nil {
+			apiBanned(rw, *ban)
+			return
+		}
+		if !can.Comment(p) {
+			writeAPIError(rw, http.StatusForbidden, nil)
+			return
+		}
+
+		var req struct {
+			Content  string     `json:"content"`
+			ParentID *uuid.UUID `json:"parent_id"`
+		}
+		if !decodeJSON(rw, r, &req) {
+			return
+		}
+
+		form := CreateCommentForm{Content: req.Content}
+		if !form.Validate() {
+			apiInvalid(rw, form.Errors)
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
+		c := goreddit.Comment{
+			ID:       uuid.New(),
+			PostID:   p.ID,
+			Content:  form.Content,
+			AuthorID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		}
+		if req.ParentID != nil {
+			// Replies must stay on the post of the comment they reply to.
+			parent, err := h.store.Comment(r.Context(), *req.ParentID)
+			if err != nil || parent.PostID != p.ID {
+				apiInvalid(rw, FormErrors{"parent_id": "This comment does not exist on this post."})
+				return
+			}
+			c.ParentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		}
+		if err := h.store.CreateComment(r.Context(), &c); err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+		c.AuthorUsername = user.Username
+
+		writeData(rw, http.StatusCreated, newAPIComment(c))
+	}
+}
+
+func (h *APIHandler) UpdateComment() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, ok := urlID(rw, r, "id")
+		if !ok {
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		if !h.policy.For(r.Context()).EditComment(c) {
+			writeAPIError(rw, http.StatusForbidden, nil)
+			return
+		}
+
+		var req struct {
+			Content *string `json:"content"`
+		}
+		if !decodeJSON(rw, r, &req) {
+			return
+		}
+
+		form := EditCommentForm{Content: c.Content}
+		if req.Content != nil {
+			form.Content = *req.Content
+		}
+		if !form.Validate() {
+			apiInvalid(rw, form.Errors)
+			return
+		}
+
+		c.Content = form.Content
+		if err := h.store.UpdateComment(r.Context(), &c); err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		c, err = h.store.Comment(r.Context(), c.ID)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		writeData(rw, http.StatusOK, newAPIComment(c))
+	}
+}
+
+func (h *APIHandler) DeleteComment() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, ok := urlID(rw, r, "id")
+		if !ok {
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		can := h.policy.For(r.Context())
+		if !can.DeleteComment(c) {
+			writeAPIError(rw, http.StatusForbidden, nil)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), c.PostID)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		if err := h.store.DeleteComment(r.Context(), c.ID); err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		if !can.isAuthor(c.AuthorID) {
+			if err := logModAction(r.Context(), h.store, commentEntry(goreddit.ModDeleteComment, p, c)); err != nil {
+				apiFail(rw, r, err)
+				return
+			}
+		}
+
+		rw.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,179 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/aleury/goreddit"
+)
+
+func (h *APIHandler) ListModerators() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, ok := urlID(rw, r, "id")
+		if !ok {
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		mm, err := h.store.Moderators(r.Context(), t.ID)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		data := make([]apiModerator, len(mm))
+		for i, m := range mm {
+			data[i] = newAPIModerator(m)
+		}
+		writeData(rw, http.StatusOK, data)
+	}
+}
+
+func (h *APIHandler) ModeratePost() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, ok := urlID(rw, r, "id")
+		if !ok {
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), id)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		if !h.policy.For(r.Context()).Moderate(p.ThreadID) {
+			writeAPIError(rw, http.StatusForbidden, nil)
+			return
+		}
+
+		// Flags left out of the request keep their current values.
+		var req struct {
+			Removed *bool  `json:"removed"`
+			Locked  *bool  `json:"locked"`
+			Pinned  *bool  `json:"pinned"`
+			Reason  string `json:"reason"`
+		}
+		if !decodeJSON(rw, r, &req) {
+			return
+		}
+
+		// The pin goes first, so that a pin beyond the limit leaves the post
+		// unchanged.
+		var actions []goreddit.ModAction
+		if req.Pinned != nil {
+			err := h.store.SetPostPinned(r.Context(), p.ID, *req.Pinned)
+			if errors.Is(err, goreddit.ErrPinLimit) {
+				writeAPIError(rw, http.StatusConflict, map[string]string{"pinned": goreddit.ErrPinLimit.Error()})
+				return
+			}
+			if err != nil {
+				apiFail(rw, r, err)
+				return
+			}
+			actions = append(actions, modAction(*req.Pinned, goreddit.ModPin, goreddit.ModUnpin))
+		}
+		if req.Removed != nil {
+			if err := h.store.SetPostRemoved(r.Context(), p.ID, *req.Removed); err != nil {
+				apiFail(rw, r, err)
+				return
+			}
+			actions = append(actions, modAction(*req.Removed, goreddit.ModRemove, goreddit.ModApprove))
+		}
+		if req.Locked != nil {
+			if err := h.store.SetPostLocked(r.Context(), p.ID, *req.Locked); err != nil {
+				apiFail(rw, r, err)
+				return
+			}
+			actions = append(actions, modAction(*req.Locked, goreddit.ModLock, goreddit.ModUnlock))
+		}
+
+		for _, action := range actions {
+			entry := postEntry(action, p)
+			entry.Reason = truncate(strings.TrimSpace(req.Reason), maxModReason)
+			if err := logModAction(r.Context(), h.store, entry); err != nil {
+				apiFail(rw, r, err)
+				return
+			}
+		}
+
+		p, err = h.store.Post(r.Context(), p.ID)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		writeData(rw, http.StatusOK, newAPIPost(p))
+	}
+}
+
+func (h *APIHandler) ModerateComment() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, ok := urlID(rw, r, "id")
+		if !ok {
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), c.PostID)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		if !h.policy.For(r.Context()).Moderate(p.ThreadID) {
+			writeAPIError(rw, http.StatusForbidden, nil)
+			return
+		}
+
+		var req struct {
+			Removed *bool  `json:"removed"`
+			Reason  string `json:"reason"`
+		}
+		if !decodeJSON(rw, r, &req) {
+			return
+		}
+
+		if req.Removed != nil {
+			if err := h.store.SetCommentRemoved(r.Context(), c.ID, *req.Removed); err != nil {
+				apiFail(rw, r, err)
+				return
+			}
+
+			entry := commentEntry(modAction(*req.Removed, goreddit.ModRemove, goreddit.ModApprove), p, c)
+			entry.Reason = truncate(strings.TrimSpace(req.Reason), maxModReason)
+			if err := logModAction(r.Context(), h.store, entry); err != nil {
+				apiFail(rw, r, err)
+				return
+			}
+		}
+
+		c, err = h.store.Comment(r.Context(), c.ID)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		writeData(rw, http.StatusOK, newAPIComment(c))
+	}
+}
+
+// modAction returns the action that sets a flag to on: set when on is true
+// and clear otherwise.
+func modAction(on bool, set, clear goreddit.ModAction) goreddit.ModAction {
+	if on {
+		return set
+	}
+	return clear
+}
@@ -0,0 +1,212 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+)
+
+func (h *APIHandler) ListPosts() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		opts := listOptions(r, goreddit.SortHot, h.pageSize)
+		pp, page, err := h.store.Posts(r.Context(), opts)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		writePage(rw, apiPosts(pp), page, opts.PageSize())
+	}
+}
+
+func (h *APIHandler) ListThreadPosts() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, ok := urlID(rw, r, "id")
+		if !ok {
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		opts := listOptions(r, goreddit.SortHot, h.pageSize)
+		opts.IncludeRemoved = h.policy.For(r.Context()).Moderate(t.ID)
+		pp, page, err := h.store.PostsByThread(r.Context(), t.ID, opts)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		writePage(rw, apiPosts(pp), page, opts.PageSize())
+	}
+}
+
+func (h *APIHandler) ShowPost() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, ok := urlID(rw, r, "id")
+		if !ok {
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), id)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		writeData(rw, http.StatusOK, newAPIPost(redactPost(p, h.policy.For(r.Context()))))
+	}
+}
+
+func (h *APIHandler) CreatePost() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, ok := urlID(rw, r, "id")
+		if !ok {
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		if ban := h.policy.For(r.Context()).Ban(t.ID); ban != nil {
+			apiBanned(rw, *ban)
+			return
+		}
+
+		var req struct {
+			Title   string `json:"title"`
+			Content string `json:"content"`
+		}
+		if !decodeJSON(rw, r, &req) {
+			return
+		}
+
+		form := CreatePostForm{Title: req.Title, Content: req.Content}
+		if !form.Validate() {
+			apiInvalid(rw, form.Errors)
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
+		p := goreddit.Post{
+			ID:       uuid.New(),
+			ThreadID: t.ID,
+			Title:    form.Title,
+			Content:  form.Content,
+			AuthorID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		}
+		if err := h.store.CreatePost(r.Context(), &p); err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+		p.AuthorUsername = user.Username
+
+		writeData(rw, http.StatusCreated, newAPIPost(p))
+	}
+}
+
+func (h *APIHandler) UpdatePost() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, ok := urlID(rw, r, "id")
+		if !ok {
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), id)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		if !h.policy.For(r.Context()).EditPost(p) {
+			writeAPIError(rw, http.StatusForbidden, nil)
+			return
+		}
+
+		// Fields left out of the request keep their current values.
+		var req struct {
+			Title   *string `json:"title"`
+			Content *string `json:"content"`
+		}
+		if !decodeJSON(rw, r, &req) {
+			return
+		}
+
+		form := EditPostForm{Title: p.Title, Content: p.Content}
+		if req.Title != nil {
+			form.Title = *req.Title
+		}
+		if req.Content != nil {
+			form.Content = *req.Content
+		}
+		if !form.Validate() {
+			apiInvalid(rw, form.Errors)
+			return
+		}
+
+		p.Title = form.Title
+		p.Content = form.Content
+		if err := h.store.UpdatePost(r.Context(), &p); err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		p, err = h.store.Post(r.Context(), p.ID)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		writeData(rw, http.StatusOK, newAPIPost(p))
+	}
+}
+
+func (h *APIHandler) DeletePost() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, ok := urlID(rw, r, "id")
+		if !ok {
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), id)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		can := h.policy.For(r.Context())
+		if !can.DeletePost(p) {
+			writeAPIError(rw, http.StatusForbidden, nil)
+			return
+		}
+
+		if err := h.store.DeletePost(r.Context(), p.ID); err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		if !can.isAuthor(p.AuthorID) {
+			if err := logModAction(r.Context(), h.store, postEntry(goreddit.ModDeletePost, p)); err != nil {
+				apiFail(rw, r, err)
+				return
+			}
+		}
+
+		rw.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func apiPosts(pp []goreddit.Post) []apiPost {
+	data := make([]apiPost, len(pp))
+	for i, p := range pp {
+		data[i] = newAPIPost(p)
+	}
+	return data
+}
@@ -0,0 +1,330 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aleury/goreddit"
+	"github.com/aleury/goreddit/memory"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type apiResponse struct {
+	Data       json.RawMessage `json:"data"`
+	Pagination *apiPagination  `json:"pagination"`
+	Error      *apiError       `json:"error"`
+}
+
+func TestAPIThreads(t *testing.T) {
+	store := memory.NewStore()
+	alice := goreddit.User{ID: uuid.New(), Username: "alice"}
+	if err := store.CreateUser(context.Background(), &alice); err != nil {
+		t.Fatal(err)
+	}
+
+	api := &APIHandler{store: store, policy: &Policy{store: store}, pageSize: 2}
+	r := chi.NewRouter()
+	r.Get("/threads", api.ListThreads())
+	r.With(requireAPIUser).Post("/threads", api.CreateThread())
+	r.Get("/threads/{id}", api.ShowThread())
+
+	do := func(method, path, body string, user *goreddit.User) (int, apiResponse) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if user != nil {
+			req = req.WithContext(context.WithValue(req.Context(), ctxKey("user"), *user))
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		var res apiResponse
+		if rec.Code != http.StatusNoContent {
+			if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+				t.Fatalf("%s %s: invalid JSON %q", method, path, rec.Body.String())
+			}
+		}
+		return rec.Code, res
+	}
+
+	if code, res := do("POST", "/threads", `{"title":"Go","description":"All about Go"}`, nil); code != http.StatusUnauthorized || res.Error == nil || res.Error.Code != "unauthorized" {
+		t.Errorf("anonymous create = %d %+v, want 401 error", code, res.Error)
+	}
+
+	code, res := do("POST", "/threads", `{"title":""}`, &alice)
+	if code != http.StatusUnprocessableEntity || res.Error == nil || res.Error.Fields["title"] == "" || res.Error.Fields["description"] == "" {
+		t.Errorf("invalid create = %d %+v, want 422 with title and description errors", code, res.Error)
+	}
+
+	if code, _ := do("POST", "/threads", `{`, &alice); code != http.StatusBadRequest {
+		t.Errorf("malformed create = %d, want 400", code)
+	}
+
+	var created apiThread
+	for _, title := range []string{"Go", "Rust", "Zig"} {
+		code, res := do("POST", "/threads", `{"title":"`+title+`","description":"About `+title+`"}`, &alice)
+		if code != http.StatusCreated {
+			t.Fatalf("create = %d %+v, want 201", code, res.Error)
+		}
+		json.Unmarshal(res.Data, &created)
+		if created.Title != title || created.Author == nil || created.Author.Username != "alice" {
+			t.Errorf("created = %+v, want %q by alice", created, title)
+		}
+	}
+
+	code, res = do("GET", "/threads/"+created.ID.String(), "", nil)
+	if code != http.StatusOK {
+		t.Fatalf("show = %d, want 200", code)
+	}
+	var shown apiThread
+	json.Unmarshal(res.Data, &shown)
+	if shown.ID != created.ID {
+		t.Errorf("show = %+v, want %+v", shown, created)
+	}
+
+	for _, path := range []string{"/threads/" + uuid.NewString(), "/threads/not-a-uuid"} {
+		if code, res := do("GET", path, "", nil); code != http.StatusNotFound || res.Error == nil || res.Error.Code != "not_found" {
+			t.Errorf("GET %s = %d %+v, want 404 error", path, code, res.Error)
+		}
+	}
+
+	code, res = do("GET", "/threads", "", nil)
+	var page []apiThread
+	json.Unmarshal(res.Data, &page)
+	if code != http.StatusOK || len(page) != 2 || res.Pagination == nil || res.Pagination.Next == "" || res.Pagination.Limit != 2 {
+		t.Fatalf("list = %d %d threads %+v, want 200 with 2 threads and a next page", code, len(page), res.Pagination)
+	}
+	code, res = do("GET", "/threads?cursor="+res.Pagination.Next, "", nil)
+	json.Unmarshal(res.Data, &page)
+	if code != http.StatusOK || len(page) != 1 || res.Pagination.Next != "" || res.Pagination.Prev == "" {
+		t.Errorf("second page = %d %d threads %+v, want 200 with the last thread", code, len(page), res.Pagination)
+	}
+
+	if code, res := do("GET", "/threads?cursor=bogus", "", nil); code != http.StatusBadRequest || res.Error == nil {
+		t.Errorf("bad cursor = %d %+v, want 400 error", code, res.Error)
+	}
+}
+
+func TestAPIModeration(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	alice := goreddit.User{ID: uuid.New(), Username: "alice"}
+	bob := goreddit.User{ID: uuid.New(), Username: "bob"}
+	for _, u := range []*goreddit.User{&alice, &bob} {
+		if err := store.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	th := goreddit.Thread{ID: uuid.New(), Title: "Go", AuthorID: uuid.NullUUID{UUID: alice.ID, Valid: true}}
+	if err := store.CreateThread(ctx, &th); err != nil {
+		t.Fatal(err)
+	}
+	var pp []goreddit.Post
+	for _, title := range []string{"One", "Two", "Three"} {
+		p := goreddit.Post{ID: uuid.New(), ThreadID: th.ID, Title: title, Content: title + " content"}
+		if err := store.CreatePost(ctx, &p); err != nil {
+			t.Fatal(err)
+		}
+		pp = append(pp, p)
+	}
+
+	api := &APIHandler{store: store, policy: &Policy{store: store}, pageSize: 10}
+	r := chi.NewRouter()
+	r.Get("/posts/{id}", api.ShowPost())
+	r.With(requireAPIUser).Post("/posts/{id}/comments", api.CreateComment())
+	r.With(requireAPIUser).Put("/posts/{id}/moderation", api.ModeratePost())
+
+	do := func(method, path, body string, user goreddit.User) (int, apiResponse) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), ctxKey("user"), user))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		var res apiResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+			t.Fatalf("%s %s: invalid JSON %q", method, path, rec.Body.String())
+		}
+		return rec.Code, res
+	}
+	moderation := func(p goreddit.Post) string { return "/posts/" + p.ID.String() + "/moderation" }
+
+	if code, _ := do("PUT", moderation(pp[0]), `{"removed":true}`, bob); code != http.StatusForbidden {
+		t.Errorf("moderation by a user = %d, want 403", code)
+	}
+
+	code, res := do("PUT", moderation(pp[0]), `{"removed":true,"locked":true,"reason":"spam"}`, alice)
+	var moderated apiPost
+	json.Unmarshal(res.Data, &moderated)
+	if code != http.StatusOK || !moderated.Removed || !moderated.Locked || moderated.Pinned {
+		t.Errorf("moderation = %d %+v, want removed and locked", code, moderated)
+	}
+
+	ee, _, err := store.ModLog(ctx, goreddit.ModLogOptions{ThreadID: uuid.NullUUID{UUID: th.ID, Valid: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ee) != 2 || ee[0].ActorUsername != "alice" || ee[0].Reason != "spam" || ee[0].TargetID != pp[0].ID {
+		t.Errorf("mod log after moderation = %+v, want a remove and a lock by alice", ee)
+	}
+
+	var shown apiPost
+	_, res = do("GET", "/posts/"+pp[0].ID.String(), "", bob)
+	json.Unmarshal(res.Data, &shown)
+	if shown.Content != "" {
+		t.Errorf("removed post shown to a user has content %q, want none", shown.Content)
+	}
+	_, res = do("GET", "/posts/"+pp[0].ID.String(), "", alice)
+	json.Unmarshal(res.Data, &shown)
+	if shown.Content == "" {
+		t.Error("removed post shown to a moderator has no content")
+	}
+
+	if code, _ := do("POST", "/posts/"+pp[0].ID.String()+"/comments", `{"content":"Hi"}`, bob); code != http.StatusForbidden {
+		t.Errorf("comment on a locked post = %d, want 403", code)
+	}
+	if code, _ := do("POST", "/posts/"+pp[0].ID.String()+"/comments", `{"content":"Hi"}`, alice); code != http.StatusCreated {
+		t.Errorf("moderator comment on a locked post = %d, want 201", code)
+	}
+
+	for _, p := range pp[1:] {
+		if code, res := do("PUT", moderation(p), `{"pinned":true}`, alice); code != http.StatusOK {
+			t.Fatalf("pin = %d %+v, want 200", code, res.Error)
+		}
+	}
+	if code, _ := do("PUT", moderation(pp[2]), `{"pinned":true}`, alice); code != http.StatusOK {
+		t.Errorf("pinning a pinned post = %d, want 200", code)
+	}
+	extra := goreddit.Post{ID: uuid.New(), ThreadID: th.ID, Title: "Four"}
+	if err := store.CreatePost(ctx, &extra); err != nil {
+		t.Fatal(err)
+	}
+	if code, res := do("PUT", moderation(extra), `{"pinned":true}`, alice); code != http.StatusConflict || res.Error == nil || res.Error.Fields["pinned"] == "" {
+		t.Errorf("pin beyond the limit = %d %+v, want 409", code, res.Error)
+	}
+}
+
+func TestAPIBans(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	bob := goreddit.User{ID: uuid.New(), Username: "bob"}
+	if err := store.CreateUser(ctx, &bob); err != nil {
+		t.Fatal(err)
+	}
+	th := goreddit.Thread{ID: uuid.New(), Title: "Go"}
+	if err := store.CreateThread(ctx, &th); err != nil {
+		t.Fatal(err)
+	}
+	p := goreddit.Post{ID: uuid.New(), ThreadID: th.ID, Title: "One"}
+	if err := store.CreatePost(ctx, &p); err != nil {
+		t.Fatal(err)
+	}
+
+	api := &APIHandler{store: store, policy: &Policy{store: store}, pageSize: 10}
+	r := chi.NewRouter()
+	r.With(requireAPIUser).Post("/threads", api.CreateThread())
+	r.With(requireAPIUser).Post("/posts/{id}/comments", api.CreateComment())
+
+	do := func(path, body string) (int, apiResponse) {
+		t.Helper()
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), ctxKey("user"), bob))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		var res apiResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+			t.Fatalf("POST %s: invalid JSON %q", path, rec.Body.String())
+		}
+		return rec.Code, res
+	}
+	comment := "/posts/" + p.ID.String() + "/comments"
+
+	threadBan := goreddit.Ban{ID: uuid.New(), ThreadID: uuid.NullUUID{UUID: th.ID, Valid: true}, UserID: bob.ID, Reason: "spam"}
+	if err := store.CreateBan(ctx, &threadBan); err != nil {
+		t.Fatal(err)
+	}
+	if code, res := do(comment, `{"content":"Hi"}`); code != http.StatusForbidden || res.Error == nil || !strings.Contains(res.Error.Fields["ban"], "spam") {
+		t.Errorf("comment while banned from the thread = %d %+v, want 403 with the ban", code, res.Error)
+	}
+	if code, _ := do("/threads", `{"title":"Rust","description":"About Rust"}`); code != http.StatusCreated {
+		t.Errorf("create thread while banned from another thread = %d, want 201", code)
+	}
+
+	if err := store.LiftBan(ctx, threadBan.ID); err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := do(comment, `{"content":"Hi"}`); code != http.StatusCreated {
+		t.Errorf("comment after the ban was lifted = %d, want 201", code)
+	}
+
+	siteBan := goreddit.Ban{ID: uuid.New(), UserID: bob.ID}
+	if err := store.CreateBan(ctx, &siteBan); err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := do(comment, `{"content":"Hi"}`); code != http.StatusForbidden {
+		t.Errorf("comment while banned from the site = %d, want 403", code)
+	}
+	if code, _ := do("/threads", `{"title":"Zig","description":"About Zig"}`); code != http.StatusForbidden {
+		t.Errorf("create thread while banned from the site = %d, want 403", code)
+	}
+}
+
+func TestAPIAdminDelete(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	root := goreddit.User{ID: uuid.New(), Username: "root", Role: goreddit.RoleAdmin}
+	bob := goreddit.User{ID: uuid.New(), Username: "bob"}
+	for _, u := range []*goreddit.User{&root, &bob} {
+		if err := store.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	th := goreddit.Thread{ID: uuid.New(), Title: "Go"}
+	if err := store.CreateThread(ctx, &th); err != nil {
+		t.Fatal(err)
+	}
+	var pp []goreddit.Post
+	for _, author := range []goreddit.User{bob, root} {
+		p := goreddit.Post{ID: uuid.New(), ThreadID: th.ID, Title: "By " + author.Username, AuthorID: uuid.NullUUID{UUID: author.ID, Valid: true}}
+		if err := store.CreatePost(ctx, &p); err != nil {
+			t.Fatal(err)
+		}
+		pp = append(pp, p)
+	}
+
+	api := &APIHandler{store: store, policy: &Policy{store: store}, pageSize: 10}
+	r := chi.NewRouter()
+	r.With(requireAPIUser).Delete("/posts/{id}", api.DeletePost())
+
+	do := func(path string, user goreddit.User) int {
+		t.Helper()
+		req := httptest.NewRequest("DELETE", path, nil)
+		req = req.WithContext(context.WithValue(req.Context(), ctxKey("user"), user))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := do("/posts/"+pp[1].ID.String(), bob); code != http.StatusForbidden {
+		t.Errorf("user deleting an admin's post = %d, want 403", code)
+	}
+	if code := do("/posts/"+pp[0].ID.String(), root); code != http.StatusNoContent {
+		t.Errorf("admin deleting a user's post = %d, want 204", code)
+	}
+	if code := do("/posts/"+pp[1].ID.String(), root); code != http.StatusNoContent {
+		t.Errorf("admin deleting their own post = %d, want 204", code)
+	}
+
+	ee, _, err := store.ModLog(ctx, goreddit.ModLogOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ee) != 1 || ee[0].Action != goreddit.ModDeletePost || ee[0].TargetID != pp[0].ID || ee[0].ActorUsername != "root" {
+		t.Errorf("mod log = %+v, want only the deletion of bob's post by root", ee)
+	}
+}
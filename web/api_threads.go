@@ -0,0 +1,168 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+)
+
+func (h *APIHandler) ListThreads() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		opts := pageOptions(r, h.pageSize)
+		tt, page, err := h.store.Threads(r.Context(), opts)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		data := make([]apiThread, len(tt))
+		for i, t := range tt {
+			data[i] = newAPIThread(t)
+		}
+		writePage(rw, data, page, opts.PageSize())
+	}
+}
+
+func (h *APIHandler) ShowThread() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, ok := urlID(rw, r, "id")
+		if !ok {
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		writeData(rw, http.StatusOK, newAPIThread(t))
+	}
+}
+
+func (h *APIHandler) CreateThread() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if ban := h.policy.For(r.Context()).SiteBan(); ban != nil {
+			apiBanned(rw, *ban)
+			return
+		}
+
+		var req struct {
+			Title       string `json:"title"`
+			Description string `json:"description"`
+		}
+		if !decodeJSON(rw, r, &req) {
+			return
+		}
+
+		form := CreateThreadForm{Title: req.Title, Description: req.Description}
+		if !form.Validate() {
+			apiInvalid(rw, form.Errors)
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
+		t := goreddit.Thread{
+			ID:          uuid.New(),
+			Title:       form.Title,
+			Description: form.Description,
+			AuthorID:    uuid.NullUUID{UUID: user.ID, Valid: true},
+		}
+		if err := h.store.CreateThread(r.Context(), &t); err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+		t.AuthorUsername = user.Username
+
+		writeData(rw, http.StatusCreated, newAPIThread(t))
+	}
+}
+
+func (h *APIHandler) UpdateThread() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, ok := urlID(rw, r, "id")
+		if !ok {
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		if !h.policy.For(r.Context()).EditThread(t) {
+			writeAPIError(rw, http.StatusForbidden, nil)
+			return
+		}
+
+		// Fields left out of the request keep their current values.
+		var req struct {
+			Title       *string `json:"title"`
+			Description *string `json:"description"`
+		}
+		if !decodeJSON(rw, r, &req) {
+			return
+		}
+
+		form := EditThreadForm{Title: t.Title, Description: t.Description}
+		if req.Title != nil {
+			form.Title = *req.Title
+		}
+		if req.Description != nil {
+			form.Description = *req.Description
+		}
+		if !form.Validate() {
+			apiInvalid(rw, form.Errors)
+			return
+		}
+
+		t.Title = form.Title
+		t.Description = form.Description
+		if err := h.store.UpdateThread(r.Context(), &t); err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		t, err = h.store.Thread(r.Context(), t.ID)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		writeData(rw, http.StatusOK, newAPIThread(t))
+	}
+}
+
+func (h *APIHandler) DeleteThread() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, ok := urlID(rw, r, "id")
+		if !ok {
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		if !h.policy.For(r.Context()).DeleteThread(t) {
+			writeAPIError(rw, http.StatusForbidden, nil)
+			return
+		}
+
+		if err := h.store.DeleteThread(r.Context(), t.ID); err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		if err := logModAction(r.Context(), h.store, threadEntry(goreddit.ModDeleteThread, t)); err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		rw.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,117 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aleury/goreddit"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (h *APIHandler) CreateUser() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		}
+		if !decodeJSON(rw, r, &req) {
+			return
+		}
+
+		form := RegisterForm{Username: req.Username, Password: req.Password}
+		_, err := h.store.UserByUsername(r.Context(), form.Username)
+		if err == nil {
+			form.UsernameTaken = true
+		} else if !errors.Is(err, goreddit.ErrNotFound) {
+			apiFail(rw, r, err)
+			return
+		}
+		if !form.Validate() {
+			apiInvalid(rw, form.Errors)
+			return
+		}
+
+		password, err := bcrypt.GenerateFromPassword([]byte(form.Password), bcrypt.DefaultCost)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		u := goreddit.User{
+			ID:       uuid.New(),
+			Username: form.Username,
+			Password: string(password),
+		}
+		if err := h.store.CreateUser(r.Context(), &u); err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		writeData(rw, http.StatusCreated, newAPIUser(u))
+	}
+}
+
+func (h *APIHandler) ShowUser() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		u, err := h.store.UserByUsername(r.Context(), chi.URLParam(r, "username"))
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		writeData(rw, http.StatusOK, newAPIUser(u))
+	}
+}
+
+func (h *APIHandler) ShowMe() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user, _ := userFromContext(r.Context())
+		writeData(rw, http.StatusOK, newAPIUser(user))
+	}
+}
+
+func (h *APIHandler) UpdateMe() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Password string `json:"password"`
+		}
+		if !decodeJSON(rw, r, &req) {
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
+		form := RegisterForm{Username: user.Username, Password: req.Password}
+		if !form.Validate() {
+			apiInvalid(rw, form.Errors)
+			return
+		}
+
+		password, err := bcrypt.GenerateFromPassword([]byte(form.Password), bcrypt.DefaultCost)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		user.Password = string(password)
+		if err := h.store.UpdateUser(r.Context(), &user); err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		writeData(rw, http.StatusOK, newAPIUser(user))
+	}
+}
+
+func (h *APIHandler) DeleteMe() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user, _ := userFromContext(r.Context())
+		if err := h.store.DeleteUser(r.Context(), user.ID); err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		rw.WriteHeader(http.StatusNoContent)
+	}
+}
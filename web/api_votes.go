@@ -0,0 +1,161 @@
+package web
+
+import (
+	"net/http"
+)
+
+type apiVote struct {
+	// Value is the current user's vote: 1 for up, -1 for down and 0 for
+	// none.
+	Value int `json:"value"`
+	// Votes is the resulting score of the voted content.
+	Votes int `json:"votes"`
+}
+
+type apiVoteRequest struct {
+	Value int `json:"value"`
+}
+
+func (v apiVoteRequest) valid() bool {
+	return v.Value >= -1 && v.Value <= 1
+}
+
+func (h *APIHandler) ShowPostVote() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, ok := urlID(rw, r, "id")
+		if !ok {
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), id)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
+		value, err := h.store.UserPostVote(r.Context(), user.ID, p.ID)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		writeData(rw, http.StatusOK, apiVote{Value: value, Votes: p.Votes})
+	}
+}
+
+func (h *APIHandler) CastPostVote() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, ok := urlID(rw, r, "id")
+		if !ok {
+			return
+		}
+
+		var req apiVoteRequest
+		if !decodeJSON(rw, r, &req) {
+			return
+		}
+		if !req.valid() {
+			apiInvalid(rw, FormErrors{"value": "Please vote 1, -1 or 0."})
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), id)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		if ban := h.policy.For(r.Context()).Ban(p.ThreadID); ban != nil {
+			apiBanned(rw, *ban)
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
+		if err := h.store.CastPostVote(r.Context(), user.ID, p.ID, req.Value); err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		p, err = h.store.Post(r.Context(), p.ID)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		writeData(rw, http.StatusOK, apiVote{Value: req.Value, Votes: p.Votes})
+	}
+}
+
+func (h *APIHandler) ShowCommentVote() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, ok := urlID(rw, r, "id")
+		if !ok {
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
+		value, err := h.store.UserCommentVote(r.Context(), user.ID, c.ID)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		writeData(rw, http.StatusOK, apiVote{Value: value, Votes: c.Votes})
+	}
+}
+
+func (h *APIHandler) CastCommentVote() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, ok := urlID(rw, r, "id")
+		if !ok {
+			return
+		}
+
+		var req apiVoteRequest
+		if !decodeJSON(rw, r, &req) {
+			return
+		}
+		if !req.valid() {
+			apiInvalid(rw, FormErrors{"value": "Please vote 1, -1 or 0."})
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), c.PostID)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		if ban := h.policy.For(r.Context()).Ban(p.ThreadID); ban != nil {
+			apiBanned(rw, *ban)
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
+		if err := h.store.CastCommentVote(r.Context(), user.ID, c.ID, req.Value); err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		c, err = h.store.Comment(r.Context(), c.ID)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		writeData(rw, http.StatusOK, apiVote{Value: req.Value, Votes: c.Votes})
+	}
+}
@@ -0,0 +1,119 @@
+package web
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+)
+
+// banDuration is a length of ban moderators can choose from.
+type banDuration struct {
+	Value string
+	Label string
+	// Duration is 0 for permanent bans.
+	Duration time.Duration
+}
+
+// banDurations lists the lengths of ban offered to moderators.
+var banDurations = []banDuration{
+	{"1d", "1 day", 24 * time.Hour},
+	{"3d", "3 days", 3 * 24 * time.Hour},
+	{"7d", "7 days", 7 * 24 * time.Hour},
+	{"30d", "30 days", 30 * 24 * time.Hour},
+	{"permanent", "Permanent", 0},
+}
+
+// findBanDuration returns the ban duration with value.
+func findBanDuration(value string) (banDuration, bool) {
+	for _, d := range banDurations {
+		if d.Value == value {
+			return d, true
+		}
+	}
+	return banDuration{}, false
+}
+
+// checkBan looks up the user named by form and notes on the form whether
+// they may be banned from the thread, or from the site if threadID is unset.
+// It returns the user found.
+func checkBan(ctx context.Context, store goreddit.Store, form *BanForm, threadID uuid.NullUUID) (goreddit.User, error) {
+	if form.Username == "" {
+		return goreddit.User{}, nil
+	}
+	u, err := store.UserByUsername(ctx, form.Username)
+	if errors.Is(err, goreddit.ErrNotFound) {
+		form.UnknownUser = true
+		return goreddit.User{}, nil
+	} else if err != nil {
+		return goreddit.User{}, err
+	}
+
+	form.Admin = u.IsAdmin()
+	if threadID.Valid {
+		form.Moderator, err = store.IsModerator(ctx, threadID.UUID, u.ID)
+		if err != nil {
+			return goreddit.User{}, err
+		}
+	}
+	_, err = store.ActiveBan(ctx, u.ID, threadID)
+	if err != nil && !errors.Is(err, goreddit.ErrNotFound) {
+		return goreddit.User{}, err
+	}
+	form.AlreadyBanned = err == nil
+
+	return u, nil
+}
+
+// createBan bans u as asked by a valid form, on behalf of the user making the
+// request with ctx, and records it in the mod log.
+func createBan(ctx context.Context, store goreddit.Store, form BanForm, threadID uuid.NullUUID, entry goreddit.ModLogEntry) error {
+	duration, _ := findBanDuration(form.Duration)
+	b := goreddit.Ban{
+		ID:       uuid.New(),
+		ThreadID: threadID,
+		UserID:   entry.TargetID,
+		Reason:   form.Reason,
+	}
+	if user, ok := userFromContext(ctx); ok {
+		b.BannedByID = uuid.NullUUID{UUID: user.ID, Valid: true}
+	}
+	if duration.Duration > 0 {
+		b.ExpiresAt = sql.NullTime{Time: time.Now().Add(duration.Duration), Valid: true}
+	}
+	if err := store.CreateBan(ctx, &b); err != nil {
+		return err
+	}
+
+	entry.Reason = duration.Label
+	if form.Reason != "" {
+		entry.Reason += ": " + form.Reason
+	}
+	return logModAction(ctx, store, entry)
+}
+
+// banMessage describes a ban to the banned user.
+func banMessage(b goreddit.Ban) string {
+	msg := "You are banned from this thread"
+	if !b.ThreadID.Valid {
+		msg = "You are banned from the site"
+	}
+	if b.ExpiresAt.Valid {
+		msg += " until " + b.ExpiresAt.Time.UTC().Format(time.RFC3339)
+	} else {
+		msg += " permanently"
+	}
+	if b.Reason != "" {
+		msg += ": " + b.Reason
+	}
+	return msg + "."
+}
+
+// apiBanned responds that the user is banned from doing what they asked.
+func apiBanned(rw http.ResponseWriter, b goreddit.Ban) {
+	writeAPIError(rw, http.StatusForbidden, map[string]string{"ban": banMessage(b)})
+}
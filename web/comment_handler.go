@@ -1,17 +1,24 @@
 package web
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
 	"net/http"
 
 	"github.com/aleury/goreddit"
 	"github.com/alexedwards/scs/v2"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/gorilla/csrf"
 )
 
 type CommentHandler struct {
-	store    goreddit.Store
-	sessions *scs.SessionManager
+	store        goreddit.Store
+	sessions     *scs.SessionManager
+	policy       *Policy
+	commentDepth int
 }
 
 func (h *CommentHandler) Create() http.HandlerFunc {
@@ -27,23 +34,35 @@ func (h *CommentHandler) Create() http.HandlerFunc {
 
 		postId, err := uuid.Parse(chi.URLParam(r, "postId"))
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusBadRequest)
+			renderError(rw, r, http.StatusNotFound)
 			return
 		}
 
-		p, err := h.store.Post(postId)
+		p, err := h.store.Post(r.Context(), postId)
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			httpError(rw, r, err)
 			return
 		}
 
-		err = h.store.CreateComment(&goreddit.Comment{
-			ID:      uuid.New(),
-			PostID:  p.ID,
-			Content: form.Content,
+		can := h.policy.For(r.Context())
+		if ban := can.Ban(p.ThreadID); ban != nil {
+			renderBanned(rw, r, *ban)
+			return
+		}
+		if !can.Comment(p) {
+			renderError(rw, r, http.StatusForbidden)
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
+		err = h.store.CreateComment(r.Context(), &goreddit.Comment{
+			ID:       uuid.New(),
+			PostID:   p.ID,
+			Content:  form.Content,
+			AuthorID: uuid.NullUUID{UUID: user.ID, Valid: true},
 		})
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			httpError(rw, r, err)
 			return
 		}
 
@@ -53,33 +72,604 @@ func (h *CommentHandler) Create() http.HandlerFunc {
 	}
 }
 
+func (h *CommentHandler) Show() http.HandlerFunc {
+	type data struct {
+		SessionData
+
+		CSRF       template.HTML
+		Can        Permissions
+		Thread     goreddit.Thread
+		Moderators moderators
+		Reports    map[uuid.UUID]int
+		Post       goreddit.Post
+		Comments   []goreddit.Comment
+		Focused    bool
+		Tabs       sortTabs
+	}
+
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"post.html",
+		"ban_notice.html",
+		"markdown_preview.html",
+		"comment.html",
+		"sort_tabs.html",
+	))
+	return func(rw http.ResponseWriter, r *http.Request) {
+		threadId, err := uuid.Parse(chi.URLParam(r, "threadId"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		postId, err := uuid.Parse(chi.URLParam(r, "postId"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		sort := commentSort(r)
+		c, err := h.store.CommentSubtree(r.Context(), id, h.commentDepth, sort)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), c.PostID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+		// Like PostHandler.Show, only serve the comment under the URL of
+		// its own post and thread.
+		if p.ID != postId || p.ThreadID != threadId {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), p.ThreadID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		mm, err := h.store.Moderators(r.Context(), t.ID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		// Only moderators see how often content has been reported.
+		can := h.policy.For(r.Context())
+		var reports map[uuid.UUID]int
+		if can.Moderate(t.ID) {
+			reports, err = h.store.ReportCounts(r.Context(), t.ID)
+			if err != nil {
+				httpError(rw, r, err)
+				return
+			}
+		}
+
+		tmpl.Execute(rw, data{
+			Thread:      t,
+			Moderators:  mm,
+			Reports:     reports,
+			Post:        p,
+			Comments:    []goreddit.Comment{c},
+			Focused:     true,
+			Tabs:        commentTabs(sort),
+			CSRF:        csrf.TemplateField(r),
+			Can:         can,
+			SessionData: GetSessionData(h.sessions, r.Context()),
+		})
+	}
+}
+
+func (h *CommentHandler) Reply() http.HandlerFunc {
+	type data struct {
+		SessionData
+
+		CSRF   template.HTML
+		Can    Permissions
+		Thread goreddit.Thread
+		Post   goreddit.Post
+		Parent goreddit.Comment
+	}
+
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"comment_reply.html",
+		"markdown_preview.html",
+	))
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), c.PostID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		can := h.policy.For(r.Context())
+		if ban := can.Ban(p.ThreadID); ban != nil {
+			renderBanned(rw, r, *ban)
+			return
+		}
+		if !can.Comment(p) {
+			renderError(rw, r, http.StatusForbidden)
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), p.ThreadID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		tmpl.Execute(rw, data{
+			Thread:      t,
+			Post:        p,
+			Parent:      c,
+			CSRF:        csrf.TemplateField(r),
+			Can:         can,
+			SessionData: GetSessionData(h.sessions, r.Context()),
+		})
+	}
+}
+
+func (h *CommentHandler) ReplySubmit() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		form := CreateCommentForm{
+			Content: r.FormValue("content"),
+		}
+		if !form.Validate() {
+			h.sessions.Put(r.Context(), "form", form)
+			http.Redirect(rw, r, r.Referer(), http.StatusFound)
+			return
+		}
+
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		parent, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), parent.PostID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		can := h.policy.For(r.Context())
+		if ban := can.Ban(p.ThreadID); ban != nil {
+			renderBanned(rw, r, *ban)
+			return
+		}
+		if !can.Comment(p) {
+			renderError(rw, r, http.StatusForbidden)
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
+		c := &goreddit.Comment{
+			ID:       uuid.New(),
+			PostID:   p.ID,
+			ParentID: uuid.NullUUID{UUID: parent.ID, Valid: true},
+			Content:  form.Content,
+			AuthorID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		}
+		err = h.store.CreateComment(r.Context(), c)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", "Your reply has been submitted.")
+
+		redirect_url := fmt.Sprintf("/threads/%s/posts/%s#comment-%s", p.ThreadID.String(), p.ID.String(), c.ID.String())
+		http.Redirect(rw, r, redirect_url, http.StatusFound)
+	}
+}
+
 func (h *CommentHandler) Vote() http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
 		id, err := uuid.Parse(chi.URLParam(r, "id"))
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusBadRequest)
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		value, ok := voteValue(r.PostFormValue("dir"))
+		if !ok {
+			renderError(rw, r, http.StatusBadRequest)
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), c.PostID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		if ban := h.policy.For(r.Context()).Ban(p.ThreadID); ban != nil {
+			renderBanned(rw, r, *ban)
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
+		current, err := h.store.UserCommentVote(r.Context(), user.ID, c.ID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+		// Voting the same way twice retracts the vote.
+		if current == value {
+			value = 0
+		}
+
+		err = h.store.CastCommentVote(r.Context(), user.ID, c.ID, value)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		http.Redirect(rw, r, r.Referer(), http.StatusFound)
+	}
+}
+
+func (h *CommentHandler) Edit() http.HandlerFunc {
+	type data struct {
+		SessionData
+
+		CSRF    template.HTML
+		Post    goreddit.Post
+		Comment goreddit.Comment
+	}
+
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"comment_edit.html",
+	))
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		if !h.policy.For(r.Context()).EditComment(c) {
+			renderError(rw, r, http.StatusForbidden)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), c.PostID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		sd := GetSessionData(h.sessions, r.Context())
+		if _, ok := sd.Form.(EditCommentForm); !ok {
+			sd.Form = EditCommentForm{Content: c.Content}
+		}
+
+		tmpl.Execute(rw, data{
+			Post:        p,
+			Comment:     c,
+			CSRF:        csrf.TemplateField(r),
+			SessionData: sd,
+		})
+	}
+}
+
+func (h *CommentHandler) Update() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
 			return
 		}
 
-		c, err := h.store.Comment(id)
+		c, err := h.store.Comment(r.Context(), id)
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			httpError(rw, r, err)
 			return
 		}
 
-		switch r.URL.Query().Get("dir") {
-		case "up":
-			c.Votes++
-		case "down":
-			c.Votes--
+		if !h.policy.For(r.Context()).EditComment(c) {
+			renderError(rw, r, http.StatusForbidden)
+			return
+		}
+
+		form := EditCommentForm{
+			Content: r.FormValue("content"),
+		}
+		if !form.Validate() {
+			h.sessions.Put(r.Context(), "form", form)
+			http.Redirect(rw, r, r.Referer(), http.StatusFound)
+			return
 		}
 
-		err = h.store.UpdateComment(&c)
+		p, err := h.store.Post(r.Context(), c.PostID)
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			httpError(rw, r, err)
 			return
 		}
 
+		c.Content = form.Content
+		err = h.store.UpdateComment(r.Context(), &c)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", "Your comment has been updated.")
+
+		redirect_url := fmt.Sprintf("/threads/%s/posts/%s", p.ThreadID.String(), p.ID.String())
+		http.Redirect(rw, r, redirect_url, http.StatusFound)
+	}
+}
+
+func (h *CommentHandler) Delete() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		can := h.policy.For(r.Context())
+		if !can.DeleteComment(c) {
+			renderError(rw, r, http.StatusForbidden)
+			return
+		}
+
+		// Fetch the post before the comment goes, in case deleting others'
+		// comments has to be logged.
+		p, err := h.store.Post(r.Context(), c.PostID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		err = h.store.DeleteComment(r.Context(), c.ID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		if !can.isAuthor(c.AuthorID) {
+			if err := logModAction(r.Context(), h.store, commentEntry(goreddit.ModDeleteComment, p, c)); err != nil {
+				httpError(rw, r, err)
+				return
+			}
+		}
+
+		h.sessions.Put(r.Context(), "flash", "The comment has been deleted.")
+
+		http.Redirect(rw, r, r.Referer(), http.StatusFound)
+	}
+}
+
+func (h *CommentHandler) Report() http.HandlerFunc {
+	type data struct {
+		SessionData
+
+		CSRF    template.HTML
+		Thread  goreddit.Thread
+		Post    goreddit.Post
+		Comment *goreddit.Comment
+		Reasons []goreddit.ReportReason
+		Action  string
+	}
+
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"report.html",
+	))
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), c.PostID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), p.ThreadID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		if c.Removed && !h.policy.For(r.Context()).Moderate(t.ID) {
+			c.Content = "[removed by a moderator]"
+		}
+
+		tmpl.Execute(rw, data{
+			Thread:      t,
+			Post:        p,
+			Comment:     &c,
+			Reasons:     goreddit.ReportReasons,
+			Action:      "/comments/" + c.ID.String() + "/report",
+			CSRF:        csrf.TemplateField(r),
+			SessionData: GetSessionData(h.sessions, r.Context()),
+		})
+	}
+}
+
+func (h *CommentHandler) ReportSubmit() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		form := ReportForm{
+			Reason:  r.FormValue("reason"),
+			Details: r.FormValue("details"),
+		}
+		if !form.Validate() {
+			h.sessions.Put(r.Context(), "form", form)
+			http.Redirect(rw, r, r.Referer(), http.StatusFound)
+			return
+		}
+
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), c.PostID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
+		err = fileReport(r.Context(), h.store, p, &goreddit.Report{
+			ID:         uuid.New(),
+			PostID:     p.ID,
+			CommentID:  uuid.NullUUID{UUID: c.ID, Valid: true},
+			ReporterID: uuid.NullUUID{UUID: user.ID, Valid: true},
+			Reason:     goreddit.ReportReason(form.Reason),
+			Details:    form.Details,
+		})
+		if errors.Is(err, goreddit.ErrConflict) {
+			h.sessions.Put(r.Context(), "flash", "You have already reported this comment.")
+		} else if err != nil {
+			httpError(rw, r, err)
+			return
+		} else {
+			h.sessions.Put(r.Context(), "flash", "Thanks for your report. The moderators will review the comment.")
+		}
+
+		redirect_url := fmt.Sprintf("/threads/%s/posts/%s#comment-%s", p.ThreadID.String(), p.ID.String(), c.ID.String())
+		http.Redirect(rw, r, redirect_url, http.StatusFound)
+	}
+}
+
+// Remove, Approve and IgnoreReports settle the reports of the comment,
+// which takes it out of the mod queue.
+func (h *CommentHandler) Remove() http.HandlerFunc {
+	return h.moderate(goreddit.ModRemove, "The comment has been removed.", func(ctx context.Context, c goreddit.Comment) error {
+		if err := h.store.SetCommentRemoved(ctx, c.ID, true); err != nil {
+			return err
+		}
+		return h.store.DeleteCommentReports(ctx, c.ID)
+	})
+}
+
+func (h *CommentHandler) Approve() http.HandlerFunc {
+	return h.moderate(goreddit.ModApprove, "The comment has been approved.", func(ctx context.Context, c goreddit.Comment) error {
+		if err := h.store.SetCommentRemoved(ctx, c.ID, false); err != nil {
+			return err
+		}
+		return h.store.DeleteCommentReports(ctx, c.ID)
+	})
+}
+
+func (h *CommentHandler) IgnoreReports() http.HandlerFunc {
+	return h.moderate(goreddit.ModIgnoreReports, "The reports of the comment have been ignored.", func(ctx context.Context, c goreddit.Comment) error {
+		return h.store.DeleteCommentReports(ctx, c.ID)
+	})
+}
+
+// moderate returns a handler that lets the moderators of a thread apply
+// apply to a comment on one of its posts, records it in the mod log as action
+// and then flashes done.
+func (h *CommentHandler) moderate(action goreddit.ModAction, done string, apply func(ctx context.Context, c goreddit.Comment) error) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		c, err := h.store.Comment(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), c.PostID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		if !h.policy.For(r.Context()).Moderate(p.ThreadID) {
+			renderError(rw, r, http.StatusForbidden)
+			return
+		}
+
+		err = apply(r.Context(), c)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		entry := commentEntry(action, p, c)
+		entry.Reason = modReason(r)
+		if err := logModAction(r.Context(), h.store, entry); err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", done)
+
 		http.Redirect(rw, r, r.Referer(), http.StatusFound)
 	}
 }
@@ -0,0 +1,72 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aleury/goreddit"
+	"github.com/aleury/goreddit/memory"
+	"github.com/google/uuid"
+)
+
+func TestShowCommentUnderOtherPost(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	alice := newTestUser(t, store, "alice")
+	bob := newTestUser(t, store, "bob")
+
+	// Alice moderates her own thread, but not Bob's.
+	own := goreddit.Thread{ID: uuid.New(), Title: "Own", AuthorID: uuid.NullUUID{UUID: alice.ID, Valid: true}}
+	other := goreddit.Thread{ID: uuid.New(), Title: "Other", AuthorID: uuid.NullUUID{UUID: bob.ID, Valid: true}}
+	for _, th := range []*goreddit.Thread{&own, &other} {
+		if err := store.CreateThread(ctx, th); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ownPost := goreddit.Post{ID: uuid.New(), ThreadID: own.ID, Title: "Own post", Content: "Content"}
+	otherPost := goreddit.Post{ID: uuid.New(), ThreadID: other.ID, Title: "Other post", Content: "Content"}
+	siblingPost := goreddit.Post{ID: uuid.New(), ThreadID: other.ID, Title: "Sibling post", Content: "Content"}
+	for _, p := range []*goreddit.Post{&ownPost, &otherPost, &siblingPost} {
+		if err := store.CreatePost(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := goreddit.Comment{ID: uuid.New(), PostID: otherPost.ID, Content: "secret content"}
+	if err := store.CreateComment(ctx, &c); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetCommentRemoved(ctx, c.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions := NewMemorySessionManager()
+	comments := CommentHandler{store: store, sessions: sessions, policy: &Policy{store: store}, commentDepth: defaultCommentDepth}
+	show := func(threadID, postID uuid.UUID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/threads/"+threadID.String()+"/posts/"+postID.String()+"/comments/"+c.ID.String(), nil)
+		return serveRoute(sessions, "/threads/{threadId}/posts/{postId}/comments/{id}", comments.Show(), &alice, req)
+	}
+
+	for name, rec := range map[string]*httptest.ResponseRecorder{
+		"a moderated thread": show(own.ID, ownPost.ID),
+		"another post":       show(other.ID, siblingPost.ID),
+		"another thread":     show(own.ID, otherPost.ID),
+	} {
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("comment under %s: status = %d, want 404", name, rec.Code)
+		}
+		if strings.Contains(rec.Body.String(), "secret content") {
+			t.Errorf("removed comment shown under %s", name)
+		}
+	}
+
+	rec := show(other.ID, otherPost.ID)
+	if rec.Code != http.StatusOK {
+		t.Errorf("comment under its own post: status = %d, want 200", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "secret content") {
+		t.Error("removed comment shown to a user who does not moderate the thread")
+	}
+}
@@ -0,0 +1,107 @@
+package web
+
+import (
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/aleury/goreddit"
+)
+
+var (
+	errorTemplate     *template.Template
+	errorTemplateOnce sync.Once
+	errorTemplateErr  error
+)
+
+// statusFor maps an error returned by the store onto an HTTP status code.
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, goreddit.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, goreddit.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, goreddit.ErrInvalidReference):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, goreddit.ErrInvalidCursor):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// httpError responds to a failed store call with the error page matching the
+// error. Internal errors are logged rather than shown to the user.
+func httpError(rw http.ResponseWriter, r *http.Request, err error) {
+	status := statusFor(err)
+	if status == http.StatusInternalServerError {
+		log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+	}
+	renderError(rw, r, status)
+}
+
+// renderError writes the error page for status.
+func renderError(rw http.ResponseWriter, r *http.Request, status int) {
+	renderErrorPage(rw, r, status, nil)
+}
+
+// renderBanned writes the error page telling the user about the ban that
+// keeps them from doing what they asked.
+func renderBanned(rw http.ResponseWriter, r *http.Request, ban goreddit.Ban) {
+	renderErrorPage(rw, r, http.StatusForbidden, &ban)
+}
+
+func renderErrorPage(rw http.ResponseWriter, r *http.Request, status int, ban *goreddit.Ban) {
+	type data struct {
+		SessionData
+
+		Status  int
+		Message string
+		Ban     *goreddit.Ban
+	}
+
+	errorTemplateOnce.Do(func() {
+		errorTemplate, errorTemplateErr = parseTemplates(
+			"layout.html",
+			"error.html",
+			"ban_notice.html",
+		)
+	})
+	if errorTemplateErr != nil {
+		http.Error(rw, http.StatusText(status), status)
+		return
+	}
+
+	user, loggedIn := userFromContext(r.Context())
+
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	rw.WriteHeader(status)
+	errorTemplate.Execute(rw, data{
+		Status:  status,
+		Message: errorMessage(status),
+		Ban:     ban,
+		SessionData: SessionData{
+			Form:     map[string]string{},
+			User:     user,
+			LoggedIn: loggedIn,
+			Features: featuresFromContext(r.Context()),
+		},
+	})
+}
+
+func errorMessage(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "Your request could not be understood."
+	case http.StatusNotFound:
+		return "The page you were looking for doesn't exist or has been deleted."
+	case http.StatusConflict:
+		return "Your changes conflict with something that already exists."
+	case http.StatusUnprocessableEntity:
+		return "Something you referred to no longer exists."
+	case http.StatusForbidden:
+		return "You are not allowed to do that."
+	}
+	return "Something went wrong on our end. Please try again later."
+}
@@ -1,6 +1,12 @@
 package web
 
-import "encoding/gob"
+import (
+	"encoding/gob"
+	"fmt"
+	"strings"
+
+	"github.com/aleury/goreddit"
+)
 
 func init() {
 	gob.Register(RegisterForm{})
@@ -8,6 +14,13 @@ func init() {
 	gob.Register(CreateThreadForm{})
 	gob.Register(CreatePostForm{})
 	gob.Register(CreateCommentForm{})
+	gob.Register(EditThreadForm{})
+	gob.Register(EditPostForm{})
+	gob.Register(EditCommentForm{})
+	gob.Register(CreateTokenForm{})
+	gob.Register(InviteModeratorForm{})
+	gob.Register(ReportForm{})
+	gob.Register(BanForm{})
 	gob.Register(FormErrors{})
 }
 
@@ -118,3 +131,184 @@ func (f *CreateCommentForm) Validate() bool {
 
 	return len(f.Errors) == 0
 }
+
+type EditThreadForm struct {
+	Title       string
+	Description string
+
+	Errors FormErrors
+}
+
+func (f *EditThreadForm) Validate() bool {
+	f.Errors = FormErrors{}
+
+	if f.Title == "" {
+		f.Errors["Title"] = "Please enter a title."
+	}
+	if f.Description == "" {
+		f.Errors["Description"] = "Please enter some text."
+	}
+
+	return len(f.Errors) == 0
+}
+
+type EditPostForm struct {
+	Title   string
+	Content string
+
+	Errors FormErrors
+}
+
+func (f *EditPostForm) Validate() bool {
+	f.Errors = FormErrors{}
+
+	if f.Title == "" {
+		f.Errors["Title"] = "Please enter a title."
+	}
+	if f.Content == "" {
+		f.Errors["Content"] = "Please enter some text."
+	}
+
+	return len(f.Errors) == 0
+}
+
+type EditCommentForm struct {
+	Content string
+
+	Errors FormErrors
+}
+
+func (f *EditCommentForm) Validate() bool {
+	f.Errors = FormErrors{}
+
+	if f.Content == "" {
+		f.Errors["Content"] = "Please enter some text."
+	}
+
+	return len(f.Errors) == 0
+}
+
+type CreateTokenForm struct {
+	Name   string
+	Scopes []string
+
+	Errors FormErrors
+}
+
+func (f *CreateTokenForm) Validate() bool {
+	f.Errors = FormErrors{}
+
+	if f.Name == "" {
+		f.Errors["Name"] = "Please enter a name."
+	}
+
+	if len(f.Scopes) == 0 {
+		f.Errors["Scopes"] = "Please choose at least one scope."
+	}
+	for _, scope := range f.Scopes {
+		if !goreddit.Scope(scope).Valid() {
+			f.Errors["Scopes"] = "Please choose only the scopes listed."
+		}
+	}
+
+	return len(f.Errors) == 0
+}
+
+// HasScope reports whether scope is checked, for redisplaying the form.
+func (f CreateTokenForm) HasScope(scope goreddit.Scope) bool {
+	for _, s := range f.Scopes {
+		if s == string(scope) {
+			return true
+		}
+	}
+	return false
+}
+
+type InviteModeratorForm struct {
+	Username         string
+	UnknownUser      bool
+	AlreadyModerator bool
+	AlreadyInvited   bool
+
+	Errors FormErrors
+}
+
+func (f *InviteModeratorForm) Validate() bool {
+	f.Errors = FormErrors{}
+
+	if f.Username == "" {
+		f.Errors["Username"] = "Please enter a username."
+	} else if f.UnknownUser {
+		f.Errors["Username"] = "There is no user with this username."
+	} else if f.AlreadyModerator {
+		f.Errors["Username"] = "This user is already a moderator."
+	} else if f.AlreadyInvited {
+		f.Errors["Username"] = "This user has already been invited."
+	}
+
+	return len(f.Errors) == 0
+}
+
+// maxReportDetails limits the length of the details of a report.
+const maxReportDetails = 500
+
+type ReportForm struct {
+	Reason  string
+	Details string
+
+	Errors FormErrors
+}
+
+func (f *ReportForm) Validate() bool {
+	f.Errors = FormErrors{}
+
+	if !goreddit.ReportReason(f.Reason).Valid() {
+		f.Errors["Reason"] = "Please choose a reason."
+	}
+
+	if goreddit.ReportReason(f.Reason) == goreddit.ReportOther && strings.TrimSpace(f.Details) == "" {
+		f.Errors["Details"] = "Please tell the moderators what is wrong."
+	} else if len(f.Details) > maxReportDetails {
+		f.Errors["Details"] = fmt.Sprintf("Please keep the details under %d characters.", maxReportDetails)
+	}
+
+	return len(f.Errors) == 0
+}
+
+type BanForm struct {
+	Username      string
+	Duration      string
+	Reason        string
+	UnknownUser   bool
+	Moderator     bool
+	Admin         bool
+	AlreadyBanned bool
+
+	Errors FormErrors
+}
+
+func (f *BanForm) Validate() bool {
+	f.Errors = FormErrors{}
+
+	if f.Username == "" {
+		f.Errors["Username"] = "Please enter a username."
+	} else if f.UnknownUser {
+		f.Errors["Username"] = "There is no user with this username."
+	} else if f.Admin {
+		f.Errors["Username"] = "Admins cannot be banned."
+	} else if f.Moderator {
+		f.Errors["Username"] = "Moderators cannot be banned from their thread."
+	} else if f.AlreadyBanned {
+		f.Errors["Username"] = "This user is already banned."
+	}
+
+	if _, ok := findBanDuration(f.Duration); !ok {
+		f.Errors["Duration"] = "Please choose how long the ban lasts."
+	}
+
+	if len(f.Reason) > maxModReason {
+		f.Errors["Reason"] = fmt.Sprintf("Please keep the reason under %d characters.", maxModReason)
+	}
+
+	return len(f.Errors) == 0
+}
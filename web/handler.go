@@ -12,65 +12,251 @@ import (
 	"github.com/gorilla/csrf"
 )
 
+// defaultCommentDepth is how many levels of replies are shown below a comment
+// before linking to the rest of the discussion.
+const defaultCommentDepth = 5
+
+// defaultPageSize is how many threads or posts are listed on a page unless
+// the limit query parameter asks for another number.
+const defaultPageSize = goreddit.DefaultPageSize
+
+// defaultThreads is how many of the threads with the most subscribers make
+// up the front page of visitors who are not logged in.
+const defaultThreads = 10
+
+// Options configure a Handler.
+type Options struct {
+	// CSRFKey authenticates the CSRF cookie and must be 32 bytes long.
+	CSRFKey []byte
+	// SecureCookies restricts the CSRF cookie to HTTPS. The session cookie
+	// is configured on the session manager.
+	SecureCookies bool
+	// TemplateDir is the directory holding the templates, "templates" if
+	// it is empty.
+	TemplateDir string
+	// LogRequests logs every request, not just the ones that fail.
+	LogRequests bool
+
+	Features Features
+}
+
+// Features are the parts of the site that can be turned off.
+type Features struct {
+	// Registration lets visitors create accounts, on the site and through
+	// the API.
+	Registration bool
+	// API serves the JSON API and the settings page for API tokens.
+	API bool
+}
+
 type Handler struct {
 	*chi.Mux
 	store    goreddit.Store
 	sessions *scs.SessionManager
 }
 
-func NewHandler(store goreddit.Store, sessions *scs.SessionManager, csrfKey []byte) *Handler {
+func NewHandler(store goreddit.Store, sessions *scs.SessionManager, opts Options) *Handler {
+	if opts.TemplateDir != "" {
+		templateDir = opts.TemplateDir
+	}
+
 	h := &Handler{
 		Mux:      chi.NewMux(),
 		store:    store,
 		sessions: sessions,
 	}
 
-	pages := PageHandler{store: store, sessions: sessions}
-	threads := ThreadHandler{store: store, sessions: sessions}
-	posts := PostHandler{store: store, sessions: sessions}
-	comments := CommentHandler{store: store, sessions: sessions}
+	policy := &Policy{store: store}
+
+	pages := PageHandler{store: store, sessions: sessions, pageSize: defaultPageSize, defaultThreads: defaultThreads}
+	threads := ThreadHandler{store: store, sessions: sessions, policy: policy, pageSize: defaultPageSize}
+	posts := PostHandler{store: store, sessions: sessions, policy: policy, commentDepth: defaultCommentDepth}
+	comments := CommentHandler{store: store, sessions: sessions, policy: policy, commentDepth: defaultCommentDepth}
 	users := UserHandler{store: store, sessions: sessions}
+	settings := SettingsHandler{store: store, sessions: sessions}
+	search := SearchHandler{store: store, sessions: sessions, pageSize: defaultPageSize}
+	modlog := ModLogHandler{store: store, sessions: sessions, pageSize: defaultPageSize}
+	admin := AdminHandler{store: store, sessions: sessions, pageSize: defaultPageSize}
+	api := APIHandler{store: store, policy: policy, pageSize: defaultPageSize}
 
-	h.Use(middleware.Logger)
+	if opts.LogRequests {
+		h.Use(middleware.Logger)
+	}
 
-	// TODO: This is for development purposes only.
-	// Set Secure flag to true when deploying to prod.
-	h.Use(csrf.Protect(csrfKey, csrf.Secure(false)))
+	h.Use(skipCSRFForTokens)
+	h.Use(csrf.Protect(opts.CSRFKey, csrf.Secure(opts.SecureCookies), csrf.ErrorHandler(http.HandlerFunc(csrfFailure))))
 
 	h.Use(sessions.LoadAndSave)
+	h.Use(withFeatures(opts.Features))
 	h.Use(h.withUser)
 
 	h.Get("/", pages.Home())
-	h.Get("/register", users.Register())
-	h.Post("/register", users.RegisterSubmit())
+	h.Get("/all", pages.All())
+	if opts.Features.Registration {
+		h.Get("/register", users.Register())
+		h.Post("/register", users.RegisterSubmit())
+	}
 	h.Get("/login", users.Login())
 	h.Post("/login", users.LoginSubmit())
 	h.Get("/logout", users.Logout())
+	h.With(h.requireUser).Post("/markdown/preview", pages.Preview())
+	h.Get("/search", search.Search())
 
 	h.Route("/threads", func(r chi.Router) {
 		r.Get("/", threads.List())
-		r.Get("/new", threads.New())
-		r.Post("/", threads.Create())
+		r.With(h.requireUser).Get("/new", threads.New())
+		r.With(h.requireUser).Post("/", threads.Create())
 		r.Get("/{id}", threads.Show())
-		r.Post("/{id}/delete", threads.Delete())
+		r.With(h.requireUser).Get("/{id}/edit", threads.Edit())
+		r.With(h.requireUser).Post("/{id}/edit", threads.Update())
+		r.With(h.requireUser).Post("/{id}/delete", threads.Delete())
+		r.With(h.requireUser).Post("/{id}/subscribe", threads.Subscribe())
+		r.With(h.requireUser).Post("/{id}/unsubscribe", threads.Unsubscribe())
+		r.With(h.requireUser).Post("/{id}/moderators", threads.InviteModerator())
+		r.With(h.requireUser).Post("/{id}/moderators/accept", threads.AcceptInvite())
+		r.With(h.requireUser).Post("/{id}/moderators/decline", threads.DeclineInvite())
+		r.With(h.requireUser).Post("/{id}/moderators/{userId}/remove", threads.RemoveModerator())
+		r.With(h.requireUser).Get("/{id}/queue", threads.Queue())
+		r.Get("/{id}/modlog", modlog.Thread())
+		r.With(h.requireUser).Get("/{id}/bans", threads.Bans())
+		r.With(h.requireUser).Post("/{id}/bans", threads.CreateBan())
+		r.With(h.requireUser).Post("/{id}/bans/{banId}/lift", threads.LiftBan())
 
-		r.Get("/{threadId}/posts/new", posts.New())
-		r.Post("/{threadId}/posts", posts.Create())
+		r.With(h.requireUser).Get("/{threadId}/posts/new", posts.New())
+		r.With(h.requireUser).Post("/{threadId}/posts", posts.Create())
 		r.Get("/{threadId}/posts/{postId}", posts.Show())
-		r.Get("/{threadId}/posts/{postId}/vote", posts.Vote())
+		r.With(h.requireUser).Post("/{threadId}/posts/{postId}/vote", posts.Vote())
+		r.With(h.requireUser).Get("/{threadId}/posts/{postId}/edit", posts.Edit())
+		r.With(h.requireUser).Post("/{threadId}/posts/{postId}/edit", posts.Update())
+		r.With(h.requireUser).Post("/{threadId}/posts/{postId}/delete", posts.Delete())
+		r.With(h.requireUser).Post("/{threadId}/posts/{postId}/remove", posts.Remove())
+		r.With(h.requireUser).Post("/{threadId}/posts/{postId}/approve", posts.Approve())
+		r.With(h.requireUser).Post("/{threadId}/posts/{postId}/lock", posts.Lock())
+		r.With(h.requireUser).Post("/{threadId}/posts/{postId}/unlock", posts.Unlock())
+		r.With(h.requireUser).Post("/{threadId}/posts/{postId}/pin", posts.Pin())
+		r.With(h.requireUser).Post("/{threadId}/posts/{postId}/unpin", posts.Unpin())
+		r.With(h.requireUser).Post("/{threadId}/posts/{postId}/ignore", posts.IgnoreReports())
+		r.With(h.requireUser).Get("/{threadId}/posts/{postId}/report", posts.Report())
+		r.With(h.requireUser).Post("/{threadId}/posts/{postId}/report", posts.ReportSubmit())
 
-		r.Post("/{threadId}/posts/{postId}/comments", comments.Create())
+		r.With(h.requireUser).Post("/{threadId}/posts/{postId}/comments", comments.Create())
+		r.Get("/{threadId}/posts/{postId}/comments/{id}", comments.Show())
+		r.With(h.requireUser).Get("/{threadId}/posts/{postId}/comments/{id}/reply", comments.Reply())
+		r.With(h.requireUser).Post("/{threadId}/posts/{postId}/comments/{id}/reply", comments.ReplySubmit())
+	})
+	h.Route("/admin", func(r chi.Router) {
+		r.Use(h.requireUser, h.requireAdmin)
+		r.Get("/", admin.Dashboard())
+		r.Get("/users", admin.Users())
+		r.Post("/users/{id}/promote", admin.Promote())
+		r.Post("/users/{id}/demote", admin.Demote())
+		r.Get("/threads", admin.Threads())
+		r.Get("/posts", admin.Posts())
+		r.Get("/comments", admin.Comments())
+		r.Get("/bans", admin.Bans())
+		r.Post("/bans", admin.CreateBan())
+		r.Post("/bans/{id}/lift", admin.LiftBan())
+		r.Get("/modlog", modlog.All())
+	})
+	h.Route("/comments/{id}", func(r chi.Router) {
+		r.Use(h.requireUser)
+		r.Post("/vote", comments.Vote())
+		r.Get("/edit", comments.Edit())
+		r.Post("/edit", comments.Update())
+		r.Post("/delete", comments.Delete())
+		r.Post("/remove", comments.Remove())
+		r.Post("/approve", comments.Approve())
+		r.Post("/ignore", comments.IgnoreReports())
+		r.Get("/report", comments.Report())
+		r.Post("/report", comments.ReportSubmit())
 	})
-	h.Get("/comments/{id}/vote", comments.Vote())
+
+	// The settings only manage API tokens so far.
+	if opts.Features.API {
+		h.Route("/settings", func(r chi.Router) {
+			r.Use(h.requireUser)
+			r.Get("/tokens", settings.Tokens())
+			r.Post("/tokens", settings.CreateToken())
+			r.Post("/tokens/{id}/delete", settings.DeleteToken())
+		})
+		h.Route("/api/v1", func(r chi.Router) {
+			r.Use(h.withToken)
+			r.Use(withCSRFToken)
+			r.NotFound(func(rw http.ResponseWriter, r *http.Request) {
+				writeAPIError(rw, http.StatusNotFound, nil)
+			})
+			r.MethodNotAllowed(func(rw http.ResponseWriter, r *http.Request) {
+				writeAPIError(rw, http.StatusMethodNotAllowed, nil)
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(requireScope(goreddit.ScopeRead))
+				r.Get("/threads", api.ListThreads())
+				r.Get("/threads/{id}", api.ShowThread())
+				r.Get("/threads/{id}/posts", api.ListThreadPosts())
+				r.Get("/threads/{id}/moderators", api.ListModerators())
+				r.Get("/posts", api.ListPosts())
+				r.Get("/posts/{id}", api.ShowPost())
+				r.Get("/posts/{id}/comments", api.ListComments())
+				r.Get("/comments/{id}", api.ShowComment())
+				r.Get("/users/{username}", api.ShowUser())
+				r.With(requireAPIUser).Get("/posts/{id}/vote", api.ShowPostVote())
+				r.With(requireAPIUser).Get("/comments/{id}/vote", api.ShowCommentVote())
+				r.With(requireAPIUser).Get("/me", api.ShowMe())
+			})
+
+			if opts.Features.Registration {
+				r.Group(func(r chi.Router) {
+					r.Use(requireScope(goreddit.ScopeWrite))
+					r.Post("/users", api.CreateUser())
+				})
+			}
+
+			r.Group(func(r chi.Router) {
+				r.Use(requireAPIUser, requireScope(goreddit.ScopeWrite))
+				r.Post("/threads", api.CreateThread())
+				r.Patch("/threads/{id}", api.UpdateThread())
+				r.Delete("/threads/{id}", api.DeleteThread())
+				r.Post("/threads/{id}/posts", api.CreatePost())
+				r.Patch("/posts/{id}", api.UpdatePost())
+				r.Delete("/posts/{id}", api.DeletePost())
+				r.Post("/posts/{id}/comments", api.CreateComment())
+				r.Patch("/comments/{id}", api.UpdateComment())
+				r.Delete("/comments/{id}", api.DeleteComment())
+				r.Patch("/me", api.UpdateMe())
+				r.Delete("/me", api.DeleteMe())
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(requireAPIUser, requireScope(goreddit.ScopeVote))
+				r.Put("/posts/{id}/vote", api.CastPostVote())
+				r.Put("/comments/{id}/vote", api.CastCommentVote())
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(requireAPIUser, requireScope(goreddit.ScopeModerate))
+				r.Put("/posts/{id}/moderation", api.ModeratePost())
+				r.Put("/comments/{id}/moderation", api.ModerateComment())
+			})
+		})
+	}
 
 	return h
 }
 
 func (h *Handler) withUser(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		// Requests with a bearer token skip CSRF checks, so they must not
+		// act as the user of the session cookie that came along with them.
+		// The API authenticates them by their token instead.
+		if hasBearerToken(r) {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
 		userId, _ := h.sessions.Get(r.Context(), "user_id").(uuid.UUID)
 
-		user, err := h.store.User(userId)
+		user, err := h.store.User(r.Context(), userId)
 		if err != nil {
 			next.ServeHTTP(rw, r)
 			return
@@ -80,3 +266,51 @@ func (h *Handler) withUser(next http.Handler) http.Handler {
 		next.ServeHTTP(rw, r.WithContext(ctx))
 	})
 }
+
+// withFeatures makes the enabled features known to the templates through
+// SessionData.
+func withFeatures(f Features) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), ctxKey("features"), f)
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
+
+func (h *Handler) requireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if _, ok := userFromContext(r.Context()); !ok {
+			h.sessions.Put(r.Context(), "flash", "You need to be logged in to do that.")
+			http.Redirect(rw, r, "/login", http.StatusFound)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// requireAdmin turns away users who are not admins. It must come after
+// requireUser.
+func (h *Handler) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if user, _ := userFromContext(r.Context()); !user.IsAdmin() {
+			renderError(rw, r, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// voteValue converts the dir field of a vote form into the value
+// recorded in the vote ledger.
+func voteValue(dir string) (int, bool) {
+	switch dir {
+	case "up":
+		return 1, true
+	case "down":
+		return -1, true
+	}
+	return 0, false
+}
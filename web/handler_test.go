@@ -0,0 +1,62 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aleury/goreddit"
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func init() {
+	// Tests run in the package directory, below the templates.
+	templateDir = "../templates"
+}
+
+func newTestUser(t *testing.T, store goreddit.Store, username string) goreddit.User {
+	t.Helper()
+	u := goreddit.User{ID: uuid.New(), Username: username}
+	if err := store.CreateUser(context.Background(), &u); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+// serveRoute serves req with h mounted at pattern, as user if it is not nil.
+func serveRoute(sessions *scs.SessionManager, pattern string, h http.HandlerFunc, user *goreddit.User, req *http.Request) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Use(sessions.LoadAndSave)
+	if user != nil {
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				ctx := context.WithValue(r.Context(), ctxKey("user"), *user)
+				next.ServeHTTP(rw, r.WithContext(ctx))
+			})
+		})
+	}
+	r.Method(req.Method, pattern, h)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+// loginCookie returns a session cookie that logs requests to a handler using
+// sessions in as user.
+func loginCookie(t *testing.T, sessions *scs.SessionManager, user goreddit.User) *http.Cookie {
+	t.Helper()
+	ctx, err := sessions.Load(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions.Put(ctx, "user_id", user.ID)
+	token, _, err := sessions.Commit(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Cookie{Name: sessions.Cookie.Name, Value: token}
+}
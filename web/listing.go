@@ -0,0 +1,101 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/aleury/goreddit"
+)
+
+// commentSorts are the sorts offered for comments. Rising is left out since
+// comment trees are never limited to recent comments.
+var commentSorts = []goreddit.Sort{
+	goreddit.SortTop,
+	goreddit.SortHot,
+	goreddit.SortNew,
+	goreddit.SortControversial,
+}
+
+// sortTabs describes the sort tabs shown above a listing. Windows is empty
+// for listings that cannot be limited to a time window.
+type sortTabs struct {
+	Sorts   []goreddit.Sort
+	Windows []goreddit.TimeWindow
+	Sort    goreddit.Sort
+	Window  goreddit.TimeWindow
+}
+
+// listOptions reads the sort, t, cursor and limit query parameters of r,
+// falling back to def for a missing or unknown sort, to all time for a
+// missing or unknown window and to pageSize for a missing or invalid limit.
+func listOptions(r *http.Request, def goreddit.Sort, pageSize int) goreddit.ListOptions {
+	opts := goreddit.ListOptions{
+		Sort:        goreddit.Sort(r.URL.Query().Get("sort")),
+		Window:      goreddit.TimeWindow(r.URL.Query().Get("t")),
+		PageOptions: pageOptions(r, pageSize),
+	}
+	if !opts.Sort.Valid() {
+		opts.Sort = def
+	}
+	if !opts.Window.Valid() {
+		opts.Window = goreddit.WindowAll
+	}
+	return opts
+}
+
+// postTabs returns the tabs for a post listing sorted with opts.
+func postTabs(opts goreddit.ListOptions) sortTabs {
+	return sortTabs{
+		Sorts:   goreddit.Sorts,
+		Windows: goreddit.TimeWindows,
+		Sort:    opts.Sort,
+		Window:  opts.Window,
+	}
+}
+
+// commentTabs returns the tabs for a comment tree sorted by sort.
+func commentTabs(sort goreddit.Sort) sortTabs {
+	return sortTabs{Sorts: commentSorts, Sort: sort}
+}
+
+// commentSort reads the sort query parameter of a comment tree, falling back
+// to top for a missing sort or one not offered for comments.
+func commentSort(r *http.Request) goreddit.Sort {
+	sort := goreddit.Sort(r.URL.Query().Get("sort"))
+	for _, s := range commentSorts {
+		if sort == s {
+			return sort
+		}
+	}
+	return goreddit.SortTop
+}
+
+// pageOptions reads the cursor and limit query parameters of r, falling back
+// to pageSize for a missing or invalid limit.
+func pageOptions(r *http.Request, pageSize int) goreddit.PageOptions {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = pageSize
+	}
+	return goreddit.PageOptions{Cursor: r.URL.Query().Get("cursor"), Limit: limit}
+}
+
+// pager holds the links to the pages around a page of a listing.
+type pager struct {
+	Next string
+	Prev string
+}
+
+// pageLinks returns the links to the pages around page, keeping the other
+// query parameters of r.
+func pageLinks(r *http.Request, page goreddit.Page) pager {
+	link := func(cursor string) string {
+		if cursor == "" {
+			return ""
+		}
+		q := r.URL.Query()
+		q.Set("cursor", cursor)
+		return "?" + q.Encode()
+	}
+	return pager{Next: link(page.Next), Prev: link(page.Prev)}
+}
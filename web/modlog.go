@@ -0,0 +1,122 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+)
+
+// maxModReason limits the length of the reason given for a privileged
+// action.
+const maxModReason = 300
+
+// maxTargetLabel limits the length of the comment excerpt recorded as the
+// target of a log entry.
+const maxTargetLabel = 80
+
+// logModAction records a privileged action taken by the user making the
+// request with ctx. Actions taken without a user are recorded as automatic.
+func logModAction(ctx context.Context, store goreddit.Store, e goreddit.ModLogEntry) error {
+	e.ID = uuid.New()
+	if user, ok := userFromContext(ctx); ok {
+		e.ActorID = uuid.NullUUID{UUID: user.ID, Valid: true}
+		e.ActorUsername = user.Username
+	}
+	return store.CreateModLogEntry(ctx, &e)
+}
+
+// modReason returns the reason the form of r gives for a privileged action.
+func modReason(r *http.Request) string {
+	return truncate(strings.TrimSpace(r.FormValue("reason")), maxModReason)
+}
+
+func threadEntry(action goreddit.ModAction, t goreddit.Thread) goreddit.ModLogEntry {
+	return goreddit.ModLogEntry{
+		ThreadID:    uuid.NullUUID{UUID: t.ID, Valid: true},
+		ThreadTitle: t.Title,
+		Action:      action,
+		TargetKind:  goreddit.ModTargetThread,
+		TargetID:    t.ID,
+		TargetLabel: t.Title,
+	}
+}
+
+func postEntry(action goreddit.ModAction, p goreddit.Post) goreddit.ModLogEntry {
+	return goreddit.ModLogEntry{
+		ThreadID:    uuid.NullUUID{UUID: p.ThreadID, Valid: true},
+		ThreadTitle: p.ThreadTitle,
+		Action:      action,
+		TargetKind:  goreddit.ModTargetPost,
+		TargetID:    p.ID,
+		TargetLabel: p.Title,
+		PostID:      uuid.NullUUID{UUID: p.ID, Valid: true},
+	}
+}
+
+// commentEntry returns an entry for an action on the comment c on post p.
+func commentEntry(action goreddit.ModAction, p goreddit.Post, c goreddit.Comment) goreddit.ModLogEntry {
+	return goreddit.ModLogEntry{
+		ThreadID:    uuid.NullUUID{UUID: p.ThreadID, Valid: true},
+		ThreadTitle: p.ThreadTitle,
+		Action:      action,
+		TargetKind:  goreddit.ModTargetComment,
+		TargetID:    c.ID,
+		TargetLabel: truncate(strings.Join(strings.Fields(c.Content), " "), maxTargetLabel),
+		PostID:      uuid.NullUUID{UUID: p.ID, Valid: true},
+	}
+}
+
+// userEntry returns an entry for an action on the user u in thread t.
+func userEntry(action goreddit.ModAction, t goreddit.Thread, u goreddit.User) goreddit.ModLogEntry {
+	return goreddit.ModLogEntry{
+		ThreadID:    uuid.NullUUID{UUID: t.ID, Valid: true},
+		ThreadTitle: t.Title,
+		Action:      action,
+		TargetKind:  goreddit.ModTargetUser,
+		TargetID:    u.ID,
+		TargetLabel: u.Username,
+	}
+}
+
+// siteUserEntry returns an entry for a site-wide action on the user u.
+func siteUserEntry(action goreddit.ModAction, u goreddit.User) goreddit.ModLogEntry {
+	return goreddit.ModLogEntry{
+		Action:      action,
+		TargetKind:  goreddit.ModTargetUser,
+		TargetID:    u.ID,
+		TargetLabel: u.Username,
+	}
+}
+
+// modLogURL returns the link to the target of a mod log entry, or an empty
+// string if it has no page.
+func modLogURL(e goreddit.ModLogEntry) string {
+	switch {
+	case e.Action == goreddit.ModDeleteThread || !e.ThreadID.Valid:
+		return ""
+	case e.Action == goreddit.ModDeletePost:
+		return fmt.Sprintf("/threads/%s", e.ThreadID.UUID)
+	case e.Action == goreddit.ModDeleteComment && e.PostID.Valid:
+		return fmt.Sprintf("/threads/%s/posts/%s", e.ThreadID.UUID, e.PostID.UUID)
+	case e.TargetKind == goreddit.ModTargetThread:
+		return fmt.Sprintf("/threads/%s", e.TargetID)
+	case e.TargetKind == goreddit.ModTargetPost:
+		return fmt.Sprintf("/threads/%s/posts/%s", e.ThreadID.UUID, e.TargetID)
+	case e.TargetKind == goreddit.ModTargetComment && e.PostID.Valid:
+		return fmt.Sprintf("/threads/%s/posts/%s/comments/%s", e.ThreadID.UUID, e.PostID.UUID, e.TargetID)
+	}
+	return ""
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
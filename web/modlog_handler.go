@@ -0,0 +1,130 @@
+package web
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/aleury/goreddit"
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type ModLogHandler struct {
+	store    goreddit.Store
+	sessions *scs.SessionManager
+	pageSize int
+}
+
+// modLogQuery holds the filters of a mod log page, for filling in the filter
+// form again.
+type modLogQuery struct {
+	Action string
+	Target string
+	Actor  string
+	Thread string
+}
+
+// modLogOptions reads the action, target, actor, thread, cursor and limit
+// query parameters of r. Unknown actions and target kinds and malformed
+// thread IDs are ignored.
+func modLogOptions(r *http.Request, pageSize int) (goreddit.ModLogOptions, modLogQuery) {
+	query := r.URL.Query()
+	q := modLogQuery{
+		Action: query.Get("action"),
+		Target: query.Get("target"),
+		Actor:  query.Get("actor"),
+		Thread: query.Get("thread"),
+	}
+	opts := goreddit.ModLogOptions{
+		Actor:       q.Actor,
+		PageOptions: pageOptions(r, pageSize),
+	}
+
+	if action := goreddit.ModAction(q.Action); action.Valid() {
+		opts.Action = action
+	} else {
+		q.Action = ""
+	}
+	if target := goreddit.ModTarget(q.Target); target.Valid() {
+		opts.Target = target
+	} else {
+		q.Target = ""
+	}
+	if id, err := uuid.Parse(q.Thread); err == nil {
+		opts.ThreadID = uuid.NullUUID{UUID: id, Valid: true}
+	} else {
+		q.Thread = ""
+	}
+
+	return opts, q
+}
+
+// Thread lists the mod log of a thread. Like the thread itself, it is public.
+func (h *ModLogHandler) Thread() http.HandlerFunc {
+	return h.list(true)
+}
+
+// All lists the mod log of every thread, along with site-wide actions like
+// site bans and admin promotions. It is only for site admins.
+func (h *ModLogHandler) All() http.HandlerFunc {
+	return h.list(false)
+}
+
+// list returns a handler listing the mod log. If inThread is set, the log is
+// that of the thread named by the id URL parameter.
+func (h *ModLogHandler) list(inThread bool) http.HandlerFunc {
+	type data struct {
+		SessionData
+
+		Query   modLogQuery
+		Actions []goreddit.ModAction
+		Targets []goreddit.ModTarget
+		Thread  *goreddit.Thread
+		Entries []goreddit.ModLogEntry
+		Pager   pager
+	}
+
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"modlog.html",
+		"pager.html",
+		"admin_nav.html",
+	))
+	return func(rw http.ResponseWriter, r *http.Request) {
+		opts, q := modLogOptions(r, h.pageSize)
+
+		var thread *goreddit.Thread
+		if inThread {
+			id, err := uuid.Parse(chi.URLParam(r, "id"))
+			if err != nil {
+				renderError(rw, r, http.StatusNotFound)
+				return
+			}
+			t, err := h.store.Thread(r.Context(), id)
+			if err != nil {
+				httpError(rw, r, err)
+				return
+			}
+			thread = &t
+			opts.ThreadID = uuid.NullUUID{UUID: t.ID, Valid: true}
+			q.Thread = ""
+		}
+
+		ee, page, err := h.store.ModLog(r.Context(), opts)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		tmpl.Execute(rw, data{
+			Query:       q,
+			Actions:     goreddit.ModActions,
+			Targets:     goreddit.ModTargets,
+			Thread:      thread,
+			Entries:     ee,
+			Pager:       pageLinks(r, page),
+			SessionData: GetSessionData(h.sessions, r.Context()),
+		})
+	}
+}
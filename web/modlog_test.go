@@ -0,0 +1,112 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aleury/goreddit"
+	"github.com/aleury/goreddit/memory"
+	"github.com/google/uuid"
+)
+
+func TestPostActionLogsThreadTitle(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	alice := newTestUser(t, store, "alice")
+
+	th := goreddit.Thread{ID: uuid.New(), Title: "Gophers", AuthorID: uuid.NullUUID{UUID: alice.ID, Valid: true}}
+	if err := store.CreateThread(ctx, &th); err != nil {
+		t.Fatal(err)
+	}
+	p := goreddit.Post{ID: uuid.New(), ThreadID: th.ID, Title: "Spam", Content: "Spam"}
+	if err := store.CreatePost(ctx, &p); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions := NewMemorySessionManager()
+	posts := PostHandler{store: store, sessions: sessions, policy: &Policy{store: store}, commentDepth: defaultCommentDepth}
+	req := httptest.NewRequest(http.MethodPost, "/threads/"+th.ID.String()+"/posts/"+p.ID.String()+"/remove", nil)
+	rec := serveRoute(sessions, "/threads/{threadId}/posts/{postId}/remove", posts.Remove(), &alice, req)
+	if rec.Code != http.StatusFound {
+		t.Fatalf("remove: status = %d, want 302", rec.Code)
+	}
+
+	ee, _, err := store.ModLog(ctx, goreddit.ModLogOptions{ThreadID: uuid.NullUUID{UUID: th.ID, Valid: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ee) != 1 || ee[0].Action != goreddit.ModRemove {
+		t.Fatalf("ModLog = %+v, want the removal", ee)
+	}
+	if ee[0].ThreadTitle != th.Title {
+		t.Errorf("logged ThreadTitle = %q, want %q", ee[0].ThreadTitle, th.Title)
+	}
+}
+
+func TestSiteModLogIsForAdmins(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	alice := newTestUser(t, store, "alice")
+	root := newTestUser(t, store, "root")
+	if err := store.SetUserRole(ctx, root.ID, goreddit.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	th := goreddit.Thread{ID: uuid.New(), Title: "Gophers"}
+	if err := store.CreateThread(ctx, &th); err != nil {
+		t.Fatal(err)
+	}
+	e := goreddit.ModLogEntry{
+		ID:            uuid.New(),
+		ActorID:       uuid.NullUUID{UUID: root.ID, Valid: true},
+		ActorUsername: root.Username,
+		Action:        goreddit.ModBan,
+		TargetKind:    goreddit.ModTargetUser,
+		TargetID:      alice.ID,
+		TargetLabel:   alice.Username,
+		Reason:        "site-wide reason",
+	}
+	if err := store.CreateModLogEntry(ctx, &e); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions := NewMemorySessionManager()
+	h := NewHandler(store, sessions, Options{CSRFKey: make([]byte, 32)})
+	get := func(path string, user *goreddit.User) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if user != nil {
+			req.AddCookie(loginCookie(t, sessions, *user))
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, tt := range []struct {
+		name, path string
+		user       *goreddit.User
+		want       int
+	}{
+		{"visitor", "/admin/modlog", nil, http.StatusFound},
+		{"user", "/admin/modlog", &alice, http.StatusForbidden},
+		{"user", "/modlog", &alice, http.StatusNotFound},
+	} {
+		rec := get(tt.path, tt.user)
+		if rec.Code != tt.want {
+			t.Errorf("GET %s as %s: status = %d, want %d", tt.path, tt.name, rec.Code, tt.want)
+		}
+		if strings.Contains(rec.Body.String(), e.Reason) {
+			t.Errorf("GET %s as %s shows the site-wide log", tt.path, tt.name)
+		}
+	}
+
+	if rec := get("/admin/modlog", &root); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), e.Reason) {
+		t.Errorf("GET /admin/modlog as admin: status = %d, want 200 with the site-wide log", rec.Code)
+	}
+	if rec := get("/threads/"+th.ID.String()+"/modlog", nil); rec.Code != http.StatusOK {
+		t.Errorf("GET the thread's mod log as visitor: status = %d, want 200", rec.Code)
+	}
+}
@@ -5,35 +5,142 @@ import (
 	"net/http"
 
 	"github.com/aleury/goreddit"
+	"github.com/aleury/goreddit/markdown"
 	"github.com/alexedwards/scs/v2"
+	"github.com/google/uuid"
+	"github.com/gorilla/csrf"
 )
 
 type PageHandler struct {
-	store    goreddit.Store
-	sessions *scs.SessionManager
+	store          goreddit.Store
+	sessions       *scs.SessionManager
+	pageSize       int
+	defaultThreads int
 }
 
 func (h *PageHandler) Home() http.HandlerFunc {
 	type data struct {
 		SessionData
 
-		Posts []goreddit.Post
+		CSRF template.HTML
+		All  bool
+		// Subscribed is set when Threads are the threads the user
+		// subscribed to rather than the popular ones.
+		Subscribed bool
+		Threads    []goreddit.Thread
+		Invites    []goreddit.Invite
+		Posts      []goreddit.Post
+		Tabs       sortTabs
+		Pager      pager
 	}
 
-	tmpl := template.Must(template.ParseFiles(
-		"templates/layout.html",
-		"templates/home.html",
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"home.html",
+		"sort_tabs.html",
+		"pager.html",
 	))
 	return func(rw http.ResponseWriter, r *http.Request) {
-		pp, err := h.store.Posts()
+		// The front page shows the threads the user subscribed to, or the
+		// most popular threads to visitors who are not logged in and to
+		// users who have not subscribed to any.
+		var tt []goreddit.Thread
+		var ii []goreddit.Invite
+		var err error
+		if user, ok := userFromContext(r.Context()); ok {
+			tt, err = h.store.Subscriptions(r.Context(), user.ID)
+			if err == nil {
+				ii, err = h.store.Invites(r.Context(), user.ID)
+			}
+		}
+		subscribed := len(tt) > 0
+		if err == nil && !subscribed {
+			tt, err = h.store.PopularThreads(r.Context(), h.defaultThreads)
+		}
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		ids := make([]uuid.UUID, len(tt))
+		for i, t := range tt {
+			ids[i] = t.ID
+		}
+
+		opts := listOptions(r, goreddit.SortHot, h.pageSize)
+		pp, page, err := h.store.PostsByThreads(r.Context(), ids, opts)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		tmpl.Execute(rw, data{
+			Subscribed:  subscribed,
+			Threads:     tt,
+			Invites:     ii,
+			Posts:       pp,
+			Tabs:        postTabs(opts),
+			Pager:       pageLinks(r, page),
+			CSRF:        csrf.TemplateField(r),
+			SessionData: GetSessionData(h.sessions, r.Context()),
+		})
+	}
+}
+
+// All lists the posts of every thread.
+func (h *PageHandler) All() http.HandlerFunc {
+	type data struct {
+		SessionData
+
+		CSRF       template.HTML
+		All        bool
+		Subscribed bool
+		Threads    []goreddit.Thread
+		Invites    []goreddit.Invite
+		Posts      []goreddit.Post
+		Tabs       sortTabs
+		Pager      pager
+	}
+
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"home.html",
+		"sort_tabs.html",
+		"pager.html",
+	))
+	return func(rw http.ResponseWriter, r *http.Request) {
+		opts := listOptions(r, goreddit.SortHot, h.pageSize)
+		pp, page, err := h.store.Posts(r.Context(), opts)
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			httpError(rw, r, err)
 			return
 		}
 
 		tmpl.Execute(rw, data{
+			All:         true,
 			Posts:       pp,
+			Tabs:        postTabs(opts),
+			Pager:       pageLinks(r, page),
+			CSRF:        csrf.TemplateField(r),
 			SessionData: GetSessionData(h.sessions, r.Context()),
 		})
 	}
 }
+
+// maxPreviewBody limits the size of forms sent for a Markdown preview.
+const maxPreviewBody = 64 << 10
+
+// Preview renders the content field of a form as Markdown, for showing
+// what a post or comment will look like while it is written.
+func (h *PageHandler) Preview() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(rw, r.Body, maxPreviewBody)
+		if err := r.ParseForm(); err != nil {
+			renderError(rw, r, http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+		rw.Write([]byte(markdown.Render(r.PostForm.Get("content"))))
+	}
+}
@@ -0,0 +1,45 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aleury/goreddit"
+	"github.com/aleury/goreddit/memory"
+	"github.com/google/uuid"
+)
+
+func TestHomeWithoutSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	alice := newTestUser(t, store, "alice")
+	bob := newTestUser(t, store, "bob")
+
+	th := goreddit.Thread{ID: uuid.New(), Title: "Popular"}
+	if err := store.CreateThread(ctx, &th); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Subscribe(ctx, bob.ID, th.ID); err != nil {
+		t.Fatal(err)
+	}
+	p := goreddit.Post{ID: uuid.New(), ThreadID: th.ID, Title: "A popular post", Content: "Content"}
+	if err := store.CreatePost(ctx, &p); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions := NewMemorySessionManager()
+	pages := PageHandler{store: store, sessions: sessions, pageSize: defaultPageSize, defaultThreads: defaultThreads}
+	for name, user := range map[string]*goreddit.User{"visitor": nil, "user without subscriptions": &alice} {
+		rec := serveRoute(sessions, "/", pages.Home(), user, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("front page of %s: status = %d, want 200", name, rec.Code)
+		}
+		body := rec.Body.String()
+		if !strings.Contains(body, "A popular post") || !strings.Contains(body, "Popular threads") {
+			t.Errorf("front page of %s does not show the popular threads", name)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+)
+
+// Policy decides which users may change which content. Handlers consult it
+// before acting, and templates use it to decide which controls to show.
+type Policy struct {
+	store goreddit.Store
+}
+
+// For returns the permissions of the user making the request with ctx.
+func (p *Policy) For(ctx context.Context) Permissions {
+	user, loggedIn := userFromContext(ctx)
+	return Permissions{
+		ctx:       ctx,
+		policy:    p,
+		user:      user,
+		loggedIn:  loggedIn,
+		moderates: map[uuid.UUID]bool{},
+		bans:      map[uuid.NullUUID]*goreddit.Ban{},
+	}
+}
+
+// Permissions answers authorization questions for a single user. Its methods
+// can be called from templates, e.g. {{if $.Can.EditPost .}}.
+type Permissions struct {
+	ctx      context.Context
+	policy   *Policy
+	user     goreddit.User
+	loggedIn bool
+	// moderates remembers which threads the user moderates, since
+	// templates ask once for every post and comment on a page.
+	moderates map[uuid.UUID]bool
+	// bans remembers the ban in effect for the user in each thread, or
+	// nil, keyed by thread ID or the zero value for the whole site.
+	bans map[uuid.NullUUID]*goreddit.Ban
+}
+
+// Admin reports whether the user is a site administrator.
+func (p Permissions) Admin() bool {
+	return p.loggedIn && p.user.IsAdmin()
+}
+
+// Moderate reports whether the user moderates the thread. Admins moderate
+// every thread.
+func (p Permissions) Moderate(threadID uuid.UUID) bool {
+	if !p.loggedIn {
+		return false
+	}
+	if p.Admin() {
+		return true
+	}
+	if ok, seen := p.moderates[threadID]; seen {
+		return ok
+	}
+	ok, err := p.policy.store.IsModerator(p.ctx, threadID, p.user.ID)
+	if err != nil {
+		log.Printf("error checking moderator: %v", err)
+		return false
+	}
+	p.moderates[threadID] = ok
+	return ok
+}
+
+// Ban returns the ban that keeps the user from taking part in the thread, or
+// nil if there is none. Site-wide bans apply to every thread.
+func (p Permissions) Ban(threadID uuid.UUID) *goreddit.Ban {
+	return p.ban(uuid.NullUUID{UUID: threadID, Valid: true})
+}
+
+// SiteBan returns the site-wide ban of the user, or nil if there is none.
+func (p Permissions) SiteBan() *goreddit.Ban {
+	return p.ban(uuid.NullUUID{})
+}
+
+func (p Permissions) ban(threadID uuid.NullUUID) *goreddit.Ban {
+	if !p.loggedIn {
+		return nil
+	}
+	if b, seen := p.bans[threadID]; seen {
+		return b
+	}
+	var ban *goreddit.Ban
+	b, err := p.policy.store.ActiveBan(p.ctx, p.user.ID, threadID)
+	if err == nil {
+		ban = &b
+	} else if !errors.Is(err, goreddit.ErrNotFound) {
+		log.Printf("error checking ban: %v", err)
+		return nil
+	}
+	p.bans[threadID] = ban
+	return ban
+}
+
+func (p Permissions) EditThread(t goreddit.Thread) bool {
+	return p.Moderate(t.ID)
+}
+
+// DeleteThread, DeletePost and DeleteComment report whether the user may
+// delete content, which authors and admins may.
+func (p Permissions) DeleteThread(t goreddit.Thread) bool {
+	return p.isAuthor(t.AuthorID) || p.Admin()
+}
+
+func (p Permissions) EditPost(post goreddit.Post) bool {
+	return p.isAuthor(post.AuthorID)
+}
+
+func (p Permissions) DeletePost(post goreddit.Post) bool {
+	return p.isAuthor(post.AuthorID) || p.Admin()
+}
+
+// Comment reports whether the user may comment on a post. Only moderators
+// can comment on posts that are locked or removed, and banned users cannot
+// comment at all.
+func (p Permissions) Comment(post goreddit.Post) bool {
+	if !p.loggedIn || p.Ban(post.ThreadID) != nil {
+		return false
+	}
+	return !post.Locked && !post.Removed || p.Moderate(post.ThreadID)
+}
+
+func (p Permissions) EditComment(c goreddit.Comment) bool {
+	return p.isAuthor(c.AuthorID)
+}
+
+func (p Permissions) DeleteComment(c goreddit.Comment) bool {
+	return p.isAuthor(c.AuthorID) || p.Admin()
+}
+
+// RemoveModerator reports whether the user may remove m from the moderators
+// mm of a thread. Moderators can step down, and can remove the moderators
+// who joined after them. Admins can remove any moderator.
+func (p Permissions) RemoveModerator(mm []goreddit.Moderator, m goreddit.Moderator) bool {
+	if !p.loggedIn {
+		return false
+	}
+	if m.UserID == p.user.ID || p.Admin() {
+		return true
+	}
+	for _, own := range mm {
+		if own.UserID == p.user.ID {
+			return own.CreatedAt.Before(m.CreatedAt)
+		}
+	}
+	return false
+}
+
+func (p Permissions) isAuthor(authorID uuid.NullUUID) bool {
+	return p.loggedIn && authorID.Valid && authorID.UUID == p.user.ID
+}
+
+// moderators lists the moderators of a thread, so that templates can mark
+// the content they wrote.
+type moderators []goreddit.Moderator
+
+// Has reports whether the user id is one of the moderators.
+func (mm moderators) Has(id uuid.NullUUID) bool {
+	if !id.Valid {
+		return false
+	}
+	for _, m := range mm {
+		if m.UserID == id.UUID {
+			return true
+		}
+	}
+	return false
+}
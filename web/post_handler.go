@@ -1,6 +1,8 @@
 package web
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
@@ -13,8 +15,10 @@ import (
 )
 
 type PostHandler struct {
-	store    goreddit.Store
-	sessions *scs.SessionManager
+	store        goreddit.Store
+	sessions     *scs.SessionManager
+	policy       *Policy
+	commentDepth int
 }
 
 func (h *PostHandler) New() http.HandlerFunc {
@@ -25,20 +29,26 @@ func (h *PostHandler) New() http.HandlerFunc {
 		Thread goreddit.Thread
 	}
 
-	tmpl := template.Must(template.ParseFiles(
-		"templates/layout.html",
-		"templates/post_create.html",
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"post_create.html",
+		"markdown_preview.html",
 	))
 	return func(rw http.ResponseWriter, r *http.Request) {
 		threadId, err := uuid.Parse(chi.URLParam(r, "threadId"))
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusBadRequest)
+			renderError(rw, r, http.StatusNotFound)
 			return
 		}
 
-		t, err := h.store.Thread(threadId)
+		t, err := h.store.Thread(r.Context(), threadId)
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			httpError(rw, r, err)
+			return
+		}
+
+		if ban := h.policy.For(r.Context()).Ban(t.ID); ban != nil {
+			renderBanned(rw, r, *ban)
 			return
 		}
 
@@ -64,25 +74,32 @@ func (h *PostHandler) Create() http.HandlerFunc {
 
 		threadId, err := uuid.Parse(chi.URLParam(r, "threadId"))
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusBadRequest)
+			renderError(rw, r, http.StatusNotFound)
 			return
 		}
 
-		t, err := h.store.Thread(threadId)
+		t, err := h.store.Thread(r.Context(), threadId)
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			httpError(rw, r, err)
 			return
 		}
 
+		if ban := h.policy.For(r.Context()).Ban(t.ID); ban != nil {
+			renderBanned(rw, r, *ban)
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
 		p := &goreddit.Post{
 			ID:       uuid.New(),
 			ThreadID: t.ID,
 			Title:    form.Title,
 			Content:  form.Content,
+			AuthorID: uuid.NullUUID{UUID: user.ID, Valid: true},
 		}
-		err = h.store.CreatePost(p)
+		err = h.store.CreatePost(r.Context(), p)
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			httpError(rw, r, err)
 			return
 		}
 
@@ -97,52 +114,89 @@ func (h *PostHandler) Show() http.HandlerFunc {
 	type data struct {
 		SessionData
 
-		CSRF     template.HTML
-		Thread   goreddit.Thread
-		Post     goreddit.Post
-		Comments []goreddit.Comment
+		CSRF       template.HTML
+		Can        Permissions
+		Thread     goreddit.Thread
+		Moderators moderators
+		Reports    map[uuid.UUID]int
+		Post       goreddit.Post
+		Comments   []goreddit.Comment
+		Focused    bool
+		Tabs       sortTabs
 	}
 
-	tmpl := template.Must(template.ParseFiles(
-		"templates/layout.html",
-		"templates/post.html",
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"post.html",
+		"ban_notice.html",
+		"markdown_preview.html",
+		"comment.html",
+		"sort_tabs.html",
 	))
 	return func(rw http.ResponseWriter, r *http.Request) {
 		threadId, err := uuid.Parse(chi.URLParam(r, "threadId"))
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusBadRequest)
+			renderError(rw, r, http.StatusNotFound)
 			return
 		}
 
 		postId, err := uuid.Parse(chi.URLParam(r, "postId"))
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusBadRequest)
+			renderError(rw, r, http.StatusNotFound)
 			return
 		}
 
-		t, err := h.store.Thread(threadId)
+		p, err := h.store.Post(r.Context(), postId)
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			httpError(rw, r, err)
+			return
+		}
+		// Permissions are checked against the thread in the URL, so it
+		// has to be the post's.
+		if p.ThreadID != threadId {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), p.ThreadID)
+		if err != nil {
+			httpError(rw, r, err)
 			return
 		}
 
-		p, err := h.store.Post(postId)
+		mm, err := h.store.Moderators(r.Context(), t.ID)
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			httpError(rw, r, err)
 			return
 		}
 
-		cc, err := h.store.CommentsbyPost(p.ID)
+		// Only moderators see how often content has been reported.
+		can := h.policy.For(r.Context())
+		var reports map[uuid.UUID]int
+		if can.Moderate(t.ID) {
+			reports, err = h.store.ReportCounts(r.Context(), t.ID)
+			if err != nil {
+				httpError(rw, r, err)
+				return
+			}
+		}
+
+		sort := commentSort(r)
+		cc, err := h.store.CommentTree(r.Context(), p.ID, h.commentDepth, sort)
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			httpError(rw, r, err)
 			return
 		}
 
 		tmpl.Execute(rw, data{
 			Thread:      t,
+			Moderators:  mm,
+			Reports:     reports,
 			Post:        p,
 			Comments:    cc,
+			Tabs:        commentTabs(sort),
 			CSRF:        csrf.TemplateField(r),
+			Can:         can,
 			SessionData: GetSessionData(h.sessions, r.Context()),
 		})
 	}
@@ -152,29 +206,359 @@ func (h *PostHandler) Vote() http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
 		postId, err := uuid.Parse(chi.URLParam(r, "postId"))
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusBadRequest)
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		value, ok := voteValue(r.PostFormValue("dir"))
+		if !ok {
+			renderError(rw, r, http.StatusBadRequest)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), postId)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		if ban := h.policy.For(r.Context()).Ban(p.ThreadID); ban != nil {
+			renderBanned(rw, r, *ban)
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
+		current, err := h.store.UserPostVote(r.Context(), user.ID, p.ID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+		// Voting the same way twice retracts the vote.
+		if current == value {
+			value = 0
+		}
+
+		err = h.store.CastPostVote(r.Context(), user.ID, p.ID, value)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		http.Redirect(rw, r, r.Referer(), http.StatusFound)
+	}
+}
+
+func (h *PostHandler) Edit() http.HandlerFunc {
+	type data struct {
+		SessionData
+
+		CSRF template.HTML
+		Post goreddit.Post
+	}
+
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"post_edit.html",
+	))
+	return func(rw http.ResponseWriter, r *http.Request) {
+		postId, err := uuid.Parse(chi.URLParam(r, "postId"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), postId)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		if !h.policy.For(r.Context()).EditPost(p) {
+			renderError(rw, r, http.StatusForbidden)
+			return
+		}
+
+		sd := GetSessionData(h.sessions, r.Context())
+		if _, ok := sd.Form.(EditPostForm); !ok {
+			sd.Form = EditPostForm{Title: p.Title, Content: p.Content}
+		}
+
+		tmpl.Execute(rw, data{
+			Post:        p,
+			CSRF:        csrf.TemplateField(r),
+			SessionData: sd,
+		})
+	}
+}
+
+func (h *PostHandler) Update() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		postId, err := uuid.Parse(chi.URLParam(r, "postId"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
 			return
 		}
 
-		p, err := h.store.Post(postId)
+		p, err := h.store.Post(r.Context(), postId)
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			httpError(rw, r, err)
 			return
 		}
 
-		switch r.URL.Query().Get("dir") {
-		case "up":
-			p.Votes++
-		case "down":
-			p.Votes--
+		if !h.policy.For(r.Context()).EditPost(p) {
+			renderError(rw, r, http.StatusForbidden)
+			return
 		}
 
-		err = h.store.UpdatePost(&p)
+		form := EditPostForm{
+			Title:   r.FormValue("title"),
+			Content: r.FormValue("content"),
+		}
+		if !form.Validate() {
+			h.sessions.Put(r.Context(), "form", form)
+			http.Redirect(rw, r, r.Referer(), http.StatusFound)
+			return
+		}
+
+		p.Title = form.Title
+		p.Content = form.Content
+		err = h.store.UpdatePost(r.Context(), &p)
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			httpError(rw, r, err)
 			return
 		}
 
+		h.sessions.Put(r.Context(), "flash", "Your post has been updated.")
+
+		redirect_url := fmt.Sprintf("/threads/%s/posts/%s", p.ThreadID.String(), p.ID.String())
+		http.Redirect(rw, r, redirect_url, http.StatusFound)
+	}
+}
+
+func (h *PostHandler) Delete() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		postId, err := uuid.Parse(chi.URLParam(r, "postId"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), postId)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		can := h.policy.For(r.Context())
+		if !can.DeletePost(p) {
+			renderError(rw, r, http.StatusForbidden)
+			return
+		}
+
+		err = h.store.DeletePost(r.Context(), p.ID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		// Authors deleting their own posts is not a moderation action.
+		if !can.isAuthor(p.AuthorID) {
+			if err := logModAction(r.Context(), h.store, postEntry(goreddit.ModDeletePost, p)); err != nil {
+				httpError(rw, r, err)
+				return
+			}
+		}
+
+		h.sessions.Put(r.Context(), "flash", "The post has been deleted.")
+
+		http.Redirect(rw, r, "/threads/"+p.ThreadID.String(), http.StatusFound)
+	}
+}
+
+func (h *PostHandler) Report() http.HandlerFunc {
+	type data struct {
+		SessionData
+
+		CSRF    template.HTML
+		Thread  goreddit.Thread
+		Post    goreddit.Post
+		Comment *goreddit.Comment
+		Reasons []goreddit.ReportReason
+		Action  string
+	}
+
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"report.html",
+	))
+	return func(rw http.ResponseWriter, r *http.Request) {
+		postId, err := uuid.Parse(chi.URLParam(r, "postId"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), postId)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), p.ThreadID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		tmpl.Execute(rw, data{
+			Thread:      t,
+			Post:        p,
+			Reasons:     goreddit.ReportReasons,
+			Action:      fmt.Sprintf("/threads/%s/posts/%s/report", t.ID.String(), p.ID.String()),
+			CSRF:        csrf.TemplateField(r),
+			SessionData: GetSessionData(h.sessions, r.Context()),
+		})
+	}
+}
+
+func (h *PostHandler) ReportSubmit() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		form := ReportForm{
+			Reason:  r.FormValue("reason"),
+			Details: r.FormValue("details"),
+		}
+		if !form.Validate() {
+			h.sessions.Put(r.Context(), "form", form)
+			http.Redirect(rw, r, r.Referer(), http.StatusFound)
+			return
+		}
+
+		postId, err := uuid.Parse(chi.URLParam(r, "postId"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), postId)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
+		err = fileReport(r.Context(), h.store, p, &goreddit.Report{
+			ID:         uuid.New(),
+			PostID:     p.ID,
+			ReporterID: uuid.NullUUID{UUID: user.ID, Valid: true},
+			Reason:     goreddit.ReportReason(form.Reason),
+			Details:    form.Details,
+		})
+		if errors.Is(err, goreddit.ErrConflict) {
+			h.sessions.Put(r.Context(), "flash", "You have already reported this post.")
+		} else if err != nil {
+			httpError(rw, r, err)
+			return
+		} else {
+			h.sessions.Put(r.Context(), "flash", "Thanks for your report. The moderators will review the post.")
+		}
+
+		redirect_url := fmt.Sprintf("/threads/%s/posts/%s", p.ThreadID.String(), p.ID.String())
+		http.Redirect(rw, r, redirect_url, http.StatusFound)
+	}
+}
+
+// Remove, Approve and IgnoreReports settle the reports of the post, which
+// takes it out of the mod queue.
+func (h *PostHandler) Remove() http.HandlerFunc {
+	return h.moderate(goreddit.ModRemove, "The post has been removed.", func(ctx context.Context, p goreddit.Post) error {
+		if err := h.store.SetPostRemoved(ctx, p.ID, true); err != nil {
+			return err
+		}
+		return h.store.DeletePostReports(ctx, p.ID)
+	})
+}
+
+func (h *PostHandler) Approve() http.HandlerFunc {
+	return h.moderate(goreddit.ModApprove, "The post has been approved.", func(ctx context.Context, p goreddit.Post) error {
+		if err := h.store.SetPostRemoved(ctx, p.ID, false); err != nil {
+			return err
+		}
+		return h.store.DeletePostReports(ctx, p.ID)
+	})
+}
+
+func (h *PostHandler) IgnoreReports() http.HandlerFunc {
+	return h.moderate(goreddit.ModIgnoreReports, "The reports of the post have been ignored.", func(ctx context.Context, p goreddit.Post) error {
+		return h.store.DeletePostReports(ctx, p.ID)
+	})
+}
+
+func (h *PostHandler) Lock() http.HandlerFunc {
+	return h.moderate(goreddit.ModLock, "The post has been locked.", func(ctx context.Context, p goreddit.Post) error {
+		return h.store.SetPostLocked(ctx, p.ID, true)
+	})
+}
+
+func (h *PostHandler) Unlock() http.HandlerFunc {
+	return h.moderate(goreddit.ModUnlock, "The post has been unlocked.", func(ctx context.Context, p goreddit.Post) error {
+		return h.store.SetPostLocked(ctx, p.ID, false)
+	})
+}
+
+func (h *PostHandler) Pin() http.HandlerFunc {
+	return h.moderate(goreddit.ModPin, "The post has been pinned.", func(ctx context.Context, p goreddit.Post) error {
+		return h.store.SetPostPinned(ctx, p.ID, true)
+	})
+}
+
+func (h *PostHandler) Unpin() http.HandlerFunc {
+	return h.moderate(goreddit.ModUnpin, "The post has been unpinned.", func(ctx context.Context, p goreddit.Post) error {
+		return h.store.SetPostPinned(ctx, p.ID, false)
+	})
+}
+
+// moderate returns a handler that lets the moderators of a thread apply
+// apply to one of its posts, records it in the mod log as action and then
+// flashes done.
+func (h *PostHandler) moderate(action goreddit.ModAction, done string, apply func(ctx context.Context, p goreddit.Post) error) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		postId, err := uuid.Parse(chi.URLParam(r, "postId"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		p, err := h.store.Post(r.Context(), postId)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		if !h.policy.For(r.Context()).Moderate(p.ThreadID) {
+			renderError(rw, r, http.StatusForbidden)
+			return
+		}
+
+		err = apply(r.Context(), p)
+		if errors.Is(err, goreddit.ErrPinLimit) {
+			h.sessions.Put(r.Context(), "flash", "Unpin a post first: "+goreddit.ErrPinLimit.Error()+".")
+			http.Redirect(rw, r, r.Referer(), http.StatusFound)
+			return
+		}
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		entry := postEntry(action, p)
+		entry.Reason = modReason(r)
+		if err := logModAction(r.Context(), h.store, entry); err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", done)
+
 		http.Redirect(rw, r, r.Referer(), http.StatusFound)
 	}
 }
@@ -0,0 +1,59 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aleury/goreddit"
+	"github.com/aleury/goreddit/memory"
+	"github.com/google/uuid"
+)
+
+func TestShowPostInOtherThread(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	alice := newTestUser(t, store, "alice")
+	bob := newTestUser(t, store, "bob")
+
+	// Alice moderates her own thread, but not Bob's.
+	own := goreddit.Thread{ID: uuid.New(), Title: "Own", AuthorID: uuid.NullUUID{UUID: alice.ID, Valid: true}}
+	other := goreddit.Thread{ID: uuid.New(), Title: "Other", AuthorID: uuid.NullUUID{UUID: bob.ID, Valid: true}}
+	for _, th := range []*goreddit.Thread{&own, &other} {
+		if err := store.CreateThread(ctx, th); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := goreddit.Post{ID: uuid.New(), ThreadID: other.ID, Title: "Removed", Content: "secret content"}
+	if err := store.CreatePost(ctx, &p); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetPostRemoved(ctx, p.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions := NewMemorySessionManager()
+	posts := PostHandler{store: store, sessions: sessions, policy: &Policy{store: store}, commentDepth: defaultCommentDepth}
+	show := func(threadID uuid.UUID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/threads/"+threadID.String()+"/posts/"+p.ID.String(), nil)
+		return serveRoute(sessions, "/threads/{threadId}/posts/{postId}", posts.Show(), &alice, req)
+	}
+
+	rec := show(own.ID)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("post of another thread under a moderated thread: status = %d, want 404", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "secret content") {
+		t.Error("removed content shown to a moderator of another thread")
+	}
+
+	rec = show(other.ID)
+	if rec.Code != http.StatusOK {
+		t.Errorf("post under its own thread: status = %d, want 200", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "secret content") {
+		t.Error("removed content shown to a user who does not moderate the thread")
+	}
+}
@@ -0,0 +1,82 @@
+package web
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+)
+
+// fileReport creates a report on post p or one of its comments. Content
+// reported goreddit.ReportFilterThreshold times is removed until a moderator
+// reviews it in the mod queue, and the removal is logged as automatic.
+func fileReport(ctx context.Context, store goreddit.Store, p goreddit.Post, r *goreddit.Report) error {
+	if err := store.CreateReport(ctx, r); err != nil {
+		return err
+	}
+
+	counts, err := store.ReportCounts(ctx, p.ThreadID)
+	if err != nil {
+		return err
+	}
+	if counts[r.TargetID()] < goreddit.ReportFilterThreshold {
+		return nil
+	}
+
+	entry := postEntry(goreddit.ModRemove, p)
+	if r.CommentID.Valid {
+		c, err := store.Comment(ctx, r.CommentID.UUID)
+		if err != nil {
+			return err
+		}
+		if c.Removed {
+			return nil
+		}
+		if err := store.SetCommentRemoved(ctx, c.ID, true); err != nil {
+			return err
+		}
+		entry = commentEntry(goreddit.ModRemove, p, c)
+	} else {
+		if p.Removed {
+			return nil
+		}
+		if err := store.SetPostRemoved(ctx, p.ID, true); err != nil {
+			return err
+		}
+	}
+
+	entry.ID = uuid.New()
+	entry.Reason = fmt.Sprintf("Reported %d times", counts[r.TargetID()])
+	return store.CreateModLogEntry(ctx, &entry)
+}
+
+// queueItem is a reported post or comment in the mod queue together with
+// all of its reports.
+type queueItem struct {
+	goreddit.Report
+	Reports []goreddit.Report
+}
+
+// Filtered reports whether the item was removed automatically because of
+// its reports.
+func (i queueItem) Filtered() bool {
+	return i.Removed && len(i.Reports) >= goreddit.ReportFilterThreshold
+}
+
+// queueItems groups reports by the content they report, in the order the
+// content was first reported.
+func queueItems(rr []goreddit.Report) []queueItem {
+	var items []queueItem
+	index := map[uuid.UUID]int{}
+	for _, r := range rr {
+		i, ok := index[r.TargetID()]
+		if !ok {
+			i = len(items)
+			index[r.TargetID()] = i
+			items = append(items, queueItem{Report: r})
+		}
+		items[i].Reports = append(items[i].Reports, r)
+	}
+	return items
+}
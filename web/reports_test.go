@@ -0,0 +1,37 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/aleury/goreddit"
+	"github.com/google/uuid"
+)
+
+func TestQueueItems(t *testing.T) {
+	post := uuid.New()
+	comment := uuid.New()
+
+	rr := []goreddit.Report{
+		{ID: uuid.New(), PostID: post, Removed: true},
+		{ID: uuid.New(), PostID: post, CommentID: uuid.NullUUID{UUID: comment, Valid: true}},
+		{ID: uuid.New(), PostID: post, Removed: true},
+		{ID: uuid.New(), PostID: post, Removed: true},
+	}
+
+	items := queueItems(rr)
+	if len(items) != 2 {
+		t.Fatalf("len(queueItems) = %d, want 2", len(items))
+	}
+	if items[0].TargetID() != post || len(items[0].Reports) != 3 {
+		t.Errorf("items[0] = %v with %d reports, want the post with 3", items[0].TargetID(), len(items[0].Reports))
+	}
+	if items[1].TargetID() != comment || len(items[1].Reports) != 1 {
+		t.Errorf("items[1] = %v with %d reports, want the comment with 1", items[1].TargetID(), len(items[1].Reports))
+	}
+	if !items[0].Filtered() {
+		t.Error("post removed after 3 reports: Filtered() = false, want true")
+	}
+	if items[1].Filtered() {
+		t.Error("comment with 1 report: Filtered() = true, want false")
+	}
+}
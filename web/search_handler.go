@@ -0,0 +1,131 @@
+package web
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/aleury/goreddit"
+	"github.com/alexedwards/scs/v2"
+	"github.com/google/uuid"
+)
+
+// searchDateLayout is the format of the after and before query parameters,
+// as sent by date inputs.
+const searchDateLayout = "2006-01-02"
+
+type SearchHandler struct {
+	store    goreddit.Store
+	sessions *scs.SessionManager
+	pageSize int
+}
+
+// searchQuery holds the query parameters of a search, for filling in the
+// search form again.
+type searchQuery struct {
+	Q      string
+	Type   string
+	Thread string
+	Author string
+	After  string
+	Before string
+}
+
+// searchOptions reads the q, type, thread, author, after, before, cursor and
+// limit query parameters of r. Unknown types, malformed thread IDs and
+// malformed dates are ignored. Before includes the whole day it names.
+func searchOptions(r *http.Request, pageSize int) (goreddit.SearchOptions, searchQuery) {
+	query := r.URL.Query()
+	q := searchQuery{
+		Q:      query.Get("q"),
+		Type:   query.Get("type"),
+		Thread: query.Get("thread"),
+		Author: query.Get("author"),
+		After:  query.Get("after"),
+		Before: query.Get("before"),
+	}
+	opts := goreddit.SearchOptions{
+		Query:       q.Q,
+		Author:      q.Author,
+		PageOptions: pageOptions(r, pageSize),
+	}
+
+	if kind := goreddit.SearchKind(q.Type); kind.Valid() {
+		opts.Kinds = []goreddit.SearchKind{kind}
+	} else {
+		q.Type = ""
+	}
+	if id, err := uuid.Parse(q.Thread); err == nil {
+		opts.ThreadID = uuid.NullUUID{UUID: id, Valid: true}
+	} else {
+		q.Thread = ""
+	}
+	if t, err := time.Parse(searchDateLayout, q.After); err == nil {
+		opts.After = t
+	} else {
+		q.After = ""
+	}
+	if t, err := time.Parse(searchDateLayout, q.Before); err == nil {
+		opts.Before = t.AddDate(0, 0, 1)
+	} else {
+		q.Before = ""
+	}
+
+	return opts, q
+}
+
+func (h *SearchHandler) Search() http.HandlerFunc {
+	type data struct {
+		SessionData
+
+		Query  searchQuery
+		Kinds  []goreddit.SearchKind
+		Thread *goreddit.Thread
+		// Everywhere links to the same search without the thread filter.
+		Everywhere string
+		Results    []goreddit.SearchResult
+		Pager      pager
+	}
+
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"search.html",
+		"pager.html",
+	))
+	return func(rw http.ResponseWriter, r *http.Request) {
+		opts, q := searchOptions(r, h.pageSize)
+
+		var thread *goreddit.Thread
+		if opts.ThreadID.Valid {
+			t, err := h.store.Thread(r.Context(), opts.ThreadID.UUID)
+			if err != nil && !errors.Is(err, goreddit.ErrNotFound) {
+				httpError(rw, r, err)
+				return
+			}
+			if err == nil {
+				thread = &t
+			}
+		}
+
+		rr, page, err := h.store.Search(r.Context(), opts)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		everywhere := r.URL.Query()
+		everywhere.Del("thread")
+		everywhere.Del("cursor")
+
+		tmpl.Execute(rw, data{
+			Query:       q,
+			Everywhere:  "/search?" + everywhere.Encode(),
+			Kinds:       goreddit.SearchKinds,
+			Thread:      thread,
+			Results:     rr,
+			Pager:       pageLinks(r, page),
+			SessionData: GetSessionData(h.sessions, r.Context()),
+		})
+	}
+}
@@ -22,29 +22,59 @@ func NewSessionManager(dataSourceName string) (*scs.SessionManager, error) {
 	}
 
 	sessions := scs.New()
-	sessions.Store = postgresstore.New(db)
+	sessions.Store = sessionStore{PostgresStore: postgresstore.New(db), db: db}
 
 	return sessions, nil
 }
 
+// sessionStore keeps sessions in Postgres. Unlike postgresstore, it can be
+// closed, which also closes its connections to the database.
+type sessionStore struct {
+	*postgresstore.PostgresStore
+	db *sql.DB
+}
+
+func (s sessionStore) Close() error {
+	s.StopCleanup()
+	return s.db.Close()
+}
+
+// NewMemorySessionManager returns a session manager that keeps sessions in
+// memory, for use together with the in-memory store.
+func NewMemorySessionManager() *scs.SessionManager {
+	return scs.New()
+}
+
 type ctxKey string
 
 func (c ctxKey) String() string {
 	return string(c)
 }
 
+func userFromContext(ctx context.Context) (goreddit.User, bool) {
+	user, ok := ctx.Value(ctxKey("user")).(goreddit.User)
+	return user, ok
+}
+
+func featuresFromContext(ctx context.Context) Features {
+	f, _ := ctx.Value(ctxKey("features")).(Features)
+	return f
+}
+
 type SessionData struct {
 	FlashMessage string
 	Form         interface{}
 	User         goreddit.User
 	LoggedIn     bool
+	Features     Features
 }
 
 func GetSessionData(session *scs.SessionManager, ctx context.Context) SessionData {
 	var data SessionData
 
 	data.FlashMessage = session.PopString(ctx, "flash")
-	data.User, data.LoggedIn = ctx.Value(ctxKey("user")).(goreddit.User)
+	data.User, data.LoggedIn = userFromContext(ctx)
+	data.Features = featuresFromContext(ctx)
 
 	data.Form = session.Pop(ctx, "form")
 	if data.Form == nil {
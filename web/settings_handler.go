@@ -0,0 +1,138 @@
+package web
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/aleury/goreddit"
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/csrf"
+)
+
+type SettingsHandler struct {
+	store    goreddit.Store
+	sessions *scs.SessionManager
+}
+
+func (h *SettingsHandler) Tokens() http.HandlerFunc {
+	type data struct {
+		SessionData
+
+		CSRF      template.HTML
+		Tokens    []goreddit.Token
+		Scopes    []goreddit.Scope
+		TokenForm CreateTokenForm
+		NewToken  string
+	}
+
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"settings_tokens.html",
+	))
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user, _ := userFromContext(r.Context())
+
+		tt, err := h.store.TokensByUser(r.Context(), user.ID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		session := GetSessionData(h.sessions, r.Context())
+		form, _ := session.Form.(CreateTokenForm)
+
+		tmpl.Execute(rw, data{
+			CSRF:        csrf.TemplateField(r),
+			Tokens:      tt,
+			Scopes:      goreddit.AllScopes,
+			TokenForm:   form,
+			NewToken:    h.sessions.PopString(r.Context(), "new_token"),
+			SessionData: session,
+		})
+	}
+}
+
+func (h *SettingsHandler) CreateToken() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			renderError(rw, r, http.StatusBadRequest)
+			return
+		}
+
+		form := CreateTokenForm{
+			Name:   r.PostForm.Get("name"),
+			Scopes: r.PostForm["scopes"],
+		}
+		if !form.Validate() {
+			h.sessions.Put(r.Context(), "form", form)
+			http.Redirect(rw, r, "/settings/tokens", http.StatusFound)
+			return
+		}
+
+		secret, err := generateToken()
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		scopes := make(goreddit.Scopes, len(form.Scopes))
+		for i, s := range form.Scopes {
+			scopes[i] = goreddit.Scope(s)
+		}
+
+		user, _ := userFromContext(r.Context())
+		err = h.store.CreateToken(r.Context(), &goreddit.Token{
+			ID:     uuid.New(),
+			UserID: user.ID,
+			Name:   form.Name,
+			Hash:   hashToken(secret),
+			Scopes: scopes,
+		})
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		// The secret is shown once, on the next page, and never again.
+		h.sessions.Put(r.Context(), "new_token", secret)
+		h.sessions.Put(r.Context(), "flash", "Your token has been created. Copy it now, it won't be shown again.")
+
+		http.Redirect(rw, r, "/settings/tokens", http.StatusFound)
+	}
+}
+
+func (h *SettingsHandler) DeleteToken() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		t, err := h.store.Token(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		// Other users' tokens are reported as missing rather than forbidden,
+		// so that their IDs can't be probed.
+		user, _ := userFromContext(r.Context())
+		if t.UserID != user.ID {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		err = h.store.DeleteToken(r.Context(), t.ID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", "The token has been revoked.")
+
+		http.Redirect(rw, r, "/settings/tokens", http.StatusFound)
+	}
+}
@@ -0,0 +1,104 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aleury/goreddit"
+	"github.com/aleury/goreddit/markdown"
+)
+
+// templateFuncs are available to every template.
+var templateFuncs = template.FuncMap{
+	"dict":      dict,
+	"edited":    edited,
+	"highlight": highlight,
+	"markdown":  markdown.Render,
+	"modLogURL": modLogURL,
+	"timeago":   func(t time.Time) string { return timeAgo(t, time.Now()) },
+	"truncate":  truncate,
+}
+
+// templateDir is the directory the templates are read from. NewHandler sets
+// it from Options.TemplateDir.
+var templateDir = "templates"
+
+// parseTemplates parses the named files in templateDir into a template with
+// templateFuncs. The first file, usually the layout, is the one that gets
+// executed.
+func parseTemplates(filenames ...string) (*template.Template, error) {
+	if len(filenames) == 0 {
+		return nil, errors.New("parseTemplates: no files named")
+	}
+	paths := make([]string, len(filenames))
+	for i, name := range filenames {
+		paths[i] = filepath.Join(templateDir, name)
+	}
+	return template.New(filenames[0]).Funcs(templateFuncs).ParseFiles(paths...)
+}
+
+// dict builds a map from alternating keys and values, so that a template can
+// pass more than one value to another template.
+func dict(pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, errors.New("dict: odd number of arguments")
+	}
+
+	m := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, errors.New("dict: keys must be strings")
+		}
+		m[key] = pairs[i+1]
+	}
+	return m, nil
+}
+
+// highlight escapes a search result snippet and marks the words that
+// matched the search.
+func highlight(snippet string) template.HTML {
+	s := template.HTMLEscapeString(snippet)
+	s = strings.ReplaceAll(s, goreddit.HighlightStart, "<mark>")
+	s = strings.ReplaceAll(s, goreddit.HighlightStop, "</mark>")
+	return template.HTML(s)
+}
+
+// editGracePeriod is how long after creation content can be changed without
+// being marked as edited.
+const editGracePeriod = 3 * time.Minute
+
+// edited reports whether content was changed after the grace period.
+func edited(createdAt, updatedAt time.Time) bool {
+	return updatedAt.Sub(createdAt) > editGracePeriod
+}
+
+// timeAgo describes how long before now t was, e.g. "3 hours ago".
+func timeAgo(t, now time.Time) string {
+	d := now.Sub(t)
+
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute")
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour")
+	case d < 30*24*time.Hour:
+		return plural(int(d/(24*time.Hour)), "day")
+	case d < 365*24*time.Hour:
+		return plural(int(d/(30*24*time.Hour)), "month")
+	}
+	return plural(int(d/(365*24*time.Hour)), "year")
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s ago", unit)
+	}
+	return fmt.Sprintf("%d %ss ago", n, unit)
+}
@@ -0,0 +1,58 @@
+package web
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aleury/goreddit"
+)
+
+func TestTimeAgo(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{-time.Hour, "just now"},
+		{0, "just now"},
+		{59 * time.Second, "just now"},
+		{time.Minute, "1 minute ago"},
+		{5*time.Minute + 30*time.Second, "5 minutes ago"},
+		{time.Hour, "1 hour ago"},
+		{23 * time.Hour, "23 hours ago"},
+		{24 * time.Hour, "1 day ago"},
+		{29 * 24 * time.Hour, "29 days ago"},
+		{30 * 24 * time.Hour, "1 month ago"},
+		{364 * 24 * time.Hour, "12 months ago"},
+		{365 * 24 * time.Hour, "1 year ago"},
+		{3 * 365 * 24 * time.Hour, "3 years ago"},
+	}
+	for _, tt := range tests {
+		if got := timeAgo(now.Add(-tt.ago), now); got != tt.want {
+			t.Errorf("timeAgo(now - %v) = %q, want %q", tt.ago, got, tt.want)
+		}
+	}
+}
+
+func TestEdited(t *testing.T) {
+	created := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if edited(created, created) {
+		t.Error("edited(t, t) = true, want false")
+	}
+	if edited(created, created.Add(time.Minute)) {
+		t.Error("edited within the grace period = true, want false")
+	}
+	if !edited(created, created.Add(time.Hour)) {
+		t.Error("edited after an hour = false, want true")
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	snippet := "a <b> " + goreddit.HighlightStart + "match" + goreddit.HighlightStop + " & more"
+	want := `a &lt;b&gt; <mark>match</mark> &amp; more`
+	if got := string(highlight(snippet)); got != want {
+		t.Errorf("highlight(%q) = %q, want %q", snippet, got, want)
+	}
+}
@@ -1,8 +1,10 @@
 package web
 
 import (
+	"errors"
 	"html/template"
 	"net/http"
+	"strings"
 
 	"github.com/aleury/goreddit"
 	"github.com/alexedwards/scs/v2"
@@ -14,6 +16,8 @@ import (
 type ThreadHandler struct {
 	store    goreddit.Store
 	sessions *scs.SessionManager
+	policy   *Policy
+	pageSize int
 }
 
 func (h *ThreadHandler) List() http.HandlerFunc {
@@ -21,21 +25,24 @@ func (h *ThreadHandler) List() http.HandlerFunc {
 		SessionData
 
 		Threads []goreddit.Thread
+		Pager   pager
 	}
 
-	tmpl := template.Must(template.ParseFiles(
-		"templates/layout.html",
-		"templates/threads.html",
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"threads.html",
+		"pager.html",
 	))
 	return func(rw http.ResponseWriter, r *http.Request) {
-		tt, err := h.store.Threads()
+		tt, page, err := h.store.Threads(r.Context(), pageOptions(r, h.pageSize))
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			httpError(rw, r, err)
 			return
 		}
 
 		tmpl.Execute(rw, data{
 			Threads:     tt,
+			Pager:       pageLinks(r, page),
 			SessionData: GetSessionData(h.sessions, r.Context()),
 		})
 	}
@@ -48,11 +55,16 @@ func (h *ThreadHandler) New() http.HandlerFunc {
 		CSRF template.HTML
 	}
 
-	tmpl := template.Must(template.ParseFiles(
-		"templates/layout.html",
-		"templates/thread_create.html",
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"thread_create.html",
 	))
 	return func(w http.ResponseWriter, r *http.Request) {
+		if ban := h.policy.For(r.Context()).SiteBan(); ban != nil {
+			renderBanned(w, r, *ban)
+			return
+		}
+
 		tmpl.Execute(w, data{
 			CSRF:        csrf.TemplateField(r),
 			SessionData: GetSessionData(h.sessions, r.Context()),
@@ -72,13 +84,20 @@ func (h *ThreadHandler) Create() http.HandlerFunc {
 			return
 		}
 
-		err := h.store.CreateThread(&goreddit.Thread{
+		if ban := h.policy.For(r.Context()).SiteBan(); ban != nil {
+			renderBanned(rw, r, *ban)
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
+		err := h.store.CreateThread(r.Context(), &goreddit.Thread{
 			ID:          uuid.New(),
 			Title:       form.Title,
 			Description: form.Description,
+			AuthorID:    uuid.NullUUID{UUID: user.ID, Valid: true},
 		})
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			httpError(rw, r, err)
 			return
 		}
 
@@ -92,54 +111,287 @@ func (h *ThreadHandler) Show() http.HandlerFunc {
 	type data struct {
 		SessionData
 
-		CSRF   template.HTML
-		Thread goreddit.Thread
-		Posts  []goreddit.Post
+		CSRF       template.HTML
+		Can        Permissions
+		Thread     goreddit.Thread
+		Subscribed bool
+		Moderators moderators
+		Invite     *goreddit.Invite
+		Reports    map[uuid.UUID]int
+		Pinned     []goreddit.Post
+		Posts      []goreddit.Post
+		Tabs       sortTabs
+		Pager      pager
+	}
+
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"thread.html",
+		"ban_notice.html",
+		"sort_tabs.html",
+		"pager.html",
+	))
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		var subscribed bool
+		var invite *goreddit.Invite
+		if user, ok := userFromContext(r.Context()); ok {
+			subscribed, err = h.store.Subscribed(r.Context(), user.ID, t.ID)
+			if err != nil {
+				httpError(rw, r, err)
+				return
+			}
+
+			inv, err := h.store.Invite(r.Context(), t.ID, user.ID)
+			if err != nil && !errors.Is(err, goreddit.ErrNotFound) {
+				httpError(rw, r, err)
+				return
+			}
+			if err == nil {
+				invite = &inv
+			}
+		}
+
+		mm, err := h.store.Moderators(r.Context(), t.ID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		// Only moderators see how often content has been reported.
+		can := h.policy.For(r.Context())
+		var reports map[uuid.UUID]int
+		if can.Moderate(t.ID) {
+			reports, err = h.store.ReportCounts(r.Context(), t.ID)
+			if err != nil {
+				httpError(rw, r, err)
+				return
+			}
+		}
+
+		opts := listOptions(r, goreddit.SortHot, h.pageSize)
+		opts.IncludeRemoved = can.Moderate(t.ID)
+		pp, page, err := h.store.PostsByThread(r.Context(), t.ID, opts)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		// Pinned posts are shown above the first page instead of in
+		// their place in the listing.
+		var pinned []goreddit.Post
+		if opts.Cursor == "" {
+			pinned, err = h.store.PinnedPosts(r.Context(), t.ID)
+			if err != nil {
+				httpError(rw, r, err)
+				return
+			}
+		}
+		unpinned := pp[:0]
+		for _, p := range pp {
+			if !p.Pinned || p.Removed {
+				unpinned = append(unpinned, p)
+			}
+		}
+
+		tmpl.Execute(rw, data{
+			Thread:      t,
+			Subscribed:  subscribed,
+			Moderators:  mm,
+			Invite:      invite,
+			Reports:     reports,
+			Pinned:      pinned,
+			Posts:       unpinned,
+			Tabs:        postTabs(opts),
+			Pager:       pageLinks(r, page),
+			CSRF:        csrf.TemplateField(r),
+			Can:         can,
+			SessionData: GetSessionData(h.sessions, r.Context()),
+		})
+	}
+}
+
+// Queue lists the reported content of a thread for its moderators to
+// review.
+func (h *ThreadHandler) Queue() http.HandlerFunc {
+	type data struct {
+		SessionData
+
+		CSRF      template.HTML
+		Thread    goreddit.Thread
+		Items     []queueItem
+		Threshold int
 	}
 
-	tmpl := template.Must(template.ParseFiles(
-		"templates/layout.html",
-		"templates/thread.html",
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"thread_queue.html",
 	))
 	return func(rw http.ResponseWriter, r *http.Request) {
 		id, err := uuid.Parse(chi.URLParam(r, "id"))
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusBadRequest)
+			renderError(rw, r, http.StatusNotFound)
 			return
 		}
 
-		t, err := h.store.Thread(id)
+		t, err := h.store.Thread(r.Context(), id)
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			httpError(rw, r, err)
+			return
+		}
+
+		if !h.policy.For(r.Context()).Moderate(t.ID) {
+			renderError(rw, r, http.StatusForbidden)
 			return
 		}
 
-		pp, err := h.store.PostsByThread(t.ID)
+		rr, err := h.store.Reports(r.Context(), t.ID)
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			httpError(rw, r, err)
 			return
 		}
 
 		tmpl.Execute(rw, data{
 			Thread:      t,
-			Posts:       pp,
+			Items:       queueItems(rr),
+			Threshold:   goreddit.ReportFilterThreshold,
 			CSRF:        csrf.TemplateField(r),
 			SessionData: GetSessionData(h.sessions, r.Context()),
 		})
 	}
 }
 
+func (h *ThreadHandler) Edit() http.HandlerFunc {
+	type data struct {
+		SessionData
+
+		CSRF   template.HTML
+		Thread goreddit.Thread
+	}
+
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"thread_edit.html",
+	))
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		if !h.policy.For(r.Context()).EditThread(t) {
+			renderError(rw, r, http.StatusForbidden)
+			return
+		}
+
+		sd := GetSessionData(h.sessions, r.Context())
+		if _, ok := sd.Form.(EditThreadForm); !ok {
+			sd.Form = EditThreadForm{Title: t.Title, Description: t.Description}
+		}
+
+		tmpl.Execute(rw, data{
+			Thread:      t,
+			CSRF:        csrf.TemplateField(r),
+			SessionData: sd,
+		})
+	}
+}
+
+func (h *ThreadHandler) Update() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		if !h.policy.For(r.Context()).EditThread(t) {
+			renderError(rw, r, http.StatusForbidden)
+			return
+		}
+
+		form := EditThreadForm{
+			Title:       r.FormValue("title"),
+			Description: r.FormValue("description"),
+		}
+		if !form.Validate() {
+			h.sessions.Put(r.Context(), "form", form)
+			http.Redirect(rw, r, r.Referer(), http.StatusFound)
+			return
+		}
+
+		t.Title = form.Title
+		t.Description = form.Description
+		err = h.store.UpdateThread(r.Context(), &t)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		err = logModAction(r.Context(), h.store, threadEntry(goreddit.ModEditThread, t))
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", "Your thread has been updated.")
+
+		http.Redirect(rw, r, "/threads/"+t.ID.String(), http.StatusFound)
+	}
+}
+
 func (h *ThreadHandler) Delete() http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
 		id, err := uuid.Parse(chi.URLParam(r, "id"))
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusBadRequest)
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		if !h.policy.For(r.Context()).DeleteThread(t) {
+			renderError(rw, r, http.StatusForbidden)
+			return
+		}
+
+		err = h.store.DeleteThread(r.Context(), t.ID)
+		if err != nil {
+			httpError(rw, r, err)
 			return
 		}
 
-		err = h.store.DeleteThread(id)
+		err = logModAction(r.Context(), h.store, threadEntry(goreddit.ModDeleteThread, t))
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			httpError(rw, r, err)
 			return
 		}
 
@@ -148,3 +400,389 @@ func (h *ThreadHandler) Delete() http.HandlerFunc {
 		http.Redirect(rw, r, "/threads", http.StatusFound)
 	}
 }
+
+func (h *ThreadHandler) Subscribe() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
+		err = h.store.Subscribe(r.Context(), user.ID, id)
+		if errors.Is(err, goreddit.ErrInvalidReference) {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		http.Redirect(rw, r, "/threads/"+id.String(), http.StatusFound)
+	}
+}
+
+func (h *ThreadHandler) Unsubscribe() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
+		err = h.store.Unsubscribe(r.Context(), user.ID, id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		http.Redirect(rw, r, "/threads/"+id.String(), http.StatusFound)
+	}
+}
+
+func (h *ThreadHandler) InviteModerator() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		if !h.policy.For(r.Context()).Moderate(t.ID) {
+			renderError(rw, r, http.StatusForbidden)
+			return
+		}
+
+		form := InviteModeratorForm{
+			Username: r.FormValue("username"),
+		}
+		var invitee goreddit.User
+		if form.Username != "" {
+			invitee, err = h.store.UserByUsername(r.Context(), form.Username)
+			if errors.Is(err, goreddit.ErrNotFound) {
+				form.UnknownUser = true
+			} else if err != nil {
+				httpError(rw, r, err)
+				return
+			}
+		}
+		if !form.UnknownUser && form.Username != "" {
+			form.AlreadyModerator, err = h.store.IsModerator(r.Context(), t.ID, invitee.ID)
+			if err != nil {
+				httpError(rw, r, err)
+				return
+			}
+		}
+		if !form.Validate() {
+			h.sessions.Put(r.Context(), "form", form)
+			http.Redirect(rw, r, "/threads/"+t.ID.String(), http.StatusFound)
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
+		err = h.store.CreateInvite(r.Context(), &goreddit.Invite{
+			ThreadID:    t.ID,
+			UserID:      invitee.ID,
+			InvitedByID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		})
+		if errors.Is(err, goreddit.ErrConflict) {
+			form.AlreadyInvited = true
+			form.Validate()
+			h.sessions.Put(r.Context(), "form", form)
+			http.Redirect(rw, r, "/threads/"+t.ID.String(), http.StatusFound)
+			return
+		}
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		err = logModAction(r.Context(), h.store, userEntry(goreddit.ModInviteModerator, t, invitee))
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", invitee.Username+" has been invited to moderate this thread.")
+
+		http.Redirect(rw, r, "/threads/"+t.ID.String(), http.StatusFound)
+	}
+}
+
+func (h *ThreadHandler) AcceptInvite() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
+		err = h.store.AcceptInvite(r.Context(), t.ID, user.ID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		err = logModAction(r.Context(), h.store, userEntry(goreddit.ModAddModerator, t, user))
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", "You are now a moderator of this thread.")
+
+		http.Redirect(rw, r, "/threads/"+id.String(), http.StatusFound)
+	}
+}
+
+func (h *ThreadHandler) DeclineInvite() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		user, _ := userFromContext(r.Context())
+		err = h.store.DeleteInvite(r.Context(), id, user.ID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", "The invitation has been declined.")
+
+		http.Redirect(rw, r, r.Referer(), http.StatusFound)
+	}
+}
+
+func (h *ThreadHandler) RemoveModerator() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		userId, err := uuid.Parse(chi.URLParam(r, "userId"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		mm, err := h.store.Moderators(r.Context(), t.ID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		var target *goreddit.Moderator
+		for i := range mm {
+			if mm[i].UserID == userId {
+				target = &mm[i]
+			}
+		}
+		if target == nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		if !h.policy.For(r.Context()).RemoveModerator(mm, *target) {
+			renderError(rw, r, http.StatusForbidden)
+			return
+		}
+
+		err = h.store.RemoveModerator(r.Context(), t.ID, userId)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		removed := goreddit.User{ID: target.UserID, Username: target.Username}
+		err = logModAction(r.Context(), h.store, userEntry(goreddit.ModRemoveModerator, t, removed))
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", target.Username+" is no longer a moderator of this thread.")
+
+		http.Redirect(rw, r, "/threads/"+id.String(), http.StatusFound)
+	}
+}
+
+// Bans lists the users banned from a thread for its moderators to manage.
+func (h *ThreadHandler) Bans() http.HandlerFunc {
+	type data struct {
+		SessionData
+
+		CSRF      template.HTML
+		Thread    goreddit.Thread
+		Bans      []goreddit.Ban
+		Durations []banDuration
+		// Username fills in the form when following a link to ban a user.
+		Username string
+	}
+
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"thread_bans.html",
+	))
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		if !h.policy.For(r.Context()).Moderate(t.ID) {
+			renderError(rw, r, http.StatusForbidden)
+			return
+		}
+
+		bb, err := h.store.Bans(r.Context(), uuid.NullUUID{UUID: t.ID, Valid: true})
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		tmpl.Execute(rw, data{
+			Thread:      t,
+			Bans:        bb,
+			Durations:   banDurations,
+			Username:    r.URL.Query().Get("username"),
+			CSRF:        csrf.TemplateField(r),
+			SessionData: GetSessionData(h.sessions, r.Context()),
+		})
+	}
+}
+
+func (h *ThreadHandler) CreateBan() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		if !h.policy.For(r.Context()).Moderate(t.ID) {
+			renderError(rw, r, http.StatusForbidden)
+			return
+		}
+
+		form := BanForm{
+			Username: r.FormValue("username"),
+			Duration: r.FormValue("duration"),
+			Reason:   strings.TrimSpace(r.FormValue("reason")),
+		}
+		inThread := uuid.NullUUID{UUID: t.ID, Valid: true}
+		banned, err := checkBan(r.Context(), h.store, &form, inThread)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+		if !form.Validate() {
+			h.sessions.Put(r.Context(), "form", form)
+			http.Redirect(rw, r, "/threads/"+t.ID.String()+"/bans", http.StatusFound)
+			return
+		}
+
+		err = createBan(r.Context(), h.store, form, inThread, userEntry(goreddit.ModBan, t, banned))
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", banned.Username+" has been banned from this thread.")
+
+		http.Redirect(rw, r, "/threads/"+t.ID.String()+"/bans", http.StatusFound)
+	}
+}
+
+// LiftBan ends a ban from a thread before it expires.
+func (h *ThreadHandler) LiftBan() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		banId, err := uuid.Parse(chi.URLParam(r, "banId"))
+		if err != nil {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		t, err := h.store.Thread(r.Context(), id)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		b, err := h.store.Ban(r.Context(), banId)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+		if b.ThreadID.UUID != t.ID {
+			renderError(rw, r, http.StatusNotFound)
+			return
+		}
+
+		if !h.policy.For(r.Context()).Moderate(t.ID) {
+			renderError(rw, r, http.StatusForbidden)
+			return
+		}
+
+		err = h.store.LiftBan(r.Context(), b.ID)
+		if err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		unbanned := goreddit.User{ID: b.UserID, Username: b.Username}
+		if err := logModAction(r.Context(), h.store, userEntry(goreddit.ModUnban, t, unbanned)); err != nil {
+			httpError(rw, r, err)
+			return
+		}
+
+		h.sessions.Put(r.Context(), "flash", b.Username+" is no longer banned from this thread.")
+
+		http.Redirect(rw, r, "/threads/"+t.ID.String()+"/bans", http.StatusFound)
+	}
+}
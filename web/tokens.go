@@ -0,0 +1,90 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/aleury/goreddit"
+)
+
+// tokenPrefix starts every personal access token, so that leaked tokens are
+// easy to recognize.
+const tokenPrefix = "grd_"
+
+// generateToken returns a new random personal access token.
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hash under which a token is stored. Tokens are long
+// and random, so a fast unsalted hash is enough to make a leaked table
+// useless.
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+func tokenFromContext(ctx context.Context) (goreddit.Token, bool) {
+	token, ok := ctx.Value(ctxKey("token")).(goreddit.Token)
+	return token, ok
+}
+
+// withToken authenticates requests carrying an Authorization bearer token as
+// the token's user. Requests with an unknown token are rejected rather than
+// served anonymously, so that clients notice a revoked token.
+func (h *Handler) withToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if !hasBearerToken(r) {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		secret := strings.TrimSpace(strings.SplitN(r.Header.Get("Authorization"), " ", 2)[1])
+		token, err := h.store.TokenByHash(r.Context(), hashToken(secret))
+		if errors.Is(err, goreddit.ErrNotFound) {
+			rw.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			writeAPIError(rw, http.StatusUnauthorized, nil)
+			return
+		}
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		user, err := h.store.User(r.Context(), token.UserID)
+		if err != nil {
+			apiFail(rw, r, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), ctxKey("user"), user)
+		ctx = context.WithValue(ctx, ctxKey("token"), token)
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// requireScope rejects token-authenticated requests whose token lacks scope.
+// Requests authenticated by the session cookie may do anything their user
+// may.
+func requireScope(scope goreddit.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if token, ok := tokenFromContext(r.Context()); ok && !token.Scopes.Has(scope) {
+				rw.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+string(scope)+`"`)
+				writeAPIError(rw, http.StatusForbidden, map[string]string{"scope": "This token lacks the " + string(scope) + " scope."})
+				return
+			}
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aleury/goreddit"
+	"github.com/aleury/goreddit/memory"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func TestAPITokens(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	alice := goreddit.User{ID: uuid.New(), Username: "alice"}
+	if err := store.CreateUser(ctx, &alice); err != nil {
+		t.Fatal(err)
+	}
+
+	newToken := func(scopes ...goreddit.Scope) string {
+		t.Helper()
+		secret, err := generateToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = store.CreateToken(ctx, &goreddit.Token{
+			ID:     uuid.New(),
+			UserID: alice.ID,
+			Name:   "test",
+			Hash:   hashToken(secret),
+			Scopes: scopes,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return secret
+	}
+	readOnly := newToken(goreddit.ScopeRead)
+	readWrite := newToken(goreddit.ScopeRead, goreddit.ScopeWrite)
+
+	h := &Handler{store: store}
+	api := &APIHandler{store: store, policy: &Policy{store: store}, pageSize: 2}
+	r := chi.NewRouter()
+	r.Use(h.withToken)
+	r.With(requireScope(goreddit.ScopeRead)).Get("/threads", api.ListThreads())
+	r.With(requireAPIUser, requireScope(goreddit.ScopeRead)).Get("/me", api.ShowMe())
+	r.With(requireAPIUser, requireScope(goreddit.ScopeWrite)).Post("/threads", api.CreateThread())
+	r.With(requireAPIUser, requireScope(goreddit.ScopeVote)).Put("/posts/{id}/vote", api.CastPostVote())
+
+	do := func(method, path, body, token string) int {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		token  string
+		want   int
+	}{
+		{"anonymous me", "GET", "/me", "", "", http.StatusUnauthorized},
+		{"read me", "GET", "/me", "", readOnly, http.StatusOK},
+		{"unknown token", "GET", "/threads", "", "grd_bogus", http.StatusUnauthorized},
+		{"read create", "POST", "/threads", `{"title":"Go","description":"About Go"}`, readOnly, http.StatusForbidden},
+		{"write create", "POST", "/threads", `{"title":"Go","description":"About Go"}`, readWrite, http.StatusCreated},
+		{"write vote", "PUT", "/posts/" + uuid.NewString() + "/vote", `{"value":1}`, readWrite, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if got := do(tt.method, tt.path, tt.body, tt.token); got != tt.want {
+			t.Errorf("%s: %s %s = %d, want %d", tt.name, tt.method, tt.path, got, tt.want)
+		}
+	}
+}
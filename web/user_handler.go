@@ -1,6 +1,7 @@
 package web
 
 import (
+	"errors"
 	"html/template"
 	"net/http"
 
@@ -22,9 +23,9 @@ func (h *UserHandler) Register() http.HandlerFunc {
 		CSRF template.HTML
 	}
 
-	tmpl := template.Must(template.ParseFiles(
-		"templates/layout.html",
-		"templates/user_register.html",
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"user_register.html",
 	))
 	return func(rw http.ResponseWriter, r *http.Request) {
 		tmpl.Execute(rw, data{
@@ -41,8 +42,12 @@ func (h *UserHandler) RegisterSubmit() http.HandlerFunc {
 			Password:      r.FormValue("password"),
 			UsernameTaken: false,
 		}
-		if _, err := h.store.UserByUsername(form.Username); err == nil {
+		_, err := h.store.UserByUsername(r.Context(), form.Username)
+		if err == nil {
 			form.UsernameTaken = true
+		} else if !errors.Is(err, goreddit.ErrNotFound) {
+			httpError(rw, r, err)
+			return
 		}
 		if !form.Validate() {
 			h.sessions.Put(r.Context(), "form", form)
@@ -52,17 +57,25 @@ func (h *UserHandler) RegisterSubmit() http.HandlerFunc {
 
 		password, err := bcrypt.GenerateFromPassword([]byte(form.Password), bcrypt.DefaultCost)
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			httpError(rw, r, err)
 			return
 		}
 
-		err = h.store.CreateUser(&goreddit.User{
+		err = h.store.CreateUser(r.Context(), &goreddit.User{
 			ID:       uuid.New(),
 			Username: form.Username,
 			Password: string(password),
 		})
+		if errors.Is(err, goreddit.ErrConflict) {
+			// The username was taken after it was checked above.
+			form.UsernameTaken = true
+			form.Validate()
+			h.sessions.Put(r.Context(), "form", form)
+			http.Redirect(rw, r, r.Referer(), http.StatusFound)
+			return
+		}
 		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			httpError(rw, r, err)
 			return
 		}
 
@@ -77,9 +90,9 @@ func (h *UserHandler) Login() http.HandlerFunc {
 		CSRF template.HTML
 	}
 
-	tmpl := template.Must(template.ParseFiles(
-		"templates/layout.html",
-		"templates/user_login.html",
+	tmpl := template.Must(parseTemplates(
+		"layout.html",
+		"user_login.html",
 	))
 	return func(rw http.ResponseWriter, r *http.Request) {
 		tmpl.Execute(rw, data{
@@ -96,9 +109,12 @@ func (h *UserHandler) LoginSubmit() http.HandlerFunc {
 			Password:             r.FormValue("password"),
 			IncorrectCredentials: false,
 		}
-		user, err := h.store.UserByUsername(form.Username)
-		if err != nil {
+		user, err := h.store.UserByUsername(r.Context(), form.Username)
+		if errors.Is(err, goreddit.ErrNotFound) {
 			form.IncorrectCredentials = true
+		} else if err != nil {
+			httpError(rw, r, err)
+			return
 		} else {
 			compareErr := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(form.Password))
 			form.IncorrectCredentials = compareErr != nil
@@ -0,0 +1,84 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aleury/goreddit"
+	"github.com/aleury/goreddit/memory"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func TestVoteNeedsPostWithCSRFToken(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	alice := newTestUser(t, store, "alice")
+
+	th := goreddit.Thread{ID: uuid.New(), Title: "Gophers"}
+	if err := store.CreateThread(ctx, &th); err != nil {
+		t.Fatal(err)
+	}
+	p := goreddit.Post{ID: uuid.New(), ThreadID: th.ID, Title: "Hello", Content: "Hello"}
+	if err := store.CreatePost(ctx, &p); err != nil {
+		t.Fatal(err)
+	}
+	postVote := "/threads/" + th.ID.String() + "/posts/" + p.ID.String() + "/vote"
+
+	h := NewHandler(store, NewMemorySessionManager(), Options{CSRFKey: make([]byte, 32), Features: Features{API: true}})
+	for _, tt := range []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, postVote + "?dir=up", http.StatusMethodNotAllowed},
+		{http.MethodPost, postVote, http.StatusForbidden},
+	} {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader("dir=up"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s without a CSRF token: status = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+
+	err := chi.Walk(h, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		// The API looks up votes with GET and casts them with PUT, and
+		// bearer tokens cannot be sent by other sites.
+		if strings.HasPrefix(route, "/api/") {
+			return nil
+		}
+		if strings.HasSuffix(route, "/vote") && method != http.MethodPost {
+			t.Errorf("%s %s: votes must not be cast with safe methods", method, route)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessions := NewMemorySessionManager()
+	posts := PostHandler{store: store, sessions: sessions, policy: &Policy{store: store}}
+	vote := func(dir string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, postVote, strings.NewReader(url.Values{"dir": {dir}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := serveRoute(sessions, "/threads/{threadId}/posts/{postId}/vote", posts.Vote(), &alice, req)
+		if rec.Code != http.StatusFound {
+			t.Fatalf("vote %s: status = %d, want 302", dir, rec.Code)
+		}
+	}
+	for _, step := range []struct {
+		dir  string
+		want int
+	}{{"up", 1}, {"up", 0}, {"down", -1}} {
+		vote(step.dir)
+		if got, err := store.UserPostVote(ctx, alice.ID, p.ID); err != nil || got != step.want {
+			t.Errorf("after voting %s: vote = %d, %v; want %d", step.dir, got, err, step.want)
+		}
+	}
+}